@@ -10,8 +10,11 @@ import (
 	"github.com/ekristen/aws-nuke/v3/pkg/common"
 
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/account"
+	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/audit"
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/completion"
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/config"
+	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/diff"
+	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/inventory"
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/list"
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
 	_ "github.com/ekristen/aws-nuke/v3/pkg/commands/version"
@@ -0,0 +1,224 @@
+// Package audit writes a tamper-evident log of the attempts to remove resources. Every record holds the hash of the
+// record before it, so a record that is modified, removed or reordered after the fact breaks the chain and is found
+// by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Genesis is the previous hash of the first record of a log
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// maxRecordSize is the largest record that Verify reads, resources can have many properties
+const maxRecordSize = 16 * 1024 * 1024
+
+// Result is the outcome of an attempt to remove a resource
+type Result string
+
+const (
+	// ResultRequested means the removal was requested, the resource may take some time to be gone
+	ResultRequested Result = "requested"
+
+	// ResultFailed means the removal failed, it is attempted again in the next iteration
+	ResultFailed Result = "failed"
+
+	// ResultHold means the resource is held back until the resources it depends on are removed
+	ResultHold Result = "hold"
+)
+
+// Record is a single attempt to remove a resource
+type Record struct {
+	Seq          int64             `json:"seq"`
+	Time         time.Time         `json:"time"`
+	CallerARN    string            `json:"caller_arn"`
+	Account      string            `json:"account"`
+	Region       string            `json:"region"`
+	ResourceType string            `json:"resource_type"`
+	Identifier   string            `json:"identifier"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	Attempt      int               `json:"attempt"`
+	Result       Result            `json:"result"`
+	Reason       string            `json:"reason,omitempty"`
+	PrevHash     string            `json:"prev_hash"`
+	Hash         string            `json:"hash,omitempty"`
+}
+
+// ComputeHash returns the hash of the record, which covers every field except the hash itself
+func (r *Record) ComputeHash() (string, error) {
+	unhashed := *r
+	unhashed.Hash = ""
+
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log is an append-only audit log file, it is safe for concurrent use by multiple accounts
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+	head string
+}
+
+// Open opens the audit log at path, creating it if it does not exist. The records of an existing log are verified
+// first and new records continue its chain, a log whose chain is broken is not appended to.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := Verify(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("refusing to append to audit log %s: %w", path, err)
+	}
+
+	return &Log{
+		file: f,
+		seq:  int64(summary.Records),
+		head: summary.Head,
+	}, nil
+}
+
+// Append chains the record to the log and writes it to disk before returning
+func (l *Log) Append(rec *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec.Seq = l.seq + 1
+	rec.PrevHash = l.head
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Time = rec.Time.UTC()
+
+	hash, err := rec.ComputeHash()
+	if err != nil {
+		return err
+	}
+	rec.Hash = hash
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	l.seq = rec.Seq
+	l.head = rec.Hash
+
+	return nil
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// Summary is the outcome of verifying a log
+type Summary struct {
+	// Records is the number of records in the log
+	Records int
+
+	// Head is the hash of the last record, or Genesis when the log is empty. Keeping a copy of it elsewhere allows
+	// detecting that records were removed from the end of the log.
+	Head string
+
+	// First and Last are the times of the first and last record
+	First time.Time
+	Last  time.Time
+}
+
+// ChainError is the first record at which the chain of a log is broken
+type ChainError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Verify reads the records of a log and checks that every record is intact and chained to the record before it
+func Verify(r io.Reader) (*Summary, error) {
+	summary := &Summary{Head: Genesis}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	line := 0
+	for s.Scan() {
+		line++
+
+		rec := &Record{}
+		if err := json.Unmarshal(s.Bytes(), rec); err != nil {
+			return nil, &ChainError{Line: line, Reason: fmt.Sprintf("invalid record: %s", err)}
+		}
+
+		if err := verifyRecord(rec, summary); err != nil {
+			return nil, &ChainError{Line: line, Reason: err.Error()}
+		}
+
+		summary.Records++
+		summary.Head = rec.Hash
+		if summary.Records == 1 {
+			summary.First = rec.Time
+		}
+		summary.Last = rec.Time
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// verifyRecord checks a single record against the records that came before it
+func verifyRecord(rec *Record, previous *Summary) error {
+	if expected := int64(previous.Records) + 1; rec.Seq != expected {
+		return fmt.Errorf("expected sequence number %d, found %d", expected, rec.Seq)
+	}
+
+	if rec.PrevHash != previous.Head {
+		return errors.New("previous hash does not match the hash of the record before it")
+	}
+
+	hash, err := rec.ComputeHash()
+	if err != nil {
+		return err
+	}
+
+	if rec.Hash != hash {
+		return errors.New("hash does not match the contents of the record")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord(identifier string, result Result) *Record {
+	return &Record{
+		Time:         time.Date(2024, 1, 10, 12, 0, 0, 123456789, time.UTC),
+		CallerARN:    "arn:aws:iam::123456789012:role/nuke",
+		Account:      "123456789012",
+		Region:       "us-east-1",
+		ResourceType: "EC2Instance",
+		Identifier:   identifier,
+		Properties:   map[string]string{"InstanceID": identifier, "tag:Name": "<web>"},
+		Attempt:      1,
+		Result:       result,
+	}
+}
+
+func writeTestLog(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l, err := Open(path)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Append(testRecord("i-1", ResultRequested)))
+	require.NoError(t, l.Append(testRecord("i-2", ResultFailed)))
+	require.NoError(t, l.Append(testRecord("i-3", ResultRequested)))
+	require.NoError(t, l.Close())
+
+	return path
+}
+
+func verifyFile(t *testing.T, path string) (*Summary, error) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	return Verify(f)
+}
+
+func TestLog_Verify(t *testing.T) {
+	path := writeTestLog(t)
+
+	summary, err := verifyFile(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Records)
+	assert.Len(t, summary.Head, 64)
+	assert.NotEqual(t, Genesis, summary.Head)
+
+	empty, err := Verify(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Records)
+	assert.Equal(t, Genesis, empty.Head)
+}
+
+func TestLog_Continue(t *testing.T) {
+	path := writeTestLog(t)
+
+	l, err := Open(path)
+	require.NoError(t, err)
+
+	rec := testRecord("i-4", ResultHold)
+	require.NoError(t, l.Append(rec))
+	require.NoError(t, l.Close())
+	assert.Equal(t, int64(4), rec.Seq)
+
+	summary, err := verifyFile(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, summary.Records)
+	assert.Equal(t, rec.Hash, summary.Head)
+}
+
+func TestLog_Tampered(t *testing.T) {
+	cases := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		expected string
+	}{
+		{
+			name: "modified",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"result":"failed"`, `"result":"requested"`, 1)
+				return lines
+			},
+			expected: "line 2: hash does not match the contents of the record",
+		},
+		{
+			name: "removed",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			expected: "line 2: expected sequence number 2, found 3",
+		},
+		{
+			name: "reordered",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			expected: "line 2: expected sequence number 2, found 3",
+		},
+		{
+			name: "truncated",
+			tamper: func(lines []string) []string {
+				lines[2] = lines[2][:20]
+				return lines
+			},
+			expected: "line 3: invalid record",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestLog(t)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			lines = tc.tamper(lines)
+
+			_, err = Verify(bytes.NewBufferString(strings.Join(lines, "\n")))
+			assert.ErrorContains(t, err, tc.expected)
+
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+			_, err = Open(path)
+			assert.ErrorContains(t, err, "refusing to append")
+		})
+	}
+}
+
+func TestLog_Rechained(t *testing.T) {
+	path := writeTestLog(t)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// rewriting a record and its own hash still breaks the chain at the record after it
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	rec := testRecord("i-1", ResultFailed)
+	rec.Seq = 1
+	rec.PrevHash = Genesis
+	rec.Hash, err = rec.ComputeHash()
+	require.NoError(t, err)
+
+	forged, err := json.Marshal(rec)
+	require.NoError(t, err)
+	lines[0] = string(forged)
+
+	_, err = Verify(strings.NewReader(strings.Join(lines, "\n")))
+	assert.ErrorContains(t, err, "line 2: previous hash does not match")
+}
@@ -28,6 +28,7 @@ type Account struct {
 
 func NewAccount(creds *Credentials, endpoints config.CustomEndpoints) (*Account, error) {
 	creds.CustomEndpoints = endpoints
+
 	account := Account{
 		Credentials: creds,
 	}
@@ -103,6 +104,16 @@ func NewAccount(creds *Credentials, endpoints config.CustomEndpoints) (*Account,
 	return &account, nil
 }
 
+// NewOfflineAccount creates an account that is only known by its ID and regions, such as the account of a previously
+// captured inventory. It has no credentials, aliases or sessions, nothing is ever requested from AWS for it.
+func NewOfflineAccount(id string, regions []string) *Account {
+	return &Account{
+		Credentials: &Credentials{},
+		id:          id,
+		regions:     regions,
+	}
+}
+
 // ID returns the account ID
 func (a *Account) ID() string {
 	return a.id
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -59,7 +60,8 @@ func (c *Credentials) NewConfig(ctx context.Context, region, serviceType string)
 		opts = append(opts,
 			config.WithRegion(region),
 			config.WithCredentialsProvider(c.awsNewStaticCredentialsV2()),
-			config.WithBaseEndpoint(customService.URL))
+			config.WithBaseEndpoint(customService.URL),
+			config.WithAPIOptions([]func(*middleware.Stack) error{addMetricsMiddleware, addTracingMiddleware}))
 
 		if customService.TLSInsecureSkipVerify {
 			client := &http.Client{
@@ -98,6 +100,15 @@ func (c *Credentials) NewConfig(ctx context.Context, region, serviceType string)
 		cfg = &cfgCopy
 	}
 
+	// the options of the root config are shared, clip them so that appending never writes into the shared array
+	if c.Throttles != nil {
+		cfg.APIOptions = append(slices.Clip(cfg.APIOptions), c.Throttles.addMiddleware)
+	}
+
+	if c.Retry != nil {
+		cfg.APIOptions = append(slices.Clip(cfg.APIOptions), c.Retry.addMiddleware)
+	}
+
 	return cfg, nil
 }
 
@@ -112,6 +123,8 @@ func (c *Credentials) rootConfig(ctx context.Context) (*aws.Config, error) {
 			return errors.Join(
 				stack.Finalize.Add(traceRequest{}, middleware.After),
 				stack.Deserialize.Add(traceResponse{}, middleware.After),
+				addMetricsMiddleware(stack),
+				addTracingMiddleware(stack),
 			)
 		},
 	}))
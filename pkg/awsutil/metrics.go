@@ -0,0 +1,67 @@
+package awsutil
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go/aws/request" //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/metrics"
+)
+
+// countRequestHandler counts every attempt of an API call made through an aws-sdk-go (v1) session
+func countRequestHandler(r *request.Request) {
+	metrics.APICallsTotal.Inc(r.ClientInfo.ServiceID, operationName(r))
+}
+
+// countThrottleHandler counts the attempts of an API call made through an aws-sdk-go (v1) session that were
+// throttled, it is registered as a retry handler which is only run for attempts that resulted in an error
+func countThrottleHandler(r *request.Request) {
+	if request.IsErrorThrottle(r.Error) {
+		metrics.APIThrottlesTotal.Inc(r.ClientInfo.ServiceID, operationName(r))
+	}
+}
+
+func operationName(r *request.Request) string {
+	if r.Operation == nil {
+		return ""
+	}
+
+	return r.Operation.Name
+}
+
+// addMetricsMiddleware adds the countRequest middleware to an aws-sdk-go-v2 stack. It is added after the retry
+// middleware so that every attempt is counted.
+func addMetricsMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(countRequest{}, middleware.After)
+}
+
+// countRequest counts every attempt of an API call made through an aws-sdk-go-v2 config, and the ones that were
+// throttled.
+type countRequest struct{}
+
+func (countRequest) ID() string {
+	return "aws-nuke::countRequest"
+}
+
+func (countRequest) HandleFinalize(
+	ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	middleware.FinalizeOutput, middleware.Metadata, error,
+) {
+	service := middleware.GetServiceID(ctx)
+	operation := middleware.GetOperationName(ctx)
+
+	metrics.APICallsTotal.Inc(service, operation)
+
+	out, md, err := next.HandleFinalize(ctx, in)
+	if err != nil && !errors.Is(err, context.Canceled) &&
+		retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		metrics.APIThrottlesTotal.Inc(service, operation)
+	}
+
+	return out, md, err
+}
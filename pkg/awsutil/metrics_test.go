@@ -0,0 +1,34 @@
+package awsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"          //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/client/metadata" //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/request"         //nolint:staticcheck
+
+	"github.com/ekristen/aws-nuke/v3/pkg/metrics"
+)
+
+func TestCountHandlers(t *testing.T) {
+	r := &request.Request{
+		ClientInfo: metadata.ClientInfo{ServiceID: "Test Service"},
+		Operation:  &request.Operation{Name: "DescribeThings"},
+	}
+
+	calls := metrics.APICallsTotal.Value("Test Service", "DescribeThings")
+	throttles := metrics.APIThrottlesTotal.Value("Test Service", "DescribeThings")
+
+	countRequestHandler(r)
+	r.Error = awserr.New("AccessDenied", "denied", nil)
+	countThrottleHandler(r)
+
+	countRequestHandler(r)
+	r.Error = awserr.New("ThrottlingException", "slow down", nil)
+	countThrottleHandler(r)
+
+	assert.Equal(t, calls+2, metrics.APICallsTotal.Value("Test Service", "DescribeThings"))
+	assert.Equal(t, throttles+1, metrics.APIThrottlesTotal.Value("Test Service", "DescribeThings"))
+}
@@ -0,0 +1,104 @@
+package awsutil
+
+import (
+	"github.com/gotidy/ptr"
+	"github.com/pkg/errors"
+
+	"github.com/aws/aws-sdk-go/aws"                                      //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"                 //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/service/organizations"                    //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface" //nolint:staticcheck
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+// Organization looks up the account in AWS Organizations. The role is assumed for the lookup when it is given, the
+// credentials of the account can usually not describe their own organization.
+func (a *Account) Organization(roleArn string) (*config.OrganizationAccount, error) {
+	sess, err := a.NewSession(GlobalRegionID, "organizations")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create session in %s", GlobalRegionID)
+	}
+
+	if roleArn != "" {
+		sess = sess.Copy(&aws.Config{
+			Credentials: stscreds.NewCredentials(sess, roleArn),
+		})
+	}
+
+	return DescribeOrganizationAccount(organizations.New(sess), a.ID())
+}
+
+// DescribeOrganizationAccount returns the account as it is known to the organization it is a member of
+func DescribeOrganizationAccount(svc organizationsiface.OrganizationsAPI, accountID string) (*config.OrganizationAccount, error) {
+	orgOutput, err := svc.DescribeOrganization(&organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe organization")
+	}
+
+	accountOutput, err := svc.DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: ptr.String(accountID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe account")
+	}
+
+	account := &config.OrganizationAccount{
+		ID:                  accountID,
+		Name:                ptr.ToString(accountOutput.Account.Name),
+		ManagementAccountID: ptr.ToString(orgOutput.Organization.MasterAccountId),
+		Tags:                make(map[string]string),
+	}
+
+	// Walk up from the account to the root, the account is in every organizational unit on the way
+	childID := accountID
+	for {
+		var parent *organizations.Parent
+		if err := svc.ListParentsPages(&organizations.ListParentsInput{
+			ChildId: ptr.String(childID),
+		}, func(page *organizations.ListParentsOutput, _ bool) bool {
+			if len(page.Parents) > 0 {
+				parent = page.Parents[0]
+			}
+			return parent == nil
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed to list parents")
+		}
+
+		if parent == nil {
+			break
+		}
+
+		account.Parents = append(account.Parents, ptr.ToString(parent.Id))
+		if ptr.ToString(parent.Type) == organizations.ParentTypeRoot {
+			break
+		}
+
+		childID = ptr.ToString(parent.Id)
+	}
+
+	if err := svc.ListTagsForResourcePages(&organizations.ListTagsForResourceInput{
+		ResourceId: ptr.String(accountID),
+	}, func(page *organizations.ListTagsForResourceOutput, _ bool) bool {
+		for _, tag := range page.Tags {
+			account.Tags[ptr.ToString(tag.Key)] = ptr.ToString(tag.Value)
+		}
+		return true
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to list account tags")
+	}
+
+	if err := svc.ListDelegatedAdministratorsPages(&organizations.ListDelegatedAdministratorsInput{},
+		func(page *organizations.ListDelegatedAdministratorsOutput, _ bool) bool {
+			for _, admin := range page.DelegatedAdministrators {
+				if ptr.ToString(admin.Id) == accountID {
+					account.DelegatedAdministrator = true
+				}
+			}
+			return !account.DelegatedAdministrator
+		}); err != nil {
+		return nil, errors.Wrap(err, "failed to list delegated administrators")
+	}
+
+	return account, nil
+}
@@ -0,0 +1,99 @@
+package awsutil
+
+import (
+	"testing"
+
+	"github.com/gotidy/ptr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"                               //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/service/organizations"                    //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface" //nolint:staticcheck
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+type fakeOrganizations struct {
+	organizationsiface.OrganizationsAPI
+
+	parents map[string]*organizations.Parent
+	admins  []string
+}
+
+func (f *fakeOrganizations) DescribeOrganization(
+	_ *organizations.DescribeOrganizationInput) (*organizations.DescribeOrganizationOutput, error) {
+	return &organizations.DescribeOrganizationOutput{
+		Organization: &organizations.Organization{MasterAccountId: ptr.String("111111111111")},
+	}, nil
+}
+
+func (f *fakeOrganizations) DescribeAccount(
+	input *organizations.DescribeAccountInput) (*organizations.DescribeAccountOutput, error) {
+	return &organizations.DescribeAccountOutput{
+		Account: &organizations.Account{Id: input.AccountId, Name: ptr.String("team-sandbox")},
+	}, nil
+}
+
+func (f *fakeOrganizations) ListParentsPages(input *organizations.ListParentsInput,
+	fn func(*organizations.ListParentsOutput, bool) bool) error {
+	parent, ok := f.parents[ptr.ToString(input.ChildId)]
+	if !ok {
+		return awserr.New(organizations.ErrCodeChildNotFoundException, "child not found", nil)
+	}
+
+	fn(&organizations.ListParentsOutput{Parents: []*organizations.Parent{parent}}, true)
+	return nil
+}
+
+func (f *fakeOrganizations) ListTagsForResourcePages(_ *organizations.ListTagsForResourceInput,
+	fn func(*organizations.ListTagsForResourceOutput, bool) bool) error {
+	fn(&organizations.ListTagsForResourceOutput{Tags: []*organizations.Tag{
+		{Key: ptr.String("environment"), Value: ptr.String("sandbox")},
+	}}, false)
+	fn(&organizations.ListTagsForResourceOutput{Tags: []*organizations.Tag{
+		{Key: ptr.String("owner"), Value: ptr.String("team")},
+	}}, true)
+	return nil
+}
+
+func (f *fakeOrganizations) ListDelegatedAdministratorsPages(_ *organizations.ListDelegatedAdministratorsInput,
+	fn func(*organizations.ListDelegatedAdministratorsOutput, bool) bool) error {
+	page := &organizations.ListDelegatedAdministratorsOutput{}
+	for _, id := range f.admins {
+		page.DelegatedAdministrators = append(page.DelegatedAdministrators,
+			&organizations.DelegatedAdministrator{Id: ptr.String(id)})
+	}
+
+	fn(page, true)
+	return nil
+}
+
+func TestDescribeOrganizationAccount(t *testing.T) {
+	svc := &fakeOrganizations{
+		parents: map[string]*organizations.Parent{
+			"555133742":       {Id: ptr.String("ou-abcd-team"), Type: ptr.String(organizations.ParentTypeOrganizationalUnit)},
+			"ou-abcd-team":    {Id: ptr.String("ou-abcd-sandbox"), Type: ptr.String(organizations.ParentTypeOrganizationalUnit)},
+			"ou-abcd-sandbox": {Id: ptr.String("r-abcd"), Type: ptr.String(organizations.ParentTypeRoot)},
+		},
+		admins: []string{"222222222222"},
+	}
+
+	account, err := DescribeOrganizationAccount(svc, "555133742")
+	assert.NoError(t, err)
+	assert.Equal(t, &config.OrganizationAccount{
+		ID:                  "555133742",
+		Name:                "team-sandbox",
+		ManagementAccountID: "111111111111",
+		Parents:             []string{"ou-abcd-team", "ou-abcd-sandbox", "r-abcd"},
+		Tags:                map[string]string{"environment": "sandbox", "owner": "team"},
+	}, account)
+
+	account, err = DescribeOrganizationAccount(svc, "222222222222")
+	assert.Error(t, err, "the parents of an unknown account cannot be listed")
+	assert.Nil(t, account)
+
+	svc.parents["222222222222"] = svc.parents["555133742"]
+	account, err = DescribeOrganizationAccount(svc, "222222222222")
+	assert.NoError(t, err)
+	assert.True(t, account.DelegatedAdministrator)
+}
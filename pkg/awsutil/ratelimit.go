@@ -0,0 +1,154 @@
+package awsutil
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// limiterMinRate is the lowest rate in calls per second that a service is limited to
+	limiterMinRate = 0.5
+
+	// limiterBackoff is the factor the rate is multiplied by when a call is throttled
+	limiterBackoff = 0.7
+
+	// limiterIncrease is the number of calls per second the rate is raised by every second without throttling
+	limiterIncrease = 1.0
+)
+
+// AdaptiveLimiter is a client side rate limiter that only starts limiting once a call was throttled. The rate is
+// then lowered to a fraction of the rate at which calls were made, lowered further with every throttled call and
+// raised again slowly for as long as calls succeed.
+type AdaptiveLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	enabled bool
+
+	rate   float64
+	tokens float64
+	filled time.Time
+
+	// the rate at which calls are made is measured over windows of a second
+	windowStart time.Time
+	windowCalls int
+	measured    float64
+
+	lastChange time.Time
+}
+
+// NewAdaptiveLimiter creates a limiter that does not limit anything until the first call is throttled
+func NewAdaptiveLimiter() *AdaptiveLimiter {
+	return &AdaptiveLimiter{now: time.Now}
+}
+
+// Rate returns the current limit in calls per second, zero when calls are not limited
+func (l *AdaptiveLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return 0
+	}
+
+	return l.rate
+}
+
+// Wait blocks until the next call may be made, or until the context is done
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token for the next call and returns how long to wait before making it
+func (l *AdaptiveLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.measure(now)
+
+	if !l.enabled {
+		return 0
+	}
+
+	l.tokens = math.Min(math.Max(1, l.rate), l.tokens+now.Sub(l.filled).Seconds()*l.rate)
+	l.filled = now
+	l.tokens--
+
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// measure counts the call towards the rate at which calls are made
+func (l *AdaptiveLimiter) measure(now time.Time) {
+	if l.windowStart.IsZero() {
+		l.windowStart = now
+	}
+
+	if elapsed := now.Sub(l.windowStart); elapsed >= time.Second {
+		l.measured = float64(l.windowCalls) / elapsed.Seconds()
+		l.windowStart = now
+		l.windowCalls = 0
+	}
+
+	l.windowCalls++
+}
+
+// Throttled lowers the rate after a call was throttled
+func (l *AdaptiveLimiter) Throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	rate := l.rate
+	if !l.enabled {
+		// start from the rate at which calls were made, the current window counts if no window has completed yet
+		rate = l.measured
+		if elapsed := now.Sub(l.windowStart).Seconds(); rate == 0 && elapsed > 0 {
+			rate = float64(l.windowCalls) / math.Max(1, elapsed)
+		}
+
+		l.enabled = true
+		l.tokens = 0
+		l.filled = now
+	}
+
+	l.rate = math.Max(limiterMinRate, rate*limiterBackoff)
+	l.lastChange = now
+}
+
+// Succeeded raises the rate after a call succeeded, at most once a second
+func (l *AdaptiveLimiter) Succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return
+	}
+
+	now := l.now()
+	if now.Sub(l.lastChange) < time.Second {
+		return
+	}
+
+	l.rate += limiterIncrease
+	l.lastChange = now
+}
@@ -0,0 +1,322 @@
+package awsutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go/aws/client"  //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/request" //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+const (
+	// DefaultMaxAttempts is the maximum number of attempts of a call, including the first one
+	DefaultMaxAttempts = 5
+
+	// DefaultBaseBackoff is the backoff before the first retry
+	DefaultBaseBackoff = 100 * time.Millisecond
+
+	// DefaultMaxBackoff is the maximum backoff between two attempts
+	DefaultMaxBackoff = 20 * time.Second
+)
+
+// retrySettings are the resolved settings of the retry policy for a single service
+type retrySettings struct {
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	adaptive    bool
+}
+
+// merge overrides the settings with the values that are set
+func (s retrySettings) merge(o *config.RetrySettings) retrySettings {
+	if o == nil {
+		return s
+	}
+
+	if o.MaxAttempts > 0 {
+		s.maxAttempts = o.MaxAttempts
+	}
+
+	if o.BaseBackoff > 0 {
+		s.baseBackoff = o.BaseBackoff
+	}
+
+	if o.MaxBackoff > 0 {
+		s.maxBackoff = o.MaxBackoff
+	}
+
+	if o.Adaptive != nil {
+		s.adaptive = *o.Adaptive
+	}
+
+	return s
+}
+
+// backoff returns the delay before the given retry, the first retry is 1. The delay is drawn at random between zero
+// and the exponential backoff, which spreads out the retries of calls that were throttled at the same time.
+func (s retrySettings) backoff(retry int) time.Duration {
+	ceiling := float64(s.baseBackoff) * math.Pow(2, float64(retry-1))
+	if ceiling > float64(s.maxBackoff) || math.IsInf(ceiling, 0) {
+		ceiling = float64(s.maxBackoff)
+	}
+
+	return time.Duration(rand.Float64() * ceiling) //nolint:gosec
+}
+
+// RetryPolicy is the retry policy of the calls made to the AWS APIs by a single account. It also keeps track of the
+// adaptive rate limit of every service in every region.
+type RetryPolicy struct {
+	defaults retrySettings
+	services map[string]retrySettings
+
+	mu       sync.Mutex
+	limiters map[string]*AdaptiveLimiter
+}
+
+// NewRetryPolicy creates the retry policy from the configuration. Without a configuration there is no policy, the calls
+// are retried the way the AWS SDKs retry them by default.
+func NewRetryPolicy(cfg *config.Retry) (*RetryPolicy, error) {
+	if cfg == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	p := &RetryPolicy{
+		defaults: retrySettings{
+			maxAttempts: DefaultMaxAttempts,
+			baseBackoff: DefaultBaseBackoff,
+			maxBackoff:  DefaultMaxBackoff,
+			adaptive:    true,
+		},
+		services: make(map[string]retrySettings),
+		limiters: make(map[string]*AdaptiveLimiter),
+	}
+
+	if err := validateRetrySettings("retry", &cfg.RetrySettings); err != nil {
+		return nil, err
+	}
+	p.defaults = p.defaults.merge(&cfg.RetrySettings)
+
+	for service, settings := range cfg.Services {
+		if err := validateRetrySettings(fmt.Sprintf("retry.services.%s", service), settings); err != nil {
+			return nil, err
+		}
+		p.services[ServiceKey(service)] = p.defaults.merge(settings)
+	}
+
+	for service, settings := range p.services {
+		if settings.baseBackoff > settings.maxBackoff {
+			return nil, fmt.Errorf("retry.services.%s: base-backoff must not be greater than max-backoff", service)
+		}
+	}
+
+	if p.defaults.baseBackoff > p.defaults.maxBackoff {
+		return nil, fmt.Errorf("retry: base-backoff must not be greater than max-backoff")
+	}
+
+	return p, nil
+}
+
+func validateRetrySettings(path string, s *config.RetrySettings) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("%s: max-attempts must not be negative", path)
+	}
+
+	if s.BaseBackoff < 0 || s.MaxBackoff < 0 {
+		return fmt.Errorf("%s: backoff must not be negative", path)
+	}
+
+	return nil
+}
+
+// ServiceKey normalizes the ID of a service, such as Route 53 or CloudControl, into the key that is used in the
+// configuration, such as route53 or cloudcontrol
+func ServiceKey(serviceID string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(serviceID))
+}
+
+func (p *RetryPolicy) settings(service string) retrySettings {
+	if s, ok := p.services[service]; ok {
+		return s
+	}
+
+	return p.defaults
+}
+
+// limiter returns the adaptive rate limiter of the service in the region, nil if the service is not rate limited
+func (p *RetryPolicy) limiter(service, region string) *AdaptiveLimiter {
+	if !p.settings(service).adaptive {
+		return nil
+	}
+
+	key := service + "/" + region
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l, ok := p.limiters[key]
+	if !ok {
+		l = NewAdaptiveLimiter()
+		p.limiters[key] = l
+	}
+
+	return l
+}
+
+// throttled lowers the rate limit of the service in the region after a call was throttled
+func (p *RetryPolicy) throttled(service, region string) {
+	if l := p.limiter(service, region); l != nil {
+		l.Throttled()
+	}
+}
+
+// rate returns the lowest rate limit in calls per second of the service across regions, zero if it was never limited
+func (p *RetryPolicy) rate(service string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lowest := float64(0)
+	for key, l := range p.limiters {
+		if !strings.HasPrefix(key, service+"/") {
+			continue
+		}
+
+		if rate := l.Rate(); rate > 0 && (lowest == 0 || rate < lowest) {
+			lowest = rate
+		}
+	}
+
+	return lowest
+}
+
+// addHandlers applies the policy to an aws-sdk-go (v1) session. The retryer is chosen per request, as the service is
+// not known when the session is created.
+func (p *RetryPolicy) addHandlers(handlers *request.Handlers) {
+	handlers.Validate.PushBack(func(r *request.Request) {
+		s := p.settings(ServiceKey(r.ClientInfo.ServiceID))
+		r.Retryer = client.DefaultRetryer{
+			NumMaxRetries:    s.maxAttempts - 1,
+			MinRetryDelay:    s.baseBackoff,
+			MinThrottleDelay: s.baseBackoff,
+			MaxRetryDelay:    s.maxBackoff,
+			MaxThrottleDelay: s.maxBackoff,
+		}
+	})
+
+	// Signing happens before every attempt, waiting there makes retries respect the rate limit as well
+	handlers.Sign.PushFront(func(r *request.Request) {
+		l := p.limiter(ServiceKey(r.ClientInfo.ServiceID), aws.ToString(r.Config.Region))
+		if l == nil {
+			return
+		}
+
+		if err := l.Wait(r.Context()); err != nil {
+			r.Error = err
+		}
+	})
+
+	handlers.Retry.PushFront(func(r *request.Request) {
+		if request.IsErrorThrottle(r.Error) {
+			p.throttled(ServiceKey(r.ClientInfo.ServiceID), aws.ToString(r.Config.Region))
+		}
+	})
+
+	handlers.Complete.PushBack(func(r *request.Request) {
+		if r.Error != nil {
+			return
+		}
+
+		if l := p.limiter(ServiceKey(r.ClientInfo.ServiceID), aws.ToString(r.Config.Region)); l != nil {
+			l.Succeeded()
+		}
+	})
+}
+
+// addMiddleware applies the policy to an aws-sdk-go-v2 stack. The retry middleware that the client added is replaced
+// with one that uses the settings of the service, and every attempt waits for the rate limit of the service.
+func (p *RetryPolicy) addMiddleware(stack *middleware.Stack) error {
+	service := ""
+	if m, ok := stack.Initialize.Get("RegisterServiceMetadata"); ok {
+		if md, ok := m.(*awsmiddleware.RegisterServiceMetadata); ok {
+			service = ServiceKey(md.ServiceID)
+		}
+	}
+
+	if _, ok := stack.Finalize.Get("Retry"); ok {
+		s := p.settings(service)
+		retryer := retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = s.maxAttempts
+			o.MaxBackoff = s.maxBackoff
+			o.Backoff = s
+			// the adaptive rate limit takes care of throttling, the retry quota would only fail calls early
+			o.RateLimiter = ratelimit.None
+		})
+
+		if _, err := stack.Finalize.Swap("Retry", retry.NewAttemptMiddleware(retryer, smithyhttp.RequestCloner)); err != nil {
+			return err
+		}
+	}
+
+	return stack.Finalize.Add(limitRequest{policy: p}, middleware.After)
+}
+
+// BackoffDelay implements retry.BackoffDelayer, attempt is the number of the retry
+func (s retrySettings) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	return s.backoff(attempt), nil
+}
+
+// limitRequest waits for the rate limit of the service before every attempt, and lowers it for the attempts that were
+// throttled. It is added after the retry middleware so that every attempt passes through it.
+type limitRequest struct {
+	policy *RetryPolicy
+}
+
+func (limitRequest) ID() string {
+	return "aws-nuke::limitRequest"
+}
+
+func (m limitRequest) HandleFinalize(
+	ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	out middleware.FinalizeOutput, md middleware.Metadata, err error,
+) {
+	service := ServiceKey(awsmiddleware.GetServiceID(ctx))
+	region := awsmiddleware.GetRegion(ctx)
+
+	l := m.policy.limiter(service, region)
+	if l != nil {
+		if err := l.Wait(ctx); err != nil {
+			return out, md, err
+		}
+	}
+
+	out, md, err = next.HandleFinalize(ctx, in)
+	switch {
+	case err == nil:
+		if l != nil {
+			l.Succeeded()
+		}
+	case !errors.Is(err, context.Canceled) &&
+		retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary:
+		m.policy.throttled(service, region)
+	}
+
+	return out, md, err
+}
@@ -0,0 +1,204 @@
+package awsutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gotidy/ptr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go/aws/awserr"          //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/client/metadata" //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/request"         //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+func TestServiceKey(t *testing.T) {
+	assert.Equal(t, "route53", ServiceKey("Route 53"))
+	assert.Equal(t, "cloudcontrol", ServiceKey("CloudControl"))
+	assert.Equal(t, "iam", ServiceKey("IAM"))
+	assert.Equal(t, "resourcegroupstaggingapi", ServiceKey("Resource Groups Tagging API"))
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	// without a retry section the retryers of the SDKs are left alone
+	p, err := NewRetryPolicy(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	p, err = NewRetryPolicy(&config.Retry{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxAttempts, p.settings("iam").maxAttempts)
+	assert.True(t, p.settings("iam").adaptive)
+
+	p, err = NewRetryPolicy(&config.Retry{
+		RetrySettings: config.RetrySettings{
+			MaxAttempts: 8,
+			MaxBackoff:  time.Minute,
+		},
+		Services: map[string]*config.RetrySettings{
+			"CloudControl": {MaxAttempts: 12},
+			"iam":          {Adaptive: ptr.Bool(false), BaseBackoff: time.Second},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, p.settings("ec2").maxAttempts)
+	assert.Equal(t, DefaultBaseBackoff, p.settings("ec2").baseBackoff)
+	assert.Equal(t, time.Minute, p.settings("ec2").maxBackoff)
+
+	assert.Equal(t, 12, p.settings("cloudcontrol").maxAttempts)
+	assert.Equal(t, time.Minute, p.settings("cloudcontrol").maxBackoff)
+
+	assert.Equal(t, 8, p.settings("iam").maxAttempts)
+	assert.Equal(t, time.Second, p.settings("iam").baseBackoff)
+	assert.Nil(t, p.limiter("iam", "global"))
+	assert.NotNil(t, p.limiter("ec2", "us-east-1"))
+	assert.Same(t, p.limiter("ec2", "us-east-1"), p.limiter("ec2", "us-east-1"))
+
+	for _, cfg := range []*config.Retry{
+		{RetrySettings: config.RetrySettings{MaxAttempts: -1}},
+		{RetrySettings: config.RetrySettings{BaseBackoff: time.Minute, MaxBackoff: time.Second}},
+		{Services: map[string]*config.RetrySettings{"iam": {BaseBackoff: time.Hour}}},
+		{Services: map[string]*config.RetrySettings{"iam": {MaxBackoff: -time.Second}}},
+	} {
+		_, err := NewRetryPolicy(cfg)
+		assert.Error(t, err)
+	}
+}
+
+func TestRetrySettings_Backoff(t *testing.T) {
+	s := retrySettings{baseBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, s.backoff(1), 100*time.Millisecond)
+		assert.LessOrEqual(t, s.backoff(3), 400*time.Millisecond)
+		assert.LessOrEqual(t, s.backoff(100), time.Second)
+		assert.GreaterOrEqual(t, s.backoff(2), time.Duration(0))
+	}
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewAdaptiveLimiter()
+	l.now = func() time.Time { return now }
+
+	// nothing is limited until a call is throttled
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, time.Duration(0), l.reserve())
+		now = now.Add(100 * time.Millisecond)
+	}
+	assert.Equal(t, float64(0), l.Rate())
+	l.Succeeded()
+	assert.Equal(t, float64(0), l.Rate())
+
+	// calls were made at 10 per second, the rate drops to 7 per second
+	l.Throttled()
+	assert.InDelta(t, 7, l.Rate(), 0.001)
+
+	// the calls that are made right after the throttle are spread out at the new rate
+	assert.InDelta(t, float64(time.Second/7), float64(l.reserve()), float64(time.Millisecond))
+	assert.InDelta(t, float64(2*time.Second/7), float64(l.reserve()), float64(time.Millisecond))
+
+	l.Throttled()
+	assert.InDelta(t, 4.9, l.Rate(), 0.001)
+
+	// the rate is raised at most once a second
+	l.Succeeded()
+	assert.InDelta(t, 4.9, l.Rate(), 0.001)
+	now = now.Add(time.Second)
+	l.Succeeded()
+	assert.InDelta(t, 5.9, l.Rate(), 0.001)
+
+	for i := 0; i < 20; i++ {
+		l.Throttled()
+	}
+	assert.Equal(t, limiterMinRate, l.Rate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.tokens = -10
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
+
+func TestRetryPolicy_Handlers(t *testing.T) {
+	p, err := NewRetryPolicy(&config.Retry{
+		Services: map[string]*config.RetrySettings{
+			"iam": {MaxAttempts: 3, Adaptive: ptr.Bool(false)},
+		},
+	})
+	assert.NoError(t, err)
+
+	var handlers request.Handlers
+	p.addHandlers(&handlers)
+
+	r := &request.Request{
+		ClientInfo:  metadata.ClientInfo{ServiceID: "IAM"},
+		Operation:   &request.Operation{Name: "ListRoles"},
+		HTTPRequest: &http.Request{},
+	}
+	r.Config.Region = ptr.String("us-east-1")
+
+	handlers.Validate.Run(r)
+	assert.Equal(t, 2, r.MaxRetries())
+
+	// iam is not rate limited, sts is and is limited once it was throttled
+	r.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	handlers.Retry.Run(r)
+	assert.Equal(t, float64(0), p.rate("iam"))
+
+	r.ClientInfo.ServiceID = "STS"
+	handlers.Retry.Run(r)
+	assert.Greater(t, p.rate("sts"), float64(0))
+}
+
+type throttlingTransport struct {
+	calls int
+}
+
+func (t *throttlingTransport) Do(_ *http.Request) (*http.Response, error) {
+	t.calls++
+
+	body := `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code>` +
+		`<Message>Rate exceeded</Message></Error><RequestId>1</RequestId></ErrorResponse>`
+
+	return &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": []string{"text/xml"}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}, nil
+}
+
+func TestRetryPolicy_Middleware(t *testing.T) {
+	p, err := NewRetryPolicy(&config.Retry{
+		Services: map[string]*config.RetrySettings{
+			"sts": {MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Adaptive: ptr.Bool(false)},
+		},
+	})
+	assert.NoError(t, err)
+
+	counter := NewThrottleCounter()
+
+	transport := &throttlingTransport{}
+	client := sts.NewFromConfig(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:  transport,
+		APIOptions:  []func(*middleware.Stack) error{counter.addMiddleware, p.addMiddleware},
+	})
+
+	_, err = client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	assert.Error(t, err)
+
+	assert.Equal(t, 3, transport.calls)
+	assert.Equal(t, []ServiceThrottles{{Service: "sts", Throttles: 3}}, counter.Throttles(p))
+}
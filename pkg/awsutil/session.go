@@ -52,8 +52,15 @@ type Credentials struct {
 	Credentials *credentials.Credentials
 
 	CustomEndpoints config.CustomEndpoints
-	session         *session.Session
-	cfg             *awsv2.Config
+
+	// Retry is the retry policy of the calls made with the sessions and configs of these credentials
+	Retry *RetryPolicy
+
+	// Throttles counts the calls made with the sessions and configs of these credentials that were throttled
+	Throttles *ThrottleCounter
+
+	session *session.Session
+	cfg     *awsv2.Config
 }
 
 func (c *Credentials) HasProfile() bool {
@@ -224,6 +231,19 @@ func (c *Credentials) NewSession(region, serviceType string) (*session.Session,
 		log.Tracef("received AWS response:\n%s", DumpResponse(r.HTTPResponse))
 	})
 
+	sess.Handlers.Send.PushFront(countRequestHandler)
+	sess.Handlers.Retry.PushFront(countThrottleHandler)
+	sess.Handlers.Build.PushFront(startRequestSpanHandler)
+	sess.Handlers.Complete.PushBack(finishRequestSpanHandler)
+
+	if c.Throttles != nil {
+		c.Throttles.addHandlers(&sess.Handlers)
+	}
+
+	if c.Retry != nil {
+		c.Retry.addHandlers(&sess.Handlers)
+	}
+
 	if !isCustom {
 		sess.Handlers.Validate.PushFront(skipMissingServiceInRegionHandler)
 		sess.Handlers.Validate.PushFront(skipGlobalHandler(global))
@@ -0,0 +1,122 @@
+package awsutil
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go/aws/request" //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ThrottleCounter counts the calls to the AWS APIs of a single account that were throttled, per service. It counts
+// whether or not a retry policy is configured, the retryers of the SDKs are throttled just the same.
+type ThrottleCounter struct {
+	mu        sync.Mutex
+	throttles map[string]int
+}
+
+// NewThrottleCounter creates a counter without any throttled calls
+func NewThrottleCounter() *ThrottleCounter {
+	return &ThrottleCounter{
+		throttles: make(map[string]int),
+	}
+}
+
+// add records that a call to the service was throttled
+func (c *ThrottleCounter) add(service string) {
+	c.mu.Lock()
+	c.throttles[service]++
+	c.mu.Unlock()
+}
+
+// ServiceThrottles is the number of throttled calls to a service
+type ServiceThrottles struct {
+	Service   string
+	Throttles int
+
+	// Rate is the lowest rate limit in calls per second of the service across regions, zero if it was never limited
+	Rate float64
+}
+
+// Throttles returns the number of throttled calls per service, the most throttled service first. The rate limits are
+// taken from the retry policy, which may be nil.
+func (c *ThrottleCounter) Throttles(policy *RetryPolicy) []ServiceThrottles {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	throttles := make([]ServiceThrottles, 0, len(c.throttles))
+	for service, count := range c.throttles {
+		t := ServiceThrottles{Service: service, Throttles: count}
+		if policy != nil {
+			t.Rate = policy.rate(service)
+		}
+
+		throttles = append(throttles, t)
+	}
+
+	sort.Slice(throttles, func(i, j int) bool {
+		if throttles[i].Throttles != throttles[j].Throttles {
+			return throttles[i].Throttles > throttles[j].Throttles
+		}
+		return throttles[i].Service < throttles[j].Service
+	})
+
+	return throttles
+}
+
+// Total returns the number of throttled calls across all services
+func (c *ThrottleCounter) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, count := range c.throttles {
+		total += count
+	}
+
+	return total
+}
+
+// addHandlers counts the throttled attempts of the calls made through an aws-sdk-go (v1) session, it is registered as
+// a retry handler which is only run for attempts that resulted in an error
+func (c *ThrottleCounter) addHandlers(handlers *request.Handlers) {
+	handlers.Retry.PushFront(func(r *request.Request) {
+		if request.IsErrorThrottle(r.Error) {
+			c.add(ServiceKey(r.ClientInfo.ServiceID))
+		}
+	})
+}
+
+// addMiddleware adds the countThrottle middleware to an aws-sdk-go-v2 stack. It is added after the retry middleware
+// so that every attempt is counted.
+func (c *ThrottleCounter) addMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(countThrottle{counter: c}, middleware.After)
+}
+
+// countThrottle counts the throttled attempts of the calls made through an aws-sdk-go-v2 config
+type countThrottle struct {
+	counter *ThrottleCounter
+}
+
+func (countThrottle) ID() string {
+	return "aws-nuke::countThrottle"
+}
+
+func (m countThrottle) HandleFinalize(
+	ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	middleware.FinalizeOutput, middleware.Metadata, error,
+) {
+	out, md, err := next.HandleFinalize(ctx, in)
+	if err != nil && !errors.Is(err, context.Canceled) &&
+		retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		m.counter.add(ServiceKey(awsmiddleware.GetServiceID(ctx)))
+	}
+
+	return out, md, err
+}
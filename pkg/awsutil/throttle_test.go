@@ -0,0 +1,65 @@
+package awsutil
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go/aws/awserr"          //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/client/metadata" //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/request"         //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestThrottleCounter_Handlers(t *testing.T) {
+	c := NewThrottleCounter()
+
+	var handlers request.Handlers
+	c.addHandlers(&handlers)
+
+	r := &request.Request{
+		ClientInfo:  metadata.ClientInfo{ServiceID: "IAM"},
+		HTTPRequest: &http.Request{},
+	}
+
+	r.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	handlers.Retry.Run(r)
+	handlers.Retry.Run(r)
+	r.Error = awserr.New("AccessDenied", "denied", nil)
+	handlers.Retry.Run(r)
+
+	r.ClientInfo.ServiceID = "Route 53"
+	r.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	handlers.Retry.Run(r)
+
+	assert.Equal(t, []ServiceThrottles{
+		{Service: "iam", Throttles: 2},
+		{Service: "route53", Throttles: 1},
+	}, c.Throttles(nil))
+	assert.Equal(t, 3, c.Total())
+}
+
+func TestThrottleCounter_Middleware(t *testing.T) {
+	c := NewThrottleCounter()
+
+	// without a retry policy the calls are retried by the default retryer of the SDK, which is throttled just the same
+	transport := &throttlingTransport{}
+	client := sts.NewFromConfig(aws.Config{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:       transport,
+		RetryMaxAttempts: 2,
+		APIOptions:       []func(*middleware.Stack) error{c.addMiddleware},
+	})
+
+	_, err := client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	assert.Error(t, err)
+
+	assert.Equal(t, 2, transport.calls)
+	assert.Equal(t, []ServiceThrottles{{Service: "sts", Throttles: 2}}, c.Throttles(nil))
+}
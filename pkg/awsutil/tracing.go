@@ -0,0 +1,103 @@
+package awsutil
+
+import (
+	"context"
+	"fmt"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go/aws"         //nolint:staticcheck
+	"github.com/aws/aws-sdk-go/aws/request" //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
+)
+
+type requestSpanKey struct{}
+
+// startRequestSpanHandler starts a span for an API call made through an aws-sdk-go (v1) session, as a child of the
+// span in the context of the request. It is registered as a build handler which runs once per request.
+func startRequestSpanHandler(r *request.Request) {
+	if !tracing.Enabled() {
+		return
+	}
+
+	span := tracing.StartWithParent(tracing.SpanFromContext(r.Context()),
+		fmt.Sprintf("%s.%s", r.ClientInfo.ServiceID, operationName(r)),
+		tracing.String("rpc.system", "aws-api"),
+		tracing.String("rpc.service", r.ClientInfo.ServiceID),
+		tracing.String("rpc.method", operationName(r)),
+		tracing.String("cloud.region", aws.StringValue(r.Config.Region)),
+	)
+	span.SetKind(tracing.KindClient)
+
+	r.SetContext(context.WithValue(r.Context(), requestSpanKey{}, span))
+}
+
+// finishRequestSpanHandler finishes the span started by startRequestSpanHandler once the request has completed
+func finishRequestSpanHandler(r *request.Request) {
+	span, ok := r.Context().Value(requestSpanKey{}).(*tracing.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(tracing.Int("aws.retry_count", r.RetryCount))
+	if r.RequestID != "" {
+		span.SetAttributes(tracing.String("aws.request_id", r.RequestID))
+	}
+	if r.HTTPResponse != nil {
+		span.SetAttributes(tracing.Int("http.response.status_code", r.HTTPResponse.StatusCode))
+	}
+
+	span.SetError(r.Error)
+	span.Finish()
+}
+
+// addTracingMiddleware adds the traceSpan middleware to an aws-sdk-go-v2 stack. It is added in front of the retry
+// middleware so that there is a single span covering all attempts.
+func addTracingMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(traceSpan{}, middleware.Before)
+}
+
+// traceSpan creates a span for every API call made through an aws-sdk-go-v2 config, as a child of the span in the
+// context of the call.
+type traceSpan struct{}
+
+func (traceSpan) ID() string {
+	return "aws-nuke::traceSpan"
+}
+
+func (traceSpan) HandleFinalize(
+	ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	middleware.FinalizeOutput, middleware.Metadata, error,
+) {
+	if !tracing.Enabled() {
+		return next.HandleFinalize(ctx, in)
+	}
+
+	service := middleware.GetServiceID(ctx)
+	operation := middleware.GetOperationName(ctx)
+
+	_, span := tracing.Start(ctx, fmt.Sprintf("%s.%s", service, operation),
+		tracing.String("rpc.system", "aws-api"),
+		tracing.String("rpc.service", service),
+		tracing.String("rpc.method", operation),
+		tracing.String("cloud.region", awsmiddleware.GetRegion(ctx)),
+	)
+	span.SetKind(tracing.KindClient)
+	defer span.Finish()
+
+	out, md, err := next.HandleFinalize(ctx, in)
+
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+		span.SetAttributes(tracing.String("aws.request_id", requestID))
+	}
+	if resp, ok := awsmiddleware.GetRawResponse(md).(*smithyhttp.Response); ok && resp != nil {
+		span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	}
+
+	span.SetError(err)
+
+	return out, md, err
+}
@@ -0,0 +1,143 @@
+// Package baseline generates a preset whose filters keep every resource that exists in an account at the time it is
+// scanned, so that only the resources that are created afterward are removed.
+package baseline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// DefaultPresetName is the name of the preset when none is given
+const DefaultPresetName = "baseline"
+
+// Filter is a filter that matches a single resource by one of its properties
+type Filter struct {
+	Property string `yaml:"property"`
+	Value    string `yaml:"value"`
+}
+
+// Baseline are the filters that keep the resources that were discovered, by resource type
+type Baseline struct {
+	AccountID string
+	CreatedAt time.Time
+
+	// Resources is the number of resources that are kept by the baseline
+	Resources int
+
+	// Filters are the filters of each resource type, either a Filter or the string the resource is matched by
+	Filters map[string][]interface{}
+
+	// Unmatched are the number of resources per resource type that have no property or value to filter them by,
+	// these are not kept by the baseline
+	Unmatched map[string]int
+}
+
+// New creates the baseline of every item that was discovered, regardless of its state
+func New(accountID string, items []*queue.Item) *Baseline {
+	b := &Baseline{
+		AccountID: accountID,
+		CreatedAt: time.Now().UTC(),
+		Filters:   make(map[string][]interface{}),
+		Unmatched: make(map[string]int),
+	}
+
+	seen := make(map[string]map[interface{}]bool)
+	for _, item := range items {
+		f := FilterFor(item)
+		if f == nil {
+			b.Unmatched[item.Type]++
+			continue
+		}
+
+		b.Resources++
+
+		if seen[item.Type] == nil {
+			seen[item.Type] = make(map[interface{}]bool)
+		}
+
+		// Global resources and those that share a name across regions only need a single filter
+		if seen[item.Type][f] {
+			continue
+		}
+		seen[item.Type][f] = true
+
+		b.Filters[item.Type] = append(b.Filters[item.Type], f)
+	}
+
+	for _, filters := range b.Filters {
+		sort.SliceStable(filters, func(i, j int) bool {
+			return sortKey(filters[i]) < sortKey(filters[j])
+		})
+	}
+
+	return b
+}
+
+// Len returns the number of filters of the baseline
+func (b *Baseline) Len() int {
+	count := 0
+	for _, filters := range b.Filters {
+		count += len(filters)
+	}
+
+	return count
+}
+
+// FilterFor returns the filter that matches exactly the resource of the item, which is the most stable of its
+// identifying properties or otherwise its legacy name. Nil is returned when the resource has neither.
+func FilterFor(item *queue.Item) interface{} {
+	if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+		if property, v := nuke.IdentityProperty(getter.Properties()); property != "" {
+			return Filter{Property: property, Value: v}
+		}
+	}
+
+	if stringer, ok := item.Resource.(resource.LegacyStringer); ok {
+		if v := stringer.String(); v != "" {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// sortKey orders the filters of a resource type by their value
+func sortKey(f interface{}) string {
+	if filter, ok := f.(Filter); ok {
+		return filter.Value + "\x00" + filter.Property
+	}
+
+	return fmt.Sprint(f)
+}
+
+// Marshal returns the configuration that defines the baseline as a preset with the given name, with a header that
+// explains how the account refers to it
+func (b *Baseline) Marshal(presetName string) ([]byte, error) {
+	data, err := yaml.Marshal(map[string]interface{}{
+		"presets": map[string]interface{}{
+			presetName: map[string]interface{}{
+				"filters": b.Filters,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "# Baseline of the %d resources of account %s on %s.\n",
+		b.Resources, b.AccountID, b.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&header, "# Merge it into the config and add the preset to the account to keep these resources:\n")
+	fmt.Fprintf(&header, "#\n# accounts:\n#   %q:\n#     presets:\n#       - %s\n", b.AccountID, presetName)
+
+	return append([]byte(header.String()), data...), nil
+}
@@ -0,0 +1,140 @@
+package baseline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	arn  string
+	id   string
+	name string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return types.NewProperties().Set("ARN", r.arn).Set("ID", r.id).Set("Name", r.name).Set("tag:Owner", "team")
+}
+
+type testLegacyResource struct {
+	name string
+}
+
+func (r *testLegacyResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLegacyResource) String() string {
+	return r.name
+}
+
+type testUnnamedResource struct{}
+
+func (r *testUnnamedResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testUnnamedResource) Properties() types.Properties {
+	return types.NewProperties().Set("State", "available")
+}
+
+func TestFilterFor(t *testing.T) {
+	cases := []struct {
+		name     string
+		resource resource.Resource
+		want     interface{}
+	}{
+		{
+			name:     "arn",
+			resource: &testResource{arn: "arn:aws:sns:us-east-1:000000000000:alerts", id: "1", name: "alerts"},
+			want:     Filter{Property: "ARN", Value: "arn:aws:sns:us-east-1:000000000000:alerts"},
+		},
+		{
+			name:     "id",
+			resource: &testResource{id: "i-1", name: "web"},
+			want:     Filter{Property: "ID", Value: "i-1"},
+		},
+		{
+			name:     "name",
+			resource: &testResource{name: "web"},
+			want:     Filter{Property: "Name", Value: "web"},
+		},
+		{
+			name:     "legacy",
+			resource: &testLegacyResource{name: "s3://logs"},
+			want:     "s3://logs",
+		},
+		{
+			name:     "none",
+			resource: &testUnnamedResource{},
+			want:     nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := &queue.Item{Type: "Test", Resource: tc.resource}
+			assert.Equal(t, tc.want, FilterFor(item))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	items := []*queue.Item{
+		{Type: "EC2Instance", Owner: "us-west-2", State: queue.ItemStateNew, Resource: &testResource{id: "i-2"}},
+		{Type: "EC2Instance", Owner: "us-east-1", State: queue.ItemStateFiltered, Resource: &testResource{id: "i-1"}},
+		{Type: "IAMRole", Owner: "global", State: queue.ItemStateNew, Resource: &testResource{name: "admin"}},
+		{Type: "IAMRole", Owner: "global", State: queue.ItemStateNew, Resource: &testResource{name: "admin"}},
+		{Type: "S3Bucket", Owner: "global", State: queue.ItemStateNew, Resource: &testLegacyResource{name: "s3://logs"}},
+		{Type: "EC2Image", Owner: "us-east-1", State: queue.ItemStateNew, Resource: &testUnnamedResource{}},
+	}
+
+	b := New("000000000000", items)
+
+	assert.Equal(t, 5, b.Resources)
+	assert.Equal(t, 4, b.Len())
+	assert.Equal(t, map[string]int{"EC2Image": 1}, b.Unmatched)
+	assert.Equal(t, map[string][]interface{}{
+		"EC2Instance": {Filter{Property: "ID", Value: "i-1"}, Filter{Property: "ID", Value: "i-2"}},
+		"IAMRole":     {Filter{Property: "Name", Value: "admin"}},
+		"S3Bucket":    {"s3://logs"},
+	}, b.Filters)
+}
+
+func TestBaseline_Marshal(t *testing.T) {
+	b := New("000000000000", []*queue.Item{
+		{Type: "EC2Instance", Owner: "us-east-1", State: queue.ItemStateNew, Resource: &testResource{id: "i-1"}},
+		{Type: "S3Bucket", Owner: "global", State: queue.ItemStateNew, Resource: &testLegacyResource{name: "s3://logs"}},
+	})
+
+	data, err := b.Marshal("frozen")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# Baseline of the 2 resources of account 000000000000"))
+	assert.Contains(t, string(data), "#     presets:\n#       - frozen\n")
+
+	var parsed map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(data, &parsed))
+	assert.Equal(t, map[string]interface{}{
+		"presets": map[string]interface{}{
+			"frozen": map[string]interface{}{
+				"filters": map[string]interface{}{
+					"EC2Instance": []interface{}{
+						map[string]interface{}{"property": "ID", "value": "i-1"},
+					},
+					"S3Bucket": []interface{}{"s3://logs"},
+				},
+			},
+		},
+	}, parsed)
+}
@@ -0,0 +1,130 @@
+// Package cache stores the resources that were listed for an account, region and resource type on disk, so that
+// consecutive dry runs can reuse them instead of listing every resource type again.
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/settings"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+)
+
+// entry is the listing of a single resource type in a single region
+type entry struct {
+	ListedAt time.Time           `json:"listed_at"`
+	Settings json.RawMessage     `json:"settings"`
+	Records  []*inventory.Record `json:"records"`
+}
+
+// Cache is a directory with an entry per account, region and resource type. Entries expire once they are older than
+// the TTL, and they are only valid for the settings of the resource type they were listed with, because the settings
+// decide whether a resource refuses to be removed.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// New creates the cache in the directory, the directory is created when it does not exist yet
+func New(dir string, ttl time.Duration) (*Cache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("the cache ttl must be greater than zero")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create cache directory: %w", err)
+	}
+
+	return &Cache{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}, nil
+}
+
+// path returns the file of the entry, the separators of Cloud Control resource types are not valid in every file
+// system so they are replaced
+func (c *Cache) path(account, region, resourceType string) string {
+	return filepath.Join(c.dir, account, region, strings.ReplaceAll(resourceType, "::", ".")+".json")
+}
+
+// Get returns the records of the resource type in the region. It returns false when there is no entry, the entry has
+// expired, was listed with other settings or cannot be read, the resource type then has to be listed again.
+func (c *Cache) Get(account, region, resourceType string, setting *settings.Setting) ([]*inventory.Record, bool) {
+	data, err := os.ReadFile(c.path(account, region, resourceType))
+	if err != nil {
+		return nil, false
+	}
+
+	e := &entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, false
+	}
+
+	if c.now().Sub(e.ListedAt) > c.ttl {
+		return nil, false
+	}
+
+	current, err := json.Marshal(setting)
+	if err != nil || !bytes.Equal(current, e.Settings) {
+		return nil, false
+	}
+
+	return e.Records, true
+}
+
+// Put stores the records of the resource type in the region together with the settings they were listed with
+func (c *Cache) Put(account, region, resourceType string, setting *settings.Setting, records []*inventory.Record) error {
+	rawSettings, err := json.Marshal(setting)
+	if err != nil {
+		return err
+	}
+
+	if records == nil {
+		records = make([]*inventory.Record, 0)
+	}
+
+	data, err := json.Marshal(&entry{
+		ListedAt: c.now().UTC(),
+		Settings: rawSettings,
+		Records:  records,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := c.path(account, region, resourceType)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary file first, a run that reads it at the same time never sees half of it
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Invalidate removes every entry of the account
+func (c *Cache) Invalidate(account string) error {
+	return os.RemoveAll(filepath.Join(c.dir, account))
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/settings"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+)
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	_, err := New(dir, 0)
+	assert.Error(t, err)
+
+	c, err := New(dir, time.Hour)
+	assert.NoError(t, err)
+
+	listedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return listedAt }
+
+	records := []*inventory.Record{
+		{
+			Account: "000000000000", Region: "global", ResourceType: "IAMRole", Identifier: "admin",
+			Properties: map[string]string{"Name": "admin"},
+		},
+	}
+	setting := &settings.Setting{"IncludeServiceLinkedRoles": true}
+
+	_, ok := c.Get("000000000000", "global", "IAMRole", setting)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Put("000000000000", "global", "IAMRole", setting, records))
+	assert.NoError(t, c.Put("000000000000", "us-east-1", "AWS::EC2::VPC", &settings.Setting{}, nil))
+
+	cached, ok := c.Get("000000000000", "global", "IAMRole", setting)
+	assert.True(t, ok)
+	assert.Equal(t, records, cached)
+
+	// a resource type without any resources is cached as well
+	cached, ok = c.Get("000000000000", "us-east-1", "AWS::EC2::VPC", &settings.Setting{})
+	assert.True(t, ok)
+	assert.Empty(t, cached)
+	assert.FileExists(t, filepath.Join(dir, "000000000000", "us-east-1", "AWS.EC2.VPC.json"))
+
+	// other settings may change whether resources refuse to be removed
+	_, ok = c.Get("000000000000", "global", "IAMRole", &settings.Setting{})
+	assert.False(t, ok)
+
+	_, ok = c.Get("111111111111", "global", "IAMRole", setting)
+	assert.False(t, ok)
+
+	c.now = func() time.Time { return listedAt.Add(time.Hour + time.Second) }
+	_, ok = c.Get("000000000000", "global", "IAMRole", setting)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Invalidate("000000000000"))
+	_, err = os.Stat(filepath.Join(dir, "000000000000"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
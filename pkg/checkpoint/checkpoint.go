@@ -0,0 +1,279 @@
+// Package checkpoint persists the progress of a run to disk so that a run which was interrupted, or which failed part
+// way through, can be resumed without listing and removing everything from the beginning again.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// ReasonResumed is the reason given to resources that were being removed by a previous run and are now verified
+const ReasonResumed = "resumed from checkpoint"
+
+// Entry is the last known state of a single resource
+type Entry struct {
+	Type       string `json:"type"`
+	Region     string `json:"region"`
+	Identifier string `json:"identifier"`
+	State      string `json:"state"`
+	Reason     string `json:"reason,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+
+	// Key tells the resource apart from all other resources of its type in its region, see nuke.ResourceKey
+	Key string `json:"key"`
+
+	// Ambiguous is true when another resource shared the key, the entry is never restored as it may be either one
+	Ambiguous bool `json:"ambiguous,omitempty"`
+}
+
+func (e *Entry) key() string {
+	return fmt.Sprintf("%s|%s|%s", e.Type, e.Region, e.Key)
+}
+
+// Checkpoint is the state of a run for an account with a given configuration
+type Checkpoint struct {
+	AccountID  string    `json:"account_id"`
+	ConfigHash string    `json:"config_hash"`
+	Version    string    `json:"version"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Iteration  int       `json:"iteration"`
+	Entries    []*Entry  `json:"entries"`
+
+	// Drained are, per region, the resource types that were completely removed, see UpdateDrained
+	Drained map[string][]string `json:"drained,omitempty"`
+
+	index map[string]*Entry
+}
+
+// New creates a new empty Checkpoint
+func New(accountID, configHash, version string) *Checkpoint {
+	return &Checkpoint{
+		AccountID:  accountID,
+		ConfigHash: configHash,
+		Version:    version,
+		UpdatedAt:  time.Now().UTC(),
+		Entries:    make([]*Entry, 0),
+		index:      make(map[string]*Entry),
+	}
+}
+
+// Load reads a checkpoint from disk
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checkpoint{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unable to parse checkpoint %s: %w", path, err)
+	}
+
+	c.index = make(map[string]*Entry)
+	for _, e := range c.Entries {
+		c.index[e.key()] = e
+	}
+
+	return c, nil
+}
+
+// Save writes the checkpoint to disk. The file is written to a temporary file first and then renamed so that an
+// interruption while writing never leaves a truncated checkpoint behind.
+func (c *Checkpoint) Save(path string) error {
+	c.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Validate ensures the checkpoint was created for the same account, configuration and tool version
+func (c *Checkpoint) Validate(accountID, configHash, version string) error {
+	if c.AccountID != accountID {
+		return fmt.Errorf("checkpoint was created for account '%s', but the current account is '%s'",
+			c.AccountID, accountID)
+	}
+
+	if c.ConfigHash != configHash {
+		return fmt.Errorf("checkpoint was created with a different configuration file (hash mismatch)")
+	}
+
+	if c.Version != version {
+		return fmt.Errorf("checkpoint was created with version '%s', but the current version is '%s'",
+			c.Version, version)
+	}
+
+	return nil
+}
+
+// Update records the current state of every item. Entries for resources that are no longer in the queue, for example
+// because they were removed by a previous run, are kept as they are. Items that share their key are recorded as a
+// single ambiguous entry.
+func (c *Checkpoint) Update(items []*queue.Item, attempts func(*queue.Item) int) {
+	shared := sharedKeys(items)
+
+	for _, item := range items {
+		e := entryFromItem(item)
+		if attempts != nil {
+			e.Attempts = attempts(item)
+		}
+		e.Ambiguous = shared[e.key()]
+
+		if existing, ok := c.index[e.key()]; ok {
+			// Once ambiguous, the entry may hold the state of either resource
+			e.Ambiguous = e.Ambiguous || existing.Ambiguous
+			*existing = *e
+			continue
+		}
+
+		c.index[e.key()] = e
+		c.Entries = append(c.Entries, e)
+	}
+}
+
+// UpdateDrained records, per region, the resource types that were completely removed. A type is drained when its
+// listing was complete, it had at least one resource and every resource was either filtered or removed, which libnuke
+// only concludes once the resource no longer shows up when its type is listed again. A type without any resources is
+// never drained. Types that are not in the queue at all, because they were skipped as drained on resume, stay drained.
+// The incomplete resource types of each region are those whose listing failed or was cut short.
+func (c *Checkpoint) UpdateDrained(items []*queue.Item, incomplete map[string]map[string]error) {
+	type typeKey struct {
+		region, resourceType string
+	}
+
+	queued := make(map[typeKey]bool)
+	finished := make(map[typeKey]bool)
+	unfinished := make(map[typeKey]bool)
+
+	for _, item := range items {
+		k := typeKey{item.Owner, item.Type}
+		queued[k] = true
+
+		switch item.GetState() {
+		case queue.ItemStateFinished:
+			finished[k] = true
+		case queue.ItemStateFiltered:
+		default:
+			unfinished[k] = true
+		}
+	}
+
+	drained := make(map[string][]string)
+	for region, resourceTypes := range c.Drained {
+		for _, resourceType := range resourceTypes {
+			if !queued[typeKey{region, resourceType}] {
+				drained[region] = append(drained[region], resourceType)
+			}
+		}
+	}
+
+	for k := range finished {
+		if unfinished[k] {
+			continue
+		}
+
+		if _, ok := incomplete[k.region][k.resourceType]; ok {
+			continue
+		}
+
+		drained[k.region] = append(drained[k.region], k.resourceType)
+	}
+
+	for region := range drained {
+		sort.Strings(drained[region])
+	}
+
+	c.Drained = drained
+}
+
+// Restore applies the checkpoint to the items of a fresh scan. Items that the previous run had already asked to be
+// removed are moved to the waiting state so that their removal is verified instead of being requested again. The
+// number of removal attempts is restored through setAttempts. It returns the number of items that are verified.
+// Nothing is restored for items that cannot be told apart from another resource, now or in the previous run, those
+// are removed as if the previous run never saw them.
+func (c *Checkpoint) Restore(items []*queue.Item, setAttempts func(*queue.Item, int)) (verified int) {
+	shared := sharedKeys(items)
+
+	for _, item := range items {
+		key := entryFromItem(item).key()
+		e, ok := c.index[key]
+		if !ok || e.Ambiguous || shared[key] {
+			continue
+		}
+
+		if setAttempts != nil && e.Attempts > 0 {
+			setAttempts(item, e.Attempts)
+		}
+
+		state := item.GetState()
+		if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+			continue
+		}
+
+		switch e.State {
+		case queue.ItemStatePending.String(), queue.ItemStateWaiting.String():
+			item.State = queue.ItemStateWaiting
+			item.Reason = ReasonResumed
+			verified++
+		}
+	}
+
+	return verified
+}
+
+// sharedKeys returns the keys that more than one of the items has
+func sharedKeys(items []*queue.Item) map[string]bool {
+	seen := make(map[string]bool, len(items))
+	shared := make(map[string]bool)
+
+	for _, item := range items {
+		key := entryFromItem(item).key()
+		if seen[key] {
+			shared[key] = true
+		}
+		seen[key] = true
+	}
+
+	return shared
+}
+
+func entryFromItem(item *queue.Item) *Entry {
+	return &Entry{
+		Type:       item.Type,
+		Region:     item.Owner,
+		Identifier: nuke.ResourceIdentifier(item),
+		Key:        nuke.ResourceKey(item),
+		State:      item.GetState().String(),
+		Reason:     item.GetReason(),
+	}
+}
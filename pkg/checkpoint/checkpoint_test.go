@@ -0,0 +1,206 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	ID string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) String() string {
+	return r.ID
+}
+
+// testLayer is a resource whose String() is only its name, which all of its versions share
+type testLayer struct {
+	Name    string
+	Version string
+}
+
+func (r *testLayer) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLayer) String() string {
+	return r.Name
+}
+
+func (r *testLayer) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.Name).Set("Version", r.Version)
+}
+
+func newLayer(name, version string, state queue.ItemState) *queue.Item {
+	return &queue.Item{
+		Resource: &testLayer{Name: name, Version: version},
+		State:    state,
+		Type:     "TestLayer",
+		Owner:    "us-east-1",
+	}
+}
+
+func newItem(id, resourceType, region string, state queue.ItemState) *queue.Item {
+	return &queue.Item{
+		Resource: &testResource{ID: id},
+		State:    state,
+		Type:     resourceType,
+		Owner:    region,
+	}
+}
+
+func TestCheckpoint_SaveLoad(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+	c.Update([]*queue.Item{
+		newItem("one", "TestResource", "us-east-1", queue.ItemStatePending),
+		newItem("two", "TestResource", "us-east-1", queue.ItemStateFailed),
+	}, func(*queue.Item) int { return 2 })
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, c.Save(path))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Entries, 2)
+	assert.Equal(t, "pending", loaded.Entries[0].State)
+	assert.Equal(t, 2, loaded.Entries[1].Attempts)
+
+	assert.NoError(t, loaded.Validate("123456789012", "abc", "test"))
+	assert.ErrorContains(t, loaded.Validate("000000000000", "abc", "test"), "account")
+	assert.ErrorContains(t, loaded.Validate("123456789012", "def", "test"), "configuration")
+	assert.ErrorContains(t, loaded.Validate("123456789012", "abc", "other"), "version")
+}
+
+func TestCheckpoint_Update(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+
+	item := newItem("one", "TestResource", "us-east-1", queue.ItemStateNew)
+	c.Update([]*queue.Item{item}, nil)
+
+	item.State = queue.ItemStateFinished
+	c.Update([]*queue.Item{item}, nil)
+
+	// entries that are not part of the update are kept
+	c.Update([]*queue.Item{newItem("two", "OtherResource", "us-east-1", queue.ItemStateNew)}, nil)
+
+	assert.Len(t, c.Entries, 2)
+	assert.Equal(t, "finished", c.Entries[0].State)
+}
+
+func TestCheckpoint_UpdateDrained(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+	c.UpdateDrained([]*queue.Item{
+		newItem("a", "Drained", "us-east-1", queue.ItemStateFinished),
+		newItem("b", "Drained", "us-east-1", queue.ItemStateFiltered),
+		newItem("c", "Partial", "us-east-1", queue.ItemStateFinished),
+		newItem("d", "Partial", "us-east-1", queue.ItemStateWaiting),
+		newItem("e", "OnlyFiltered", "us-east-1", queue.ItemStateFiltered),
+		newItem("f", "ListingFailed", "us-east-1", queue.ItemStateFinished),
+		newItem("a", "Drained", "us-west-2", queue.ItemStateFailed),
+	}, map[string]map[string]error{
+		"us-east-1": {"ListingFailed": errors.New("AccessDenied")},
+	})
+
+	assert.Equal(t, map[string][]string{"us-east-1": {"Drained"}}, c.Drained)
+
+	// on resume the drained type is not listed and stays drained, a type that is listed again is drained only if it
+	// is drained again
+	c.UpdateDrained([]*queue.Item{
+		newItem("c", "Partial", "us-east-1", queue.ItemStateFinished),
+		newItem("a", "Drained", "us-west-2", queue.ItemStateFinished),
+		newItem("g", "Drained", "us-west-2", queue.ItemStateNew),
+	}, nil)
+
+	assert.Equal(t, map[string][]string{"us-east-1": {"Drained", "Partial"}}, c.Drained)
+}
+
+func TestCheckpoint_Restore(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+	c.Update([]*queue.Item{
+		newItem("pending", "TestResource", "us-east-1", queue.ItemStatePending),
+		newItem("waiting", "TestResource", "us-east-1", queue.ItemStateWaiting),
+		newItem("failed", "TestResource", "us-east-1", queue.ItemStateFailed),
+	}, func(*queue.Item) int { return 1 })
+
+	pending := newItem("pending", "TestResource", "us-east-1", queue.ItemStateNew)
+	waiting := newItem("waiting", "TestResource", "us-east-1", queue.ItemStateNew)
+	failed := newItem("failed", "TestResource", "us-east-1", queue.ItemStateNew)
+	unknown := newItem("unknown", "TestResource", "us-east-1", queue.ItemStateNew)
+
+	attempts := make(map[*queue.Item]int)
+	verified := c.Restore([]*queue.Item{pending, waiting, failed, unknown}, func(item *queue.Item, n int) {
+		attempts[item] = n
+	})
+
+	assert.Equal(t, 2, verified)
+	assert.Equal(t, queue.ItemStateWaiting, pending.GetState())
+	assert.Equal(t, ReasonResumed, pending.GetReason())
+	assert.Equal(t, queue.ItemStateWaiting, waiting.GetState())
+	assert.Equal(t, queue.ItemStateNew, failed.GetState())
+	assert.Equal(t, queue.ItemStateNew, unknown.GetState())
+	assert.Equal(t, 1, attempts[failed])
+	assert.NotContains(t, attempts, unknown)
+}
+
+func TestCheckpoint_SharedName(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+	c.Update([]*queue.Item{
+		newLayer("shared", "1", queue.ItemStatePending),
+		newLayer("shared", "2", queue.ItemStateFailed),
+	}, nil)
+
+	// the versions share their name, but each keeps its own entry
+	assert.Len(t, c.Entries, 2)
+	assert.Equal(t, "pending", c.Entries[0].State)
+	assert.Equal(t, "failed", c.Entries[1].State)
+
+	// version 1 was removed, a version that was published since shares its name but was never asked to be removed
+	failed := newLayer("shared", "2", queue.ItemStateNew)
+	published := newLayer("shared", "3", queue.ItemStateNew)
+
+	assert.Equal(t, 0, c.Restore([]*queue.Item{failed, published}, nil))
+	assert.Equal(t, queue.ItemStateNew, published.GetState())
+	assert.Empty(t, published.GetReason())
+}
+
+func TestCheckpoint_Ambiguous(t *testing.T) {
+	c := New("123456789012", "abc", "test")
+	c.Update([]*queue.Item{
+		newItem("one", "TestResource", "us-east-1", queue.ItemStatePending),
+		newItem("one", "TestResource", "us-east-1", queue.ItemStateNew),
+	}, nil)
+
+	assert.Len(t, c.Entries, 1)
+	assert.True(t, c.Entries[0].Ambiguous)
+
+	// the entry may hold the state of either resource, it is not restored even though only one is found again
+	item := newItem("one", "TestResource", "us-east-1", queue.ItemStateNew)
+	assert.Equal(t, 0, c.Restore([]*queue.Item{item}, nil))
+	assert.Equal(t, queue.ItemStateNew, item.GetState())
+
+	// nor is an entry that more than one resource of the scan matches
+	c = New("123456789012", "abc", "test")
+	c.Update([]*queue.Item{newItem("two", "TestResource", "us-east-1", queue.ItemStatePending)}, nil)
+
+	first := newItem("two", "TestResource", "us-east-1", queue.ItemStateNew)
+	second := newItem("two", "TestResource", "us-east-1", queue.ItemStateNew)
+	assert.Equal(t, 0, c.Restore([]*queue.Item{first, second}, nil))
+	assert.Equal(t, queue.ItemStateNew, first.GetState())
+	assert.Equal(t, queue.ItemStateNew, second.GetState())
+}
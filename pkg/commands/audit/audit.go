@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/audit"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+)
+
+func executeVerify(_ context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected the path of the audit log as the only argument")
+	}
+
+	path := c.Args().First()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	summary, err := audit.Verify(f)
+	if err != nil {
+		return fmt.Errorf("audit log %s is not intact: %w", path, err)
+	}
+
+	fmt.Printf("audit log %s is intact\n", path)
+	fmt.Println("> Records: ", summary.Records)
+	if summary.Records > 0 {
+		fmt.Println("> First:   ", summary.First.Format(time.RFC3339))
+		fmt.Println("> Last:    ", summary.Last.Format(time.RFC3339))
+	}
+	fmt.Println("> Head:    ", summary.Head)
+
+	if expected := c.String("head"); expected != "" && expected != summary.Head {
+		return fmt.Errorf("audit log %s ends at %s, expected %s, records were removed or added at the end",
+			path, summary.Head, expected)
+	}
+
+	return nil
+}
+
+func init() {
+	verifyCmd := &cli.Command{
+		Name:      "verify",
+		Usage:     "verify that no record of an audit log was modified, removed or reordered",
+		ArgsUsage: "<file>",
+		Description: `verify checks the hash chain of an audit log written with run --audit-log. Every record holds
+the hash of the record before it, so changing, removing or reordering records breaks the chain. Records removed
+from the end of the log can only be detected by comparing the head hash with a copy kept elsewhere, see --head.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "head",
+				Usage: "the hash the log is expected to end with, as printed by a previous verify",
+			},
+		}, global.Flags()...),
+		Before: global.Before,
+		Action: executeVerify,
+	}
+
+	cmd := &cli.Command{
+		Name:     "audit",
+		Usage:    "work with the audit log of the resources that were removed",
+		Commands: []*cli.Command{verifyCmd},
+	}
+
+	common.RegisterCommand(cmd)
+}
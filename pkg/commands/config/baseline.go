@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/baseline"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
+)
+
+func executeBaseline(ctx context.Context, c *cli.Command) error {
+	logger := logrus.StandardLogger()
+
+	return nuke.Scan(ctx, c, func(account *awsutil.Account, q *queue.Queue) error {
+		b := baseline.New(account.ID(), q.GetItems())
+
+		data, err := b.Marshal(c.String("preset-name"))
+		if err != nil {
+			return err
+		}
+
+		if len(b.Unmatched) > 0 {
+			resourceTypes := make([]string, 0, len(b.Unmatched))
+			for resourceType := range b.Unmatched {
+				resourceTypes = append(resourceTypes, resourceType)
+			}
+			sort.Strings(resourceTypes)
+
+			logger.Warn("the following resources have no property or name to filter them by, they are not kept:")
+			for _, resourceType := range resourceTypes {
+				logger.Warnf("> %s: %d", resourceType, b.Unmatched[resourceType])
+			}
+		}
+
+		if c.String("output") == "" {
+			fmt.Print(string(data))
+			return nil
+		}
+
+		if err := os.WriteFile(c.String("output"), data, 0o600); err != nil {
+			return fmt.Errorf("unable to write baseline: %w", err)
+		}
+
+		logger.Infof("baseline of %d resources of %d resource types written to %s",
+			b.Resources, len(b.Filters), c.String("output"))
+
+		return nil
+	})
+}
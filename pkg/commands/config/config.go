@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
@@ -17,9 +19,10 @@ import (
 	"github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
 	"github.com/ekristen/aws-nuke/v3/pkg/common"
 	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	awsnuke "github.com/ekristen/aws-nuke/v3/pkg/nuke"
 )
 
-func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
+func execute(ctx context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 	accountID := c.String("account-id")
 
 	parsedConfig, err := config.New(libconfig.Options{
@@ -31,6 +34,12 @@ func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 		return err
 	}
 
+	// The files the configuration was merged from and where each part of it is defined
+	composed, err := config.Compose(c.String("config"))
+	if err != nil {
+		return err
+	}
+
 	if accountID == "" {
 		logrus.Info("no account id provided, attempting to authenticate and get account id")
 		creds := nuke.ConfigureCreds(c)
@@ -56,25 +65,28 @@ func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 
 	// Resolve the resource types to be used for the nuke process based on the parameters, global configuration, and
 	// account level configuration.
+	includes := []types.Collection{
+		registry.ExpandNames(c.StringSlice("include")),
+		parsedConfig.ResourceTypes.GetIncludes(),
+		accountConfig.ResourceTypes.GetIncludes(),
+	}
+	excludes := []types.Collection{
+		registry.ExpandNames(c.StringSlice("exclude")),
+		parsedConfig.ResourceTypes.Excludes,
+		accountConfig.ResourceTypes.Excludes,
+	}
+	alternatives := []types.Collection{
+		registry.ExpandNames(c.StringSlice("cloud-control")),
+		parsedConfig.ResourceTypes.GetAlternatives(),
+		accountConfig.ResourceTypes.GetAlternatives(),
+	}
+
 	resourceTypes := types.ResolveResourceTypes(
+		registry.GetNames(), includes, excludes, alternatives, registry.GetAlternativeResourceTypeMapping())
+	exclusions := awsnuke.ExplainResourceTypes(
 		registry.GetNames(),
-		[]types.Collection{
-			{}, // note: empty collection since we are not capturing parameters
-			parsedConfig.ResourceTypes.GetIncludes(),
-			accountConfig.ResourceTypes.GetIncludes(),
-		},
-		[]types.Collection{
-			{}, // note: empty collection since we are not capturing parameters
-			parsedConfig.ResourceTypes.Excludes,
-			accountConfig.ResourceTypes.Excludes,
-		},
-		[]types.Collection{
-			{}, // note: empty collection since we are not capturing parameters
-			parsedConfig.ResourceTypes.GetAlternatives(),
-			accountConfig.ResourceTypes.GetAlternatives(),
-		},
-		registry.GetAlternativeResourceTypeMapping(),
-	)
+		[]string{awsnuke.SourceFlags, awsnuke.SourceGlobalConfig, awsnuke.SourceAccountConfig},
+		includes, excludes, alternatives, registry.GetAlternativeResourceTypeMapping())
 
 	filtersTotal := 0
 	var resourcesWithFilters []string
@@ -95,12 +107,28 @@ func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 	fmt.Printf("Account ID:       %s\n", accountID)
 	fmt.Printf("Resource Types:   %d (total)\n", len(registry.GetNames()))
 	fmt.Printf("      Included:   %d\n", len(resourceTypes))
-	fmt.Printf("      Excluded:   %d\n", len(registry.GetNames())-len(resourceTypes))
+	fmt.Printf("      Excluded:   %d\n", len(exclusions))
 	fmt.Printf("Filter Presets:   %d\n", len(accountConfig.Presets))
 	fmt.Printf("Resource Filters: %d\n", filtersTotal)
+	fmt.Printf("Config Files:     %d\n", len(composed.Files))
+	if composed.Environment != "" {
+		fmt.Printf("Environment:      %s\n", composed.Environment)
+	}
 
 	fmt.Println("")
 
+	if c.Bool("with-sources") {
+		fmt.Println("Config Files:")
+		for _, file := range composed.Files {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Println("")
+
+		fmt.Println("Sources:")
+		printSources(composed.Sources, accountID, accountConfig.Presets)
+		fmt.Println("")
+	}
+
 	if c.Bool("with-filtered") {
 		fmt.Println("Resources with Filters Defined:")
 		for _, resource := range resourcesWithFilters {
@@ -117,16 +145,18 @@ func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 		fmt.Println("")
 	}
 
-	if c.Bool("with-excluded") {
+	if c.Bool("with-excluded") || c.Bool("scan") {
 		fmt.Println("Excluded Resource Types:")
-		for _, resourceType := range registry.GetNames() {
-			if !slices.Contains(resourceTypes, resourceType) {
-				fmt.Printf("  %s\n", resourceType)
-			}
+		for _, exclusion := range exclusions {
+			fmt.Printf("  %s (%s by %s)\n", exclusion.Type, exclusion.Reason, exclusion.Source)
 		}
 		fmt.Println("")
 	}
 
+	if c.Bool("scan") {
+		return executeScan(ctx, c, c.String("account-id"), filterSets(parsedConfig, accountConfig))
+	}
+
 	if !c.Bool("with-filtered") {
 		fmt.Printf("Note: use --with-filtered to see resources with filters defined\n")
 	}
@@ -136,10 +166,47 @@ func execute(_ context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
 	if !c.Bool("with-excluded") {
 		fmt.Printf("Note: use --with-excluded to see excluded resource types\n")
 	}
+	if !c.Bool("with-sources") {
+		fmt.Printf("Note: use --with-sources to see the file each part of the configuration is defined in\n")
+	}
+	fmt.Printf("Note: use --scan to see which filter keeps each resource of the account\n")
 
 	return nil
 }
 
+// printSources prints where the top level keys, the account, the presets it uses and the settings are defined
+func printSources(sources config.Sources, accountID string, presets []string) {
+	accountPath := "accounts." + accountID
+
+	var paths []string
+	for path := range sources {
+		parts := strings.Split(path, ".")
+
+		switch {
+		case len(parts) == 1,
+			path == accountPath || strings.HasPrefix(path, accountPath+".filters.") || path == accountPath+".presets",
+			parts[0] == "presets" && slices.Contains(presets, parts[1]) && (len(parts) == 2 || parts[2] == "filters"),
+			parts[0] == "settings" && len(parts) == 2:
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	width := 0
+	for _, path := range paths {
+		width = max(width, len(path))
+	}
+
+	for _, path := range paths {
+		locations := make([]string, 0, len(sources[path]))
+		for _, source := range sources[path] {
+			locations = append(locations, source.String())
+		}
+
+		fmt.Printf("  %-*s  %s\n", width, path, strings.Join(locations, ", "))
+	}
+}
+
 func init() {
 	flags := []cli.Flag{
 		&cli.StringFlag{
@@ -165,6 +232,14 @@ func init() {
 			Name:  "with-excluded",
 			Usage: "print out the excluded resource types",
 		},
+		&cli.BoolFlag{
+			Name:  "with-sources",
+			Usage: "print out the file and line each part of the configuration is defined in",
+		},
+		&cli.BoolFlag{
+			Name:  "scan",
+			Usage: "scan the account and print out the filter that keeps each resource or that it would be removed",
+		},
 		&cli.StringFlag{
 			Name:    "default-region",
 			Sources: cli.EnvVars("AWS_DEFAULT_REGION"),
@@ -207,13 +282,26 @@ func init() {
 		},
 	}
 
+	// The remaining flags of a scan, such as the resource types to include or exclude
+	for _, f := range nuke.ScanFlags() {
+		if !slices.ContainsFunc(flags, func(existing cli.Flag) bool {
+			return existing.Names()[0] == f.Names()[0]
+		}) {
+			flags = append(flags, f)
+		}
+	}
+
 	cmd := &cli.Command{
 		Name:  "explain-config",
 		Usage: "explain the configuration file and the resources that will be nuked for an account",
 		Description: `explain the configuration file and the resources that will be nuked for an account that
 is defined within the configuration. You may either specific an account using the --account-id flag or
 leave it empty to use the default account that can be authenticated against. You can optionally list out included,
-excluded and resources with filters with their respective with flags.`,
+excluded and resources with filters with their respective with flags. When the configuration includes other files,
+--with-sources lists the file and line each part of the configuration is defined in. The excluded resource types are
+listed with the cli flag, global or account configuration that excluded them. --scan lists the resources of the
+account without removing anything and, for each of them, the preset or account filter that keeps it, or that it
+would be removed.`,
 		Flags:  append(flags, global.Flags()...),
 		Before: global.Before,
 		Action: execute,
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	libconfig "github.com/ekristen/libnuke/pkg/config"
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	awsnuke "github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// filterSets returns the filters of the account and of each preset it uses, in the order they are combined for a scan
+func filterSets(parsedConfig *config.Config, accountConfig *libconfig.Account) []awsnuke.FilterSet {
+	sets := []awsnuke.FilterSet{
+		{Origin: "account filters", Filters: accountConfig.Filters},
+	}
+
+	for _, name := range accountConfig.Presets {
+		preset, ok := parsedConfig.Presets[name]
+		if !ok {
+			continue
+		}
+
+		sets = append(sets, awsnuke.FilterSet{Origin: fmt.Sprintf("preset '%s'", name), Filters: preset.Filters})
+	}
+
+	return sets
+}
+
+// executeScan scans the account and prints, for every resource that was discovered, the filters that keep it or that
+// it would be removed
+func executeScan(ctx context.Context, c *cli.Command, accountID string, sets []awsnuke.FilterSet) error {
+	useGroups := slices.Contains(c.StringSlice("feature-flag"), "filter-groups")
+
+	return nuke.Scan(ctx, c, func(account *awsutil.Account, q *queue.Queue) error {
+		if accountID != "" && account.ID() != accountID {
+			return fmt.Errorf("authenticated to account %s, but --account-id is %s", account.ID(), accountID)
+		}
+
+		items := slices.Clone(q.GetItems())
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i], items[j]
+			if a.Owner != b.Owner {
+				return a.Owner < b.Owner
+			}
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+			return awsnuke.ResourceIdentifier(a) < awsnuke.ResourceIdentifier(b)
+		})
+
+		removed, protected := 0, 0
+		fmt.Println("Resources:")
+		for _, item := range items {
+			explanation, err := awsnuke.Explain(sets, item, useGroups)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("  %s - %s - %s\n", item.Owner, item.Type, awsnuke.ResourceIdentifier(item))
+
+			switch {
+			case explanation.Removed:
+				removed++
+				fmt.Println("      would be removed, no filter matched")
+			case awsnuke.IsProtected(item):
+				protected++
+				fmt.Printf("      %s\n", explanation.Reason)
+			case len(explanation.Matches) > 0:
+				matches := make([]string, 0, len(explanation.Matches))
+				for _, match := range explanation.Matches {
+					matches = append(matches, match.String())
+				}
+				fmt.Printf("      kept by %s\n", strings.Join(matches, "\n      and "))
+			default:
+				fmt.Printf("      kept: %s\n", explanation.Reason)
+			}
+		}
+		fmt.Println("")
+
+		fmt.Printf("Scanned %d resources, %d would be removed, %d kept and %d protected\n\n",
+			len(items), removed, len(items)-removed-protected, protected)
+
+		return nil
+	})
+}
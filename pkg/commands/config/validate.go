@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/libnuke/pkg/registry"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/baseline"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+// newCatalog returns the catalog of every resource type that is registered
+func newCatalog() *config.Catalog {
+	return config.NewCatalog(registry.GetRegistrations(), registry.GetDeprecatedResourceTypeMapping())
+}
+
+func executeValidate(_ context.Context, c *cli.Command) error {
+	path := c.String("config")
+
+	issues, err := config.ValidateFile(path, newCatalog())
+	if err != nil {
+		return fmt.Errorf("%s cannot be loaded: %w", path, err)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+
+		fmt.Printf("%s:%s\n", issue.File, issue)
+	}
+
+	if errorCount > 0 || (c.Bool("strict") && warningCount > 0) {
+		return fmt.Errorf("%s has %d errors and %d warnings", path, errorCount, warningCount)
+	}
+
+	if warningCount > 0 {
+		fmt.Printf("%s is valid, with %d warnings\n", path, warningCount)
+		return nil
+	}
+
+	fmt.Printf("%s is valid\n", path)
+
+	return nil
+}
+
+func executeSchema(_ context.Context, c *cli.Command) error {
+	data, err := json.MarshalIndent(config.Schema(newCatalog()), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	return os.WriteFile(c.String("output"), append(data, '\n'), 0o600)
+}
+
+func init() {
+	validateCmd := &cli.Command{
+		Name:  "validate",
+		Usage: "check the configuration file for mistakes without connecting to aws",
+		Description: `validate reports unknown keys, unknown resource types, properties and settings, values of the
+wrong type and presets that are not defined or not used by any account, with the line they are found on. The files
+the configuration includes and the overlay of the environment are merged first, issues are reported in the file they
+are found in. The resource types, their properties and settings are those of this version of aws-nuke.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "path to config file",
+				Value:   "config.yaml",
+				Action:  common.CheckFilePath,
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "fail on warnings as well as errors",
+			},
+		}, global.Flags()...),
+		Before: global.Before,
+		Action: executeValidate,
+	}
+
+	schemaCmd := &cli.Command{
+		Name:  "schema",
+		Usage: "print the json schema of the configuration file, for editors and other tools",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the schema to this path instead of printing it",
+			},
+		}, global.Flags()...),
+		Before: global.Before,
+		Action: executeSchema,
+	}
+
+	baselineCmd := &cli.Command{
+		Name:  "baseline",
+		Usage: "write a preset that keeps every resource that currently exists in the account",
+		Description: `baseline scans the authenticated account with the regions, resource types and filters of the
+configuration, exactly like a dry run, and writes a preset with a filter for every resource that was found. Each
+resource is matched by its ARN, ID or name, whichever it has first. Once the account refers to the preset only
+resources that are created afterward are removed. Nothing is removed by this command.`,
+		Flags: append(append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the preset to this path instead of printing it",
+			},
+			&cli.StringFlag{
+				Name:  "preset-name",
+				Usage: "the name of the preset",
+				Value: baseline.DefaultPresetName,
+			},
+		}, nuke.ScanFlags()...), global.Flags()...),
+		Before: global.Before,
+		Action: executeBaseline,
+	}
+
+	cmd := &cli.Command{
+		Name:     "config",
+		Usage:    "validate the configuration file, print its json schema or generate a baseline of an account",
+		Commands: []*cli.Command{validateCmd, schemaCmd, baselineCmd},
+	}
+
+	common.RegisterCommand(cmd)
+}
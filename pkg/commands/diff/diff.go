@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/diff"
+)
+
+func execute(_ context.Context, c *cli.Command) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected the old and the new snapshot, got %d arguments", c.Args().Len())
+	}
+
+	format, err := diff.ParseFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	before, err := diff.Load(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	after, err := diff.Load(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	d, err := diff.Compare(before, after)
+	if err != nil {
+		return err
+	}
+
+	return diff.Write(os.Stdout, d, format)
+}
+
+func init() {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "the format of the diff, one of: text, json",
+			Value: string(diff.FormatText),
+		},
+	}
+
+	cmd := &cli.Command{
+		Name:      "diff",
+		Usage:     "compare two snapshots of an account and show the resources that were added, removed or changed",
+		ArgsUsage: "<old> <new>",
+		Description: `compare two snapshots of the same account, each either an inventory or the report of a run in any
+of their formats, and show the resources that were added, removed and changed, with the properties that changed,
+grouped by region and resource type. Resources that the run of the old snapshot removed but that exist again in the
+new one are shown as returned, these are usually recreated by automation.`,
+		Flags:  append(flags, global.Flags()...),
+		Before: global.Before,
+		Action: execute,
+	}
+
+	common.RegisterCommand(cmd)
+}
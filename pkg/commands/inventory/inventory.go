@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/nuke"
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+	awsnuke "github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+func execute(ctx context.Context, c *cli.Command) error {
+	logger := logrus.StandardLogger()
+
+	format, err := inventory.ParseFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("apply-filters") && c.String("config") == "" {
+		return fmt.Errorf("--apply-filters requires a configuration file")
+	}
+
+	return nuke.List(ctx, c, c.StringSlice("region"),
+		func(account *awsutil.Account, parsedConfig *config.Config, items []*queue.Item) error {
+			// The settings are applied the same as during a scan, some resources refuse to be removed based on them
+			for _, item := range items {
+				if getter, ok := item.Resource.(resource.SettingsGetter); ok {
+					getter.Settings(parsedConfig.Settings.Get(item.Type))
+				}
+			}
+
+			if c.Bool("apply-filters") {
+				filters, err := parsedConfig.Filters(account.ID())
+				if err != nil {
+					return err
+				}
+
+				var protectTag *awsnuke.ProtectTag
+				if parsedConfig.ProtectTag != "" {
+					protectTag, err = awsnuke.ParseProtectTag(parsedConfig.ProtectTag)
+					if err != nil {
+						return err
+					}
+				}
+
+				useGroups := slices.Contains(c.StringSlice("feature-flag"), "filter-groups")
+				kept, err := inventory.Filter(items, filters, useGroups, protectTag)
+				if err != nil {
+					return err
+				}
+
+				logger.Infof("%d of %d resources are filtered by the configuration", len(items)-len(kept), len(items))
+				items = kept
+			}
+
+			records := inventory.New(account.ID(), items)
+
+			if c.String("output") == "" {
+				return inventory.Write(os.Stdout, records, format)
+			}
+
+			f, err := os.OpenFile(c.String("output"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return fmt.Errorf("unable to write inventory: %w", err)
+			}
+
+			if err := inventory.Write(f, records, format); err != nil {
+				_ = f.Close()
+				return err
+			}
+
+			logger.Infof("inventory of %d resources written to %s", len(records), c.String("output"))
+
+			return f.Close()
+		})
+}
+
+func init() {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Usage:   "path to an optional config file, for its regions, resource types, endpoints and filters",
+			Action:  common.CheckFilePath,
+		},
+		&cli.StringSliceFlag{
+			Name:  "region",
+			Usage: "the regions to list, defaults to the regions of the config file or every enabled region",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "the format of the inventory, one of: ndjson, csv, columnar",
+			Value: string(inventory.FormatNDJSON),
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "the file to write the inventory to, defaults to stdout",
+		},
+		&cli.BoolFlag{
+			Name:  "apply-filters",
+			Usage: "only export the resources that a run would remove, the resources the config filters keep are left out",
+		},
+	}
+
+	// The flags of a scan, except those that only apply to a configured account
+	for _, f := range nuke.ScanFlags() {
+		if !slices.Contains([]string{"config", "quiet", "no-alias-check"}, f.Names()[0]) {
+			flags = append(flags, f)
+		}
+	}
+
+	cmd := &cli.Command{
+		Name:  "inventory",
+		Usage: "list every resource of an account and export them, without removing anything",
+		Description: `list every resource of the account that can be authenticated against and export them with their
+properties as ndjson, csv or columnar json. The region scanners are run on their own, nothing is ever removed and
+read-only credentials are enough. A config file is optional, it limits the regions and resource types that are listed
+and, with --apply-filters, only the resources that a run would remove are exported.`,
+		Flags:  append(flags, global.Flags()...),
+		Before: global.Before,
+		Action: execute,
+	}
+
+	common.RegisterCommand(cmd)
+}
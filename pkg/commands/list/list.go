@@ -2,6 +2,7 @@ package list
 
 import (
 	"context"
+	"os"
 	"slices"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
 	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/resourcetype"
 
 	"github.com/ekristen/libnuke/pkg/registry"
 
@@ -26,6 +28,15 @@ func execute(_ context.Context, c *cli.Command) error {
 
 	slices.Sort(ls)
 
+	format, err := resourcetype.ParseFormat(c.String("output"))
+	if err != nil {
+		return err
+	}
+
+	if format != resourcetype.FormatText {
+		return resourcetype.Write(os.Stdout, resourcetype.List(registry.GetRegistrations(), ls), format)
+	}
+
 	for _, name := range ls {
 		reg := registry.GetRegistration(name)
 
@@ -55,9 +66,18 @@ func init() {
 		Name:    "resource-types",
 		Aliases: []string{"list-resources"},
 		Usage:   "list available resources to nuke",
-		Flags:   global.Flags(),
-		Before:  global.Before,
-		Action:  execute,
+		Description: `list the available resource types, optionally limited to those matching the given names or globs.
+With --output json, yaml or markdown each resource type is described with its scope, dependencies, alternative
+resource, deprecated aliases, settings and the properties it can be filtered by.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "the output format, one of: text, json, yaml, markdown",
+				Value: string(resourcetype.FormatText),
+			},
+		}, global.Flags()...),
+		Before: global.Before,
+		Action: execute,
 	}
 
 	common.RegisterCommand(cmd)
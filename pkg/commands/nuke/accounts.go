@@ -0,0 +1,324 @@
+package nuke
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/aws/aws-sdk-go/aws/arn" //nolint:staticcheck
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+)
+
+// accountTarget is a single account that is nuked as part of a multi-account run
+type accountTarget struct {
+	AccountID string
+	RoleArn   string
+}
+
+// accountSummary is the outcome of a single account in a multi-account run
+type accountSummary struct {
+	target accountTarget
+	result *accountResult
+	err    error
+}
+
+// isMultiAccount returns true if the user asked to run against more than the authenticated account
+func isMultiAccount(c *cli.Command) bool {
+	return c.String("accounts-from") != "" || c.Bool("all-accounts")
+}
+
+// resolveAccountTargets builds the list of accounts to run against, either from a file of role ARNs or from the
+// accounts that are defined in the configuration combined with the role name to assume in each of them.
+func resolveAccountTargets(c *cli.Command, parsedConfig *config.Config) ([]accountTarget, error) {
+	if c.String("accounts-from") != "" && c.Bool("all-accounts") {
+		return nil, fmt.Errorf("--accounts-from and --all-accounts cannot be used together")
+	}
+
+	var targets []accountTarget
+
+	if c.String("accounts-from") != "" {
+		f, err := os.Open(c.String("accounts-from"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		lineNumber := 0
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			lineNumber++
+			line := strings.TrimSpace(s.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			parsed, err := arn.Parse(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid role arn '%s': %w",
+					c.String("accounts-from"), lineNumber, line, err)
+			}
+
+			targets = append(targets, accountTarget{
+				AccountID: parsed.AccountID,
+				RoleArn:   line,
+			})
+		}
+
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		accountIDs := make([]string, 0, len(parsedConfig.Accounts))
+		for id := range parsedConfig.Accounts {
+			accountIDs = append(accountIDs, id)
+		}
+		sort.Strings(accountIDs)
+
+		for _, id := range accountIDs {
+			targets = append(targets, accountTarget{
+				AccountID: id,
+				RoleArn: fmt.Sprintf("arn:%s:iam::%s:role/%s",
+					awsutil.DefaultAWSPartitionID, id, c.String("account-role-name")),
+			})
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no accounts to run against")
+	}
+
+	return targets, nil
+}
+
+// accountPath inserts the account ID into a path so that each account gets its own file, for example report.json
+// becomes report-123456789012.json
+func accountPath(path, accountID string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), accountID, ext)
+}
+
+// runAccounts assumes a role in every target account and runs the full nuke cycle against each of them with a
+// bounded number of accounts running at the same time. A summary for every account is printed at the end.
+func runAccounts(ctx context.Context, c *cli.Command, parsedConfig *config.Config, opts *runOptions) error {
+	logger := logrus.StandardLogger()
+
+	if c.String("assume-role-arn") != "" {
+		return fmt.Errorf("--assume-role-arn cannot be used when running against multiple accounts")
+	}
+
+	if opts.applyPlan != nil || opts.outPlan != "" {
+		return fmt.Errorf("plans are not supported when running against multiple accounts")
+	}
+
+	concurrency := c.Int("account-concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--account-concurrency must be at least 1")
+	}
+
+	if concurrency > 1 && !c.Bool("force") {
+		return fmt.Errorf("--account-concurrency greater than 1 requires --no-prompt")
+	}
+
+	targets, err := resolveAccountTargets(c, parsedConfig)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("running against %d accounts, %d at a time", len(targets), concurrency)
+
+	summaries := make([]*accountSummary, len(targets))
+	sem := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+
+	for i, target := range targets {
+		// select picks at random when a slot is free and the context is done, so the context is checked on its own as
+		// well, no account may start after the run was interrupted
+		if err := ctx.Err(); err != nil {
+			summaries[i] = &accountSummary{target: target, err: err}
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			summaries[i] = &accountSummary{target: target, err: ctx.Err()}
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			<-sem
+			summaries[i] = &accountSummary{target: target, err: err}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, target accountTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := runAccount(ctx, c, parsedConfig, target, opts)
+			summaries[i] = &accountSummary{target: target, result: result, err: err}
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	return printAccountSummaries(logger, summaries)
+}
+
+// runAccount nukes a single target account of a multi-account run
+func runAccount(
+	ctx context.Context, c *cli.Command, parsedConfig *config.Config, target accountTarget, opts *runOptions,
+) (*accountResult, error) {
+	account, err := connectAccount(c, parsedConfig, target)
+	if err != nil {
+		return nil, err
+	}
+
+	accountOpts := *opts
+	accountOpts.logger = accountLogger(target.AccountID)
+	if accountOpts.reportPath != "" {
+		accountOpts.reportPath = accountPath(accountOpts.reportPath, account.ID())
+	}
+	if accountOpts.stateFile != "" {
+		accountOpts.stateFile = accountPath(accountOpts.stateFile, account.ID())
+	}
+	if accountOpts.reviewOut != "" {
+		accountOpts.reviewOut = accountPath(accountOpts.reviewOut, account.ID())
+	}
+
+	return nukeAccount(ctx, c, parsedConfig, account, &accountOpts)
+}
+
+// accountLogger returns a logger that writes like the standard logger with the account on every entry, so that the
+// output of accounts that run at the same time can be told apart
+func accountLogger(accountID string) *logrus.Logger {
+	std := logrus.StandardLogger()
+
+	logger := &logrus.Logger{
+		Out:          std.Out,
+		Formatter:    std.Formatter,
+		Hooks:        make(logrus.LevelHooks),
+		Level:        std.GetLevel(),
+		ExitFunc:     std.ExitFunc,
+		ReportCaller: std.ReportCaller,
+	}
+
+	// the account is added first, so that the hooks of the standard logger see it as well
+	logger.AddHook(&accountHook{accountID: accountID})
+	for level, hooks := range std.Hooks {
+		logger.Hooks[level] = append(logger.Hooks[level], hooks...)
+	}
+
+	return logger
+}
+
+// accountHook adds the account to every entry
+type accountHook struct {
+	accountID string
+}
+
+func (h *accountHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *accountHook) Fire(e *logrus.Entry) error {
+	e.Data["account"] = h.accountID
+	return nil
+}
+
+// connectAccount authenticates against the target account, assuming its role if it has one, and verifies that the
+// credentials belong to the expected account
+func connectAccount(c *cli.Command, parsedConfig *config.Config, target accountTarget) (*awsutil.Account, error) {
+	creds := ConfigureCreds(c)
+	if target.RoleArn != "" {
+		creds.AssumeRoleArn = target.RoleArn
+	}
+
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Every account gets its own retry policy and throttle counter, rate limits apply per account
+	var err error
+	creds.Retry, err = awsutil.NewRetryPolicy(parsedConfig.Retry)
+	if err != nil {
+		return nil, err
+	}
+	creds.Throttles = awsutil.NewThrottleCounter()
+
+	account, err := awsutil.NewAccount(creds, parsedConfig.CustomEndpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.ID() != target.AccountID {
+		if target.RoleArn != "" {
+			return nil, fmt.Errorf("assumed role %s resolved to account %s, expected %s",
+				target.RoleArn, account.ID(), target.AccountID)
+		}
+
+		return nil, fmt.Errorf("credentials resolved to account %s, expected %s", account.ID(), target.AccountID)
+	}
+
+	return account, nil
+}
+
+func printAccountSummaries(logger *logrus.Logger, summaries []*accountSummary) error {
+	printLog := logger.WithField("_handler", "println")
+
+	failed := 0
+	printLog.Info("Account Summary:")
+	for _, s := range summaries {
+		fields := logrus.Fields{
+			"account": s.target.AccountID,
+		}
+
+		status := "ok"
+		if s.err != nil {
+			failed++
+			status = fmt.Sprintf("error: %s", s.err)
+			fields["error"] = s.err.Error()
+		}
+
+		alias := ""
+		counts := make(map[report.State]int)
+		duration := time.Duration(0)
+		throttles := 0
+		if s.result != nil {
+			alias = s.result.Alias
+			counts = s.result.Counts
+			duration = s.result.Duration.Round(time.Second)
+			throttles = s.result.Throttles
+			fields["alias"] = alias
+			fields["throttled"] = throttles
+			for state, count := range counts {
+				fields[string(state)] = count
+			}
+		}
+
+		printLog.WithFields(fields).Infof("> %s (%s): %d would remove, %d removed, %d failed, %d filtered, "+
+			"%d protected, %d pending, %d throttled in %s - %s",
+			s.target.AccountID, alias, counts[report.StateWouldRemove], counts[report.StateRemoved],
+			counts[report.StateFailed], counts[report.StateFiltered], counts[report.StateProtected],
+			counts[report.StatePending], throttles, duration, status)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d accounts failed", failed, len(summaries))
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package nuke
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountLogger(t *testing.T) {
+	std := logrus.StandardLogger()
+	out, formatter := std.Out, std.Formatter
+	t.Cleanup(func() {
+		std.SetOutput(out)
+		std.SetFormatter(formatter)
+	})
+
+	buf := &bytes.Buffer{}
+	std.SetOutput(buf)
+	std.SetFormatter(&logrus.JSONFormatter{})
+
+	accountLogger("111111111111").WithField("component", "libnuke").Info("first")
+	accountLogger("222222222222").Info("second")
+	std.Info("standard")
+
+	assert.Contains(t, buf.String(), `"account":"111111111111","component":"libnuke","level":"info","msg":"first"`)
+	assert.Contains(t, buf.String(), `"account":"222222222222","level":"info","msg":"second"`)
+	assert.Contains(t, buf.String(), `{"level":"info","msg":"standard"`)
+}
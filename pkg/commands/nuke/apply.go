@@ -0,0 +1,23 @@
+package nuke
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/plan"
+)
+
+func executeApply(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("apply requires exactly one argument, the path to the plan")
+	}
+
+	applyPlan, err := plan.Load(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return run(ctx, c, applyPlan)
+}
@@ -0,0 +1,85 @@
+package nuke
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/audit"
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// openAuditLog opens the audit log when one was requested, it is only written during a live run. Nil is returned
+// when there is nothing to audit.
+func openAuditLog(c *cli.Command, noDryRun bool, logger *logrus.Logger) (*audit.Log, error) {
+	path := c.String("audit-log")
+	if path == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	if !noDryRun {
+		logger.Warnf("nothing is removed during a dry run, the audit log %s is not written", path)
+		return nil, nil //nolint:nilnil
+	}
+
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("recording every removal attempt in the audit log %s", path)
+
+	return auditLog, nil
+}
+
+// registerAudit records every attempt to remove a resource of the account in the audit log
+func registerAudit(n *nuke.Runner, auditLog *audit.Log, account *awsutil.Account, logger *logrus.Logger) {
+	n.RegisterRemoveHook(func(_ context.Context, item *queue.Item, attempt int) {
+		rec := &audit.Record{
+			CallerARN:    account.ARN(),
+			Account:      account.ID(),
+			Region:       item.Owner,
+			ResourceType: item.Type,
+			Identifier:   nuke.ResourceIdentifier(item),
+			Attempt:      attempt,
+			Result:       auditResult(item.GetState()),
+		}
+
+		if rec.Result == audit.ResultFailed {
+			rec.Reason = item.GetReason()
+		}
+
+		if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+			rec.Properties = make(map[string]string)
+			for k, v := range getter.Properties() {
+				if strings.HasPrefix(k, "_") {
+					continue
+				}
+				rec.Properties[k] = v
+			}
+		}
+
+		if err := auditLog.Append(rec); err != nil {
+			logger.WithError(err).Errorf("unable to record the removal of %s %s in the audit log",
+				item.Type, rec.Identifier)
+		}
+	})
+}
+
+// auditResult returns the result of a removal attempt from the state the item was left in
+func auditResult(state queue.ItemState) audit.Result {
+	switch state {
+	case queue.ItemStateFailed:
+		return audit.ResultFailed
+	case queue.ItemStateHold:
+		return audit.ResultHold
+	default:
+		return audit.ResultRequested
+	}
+}
@@ -0,0 +1,72 @@
+package nuke
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/settings"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/cache"
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// cachedResources returns the resources of the resource types of the region that are in the cache, and the resource
+// types that are not and have to be listed
+func cachedResources(
+	c *cache.Cache, accountID, region string, resourceTypes []string, s *settings.Settings, logger *logrus.Logger,
+) (cached map[string][]resource.Resource, missing []string) {
+	cached = make(map[string][]resource.Resource)
+
+	for _, resourceType := range resourceTypes {
+		records, ok := c.Get(accountID, region, resourceType, s.Get(resourceType))
+		if !ok {
+			missing = append(missing, resourceType)
+			continue
+		}
+
+		cached[resourceType] = inventory.Resources(records, region, []string{resourceType})[resourceType]
+	}
+
+	if len(cached) > 0 {
+		logger.Infof("%d of %d resource types in %s are taken from the cache", len(cached), len(resourceTypes), region)
+	}
+
+	return cached, missing
+}
+
+// updateCache stores the resources of the resource types that were listed in each region, the settings must have been
+// applied to the resources. A resource type without any resources is stored as well, so that it is not listed again.
+// One whose listing failed or was cut short is not, its resources would be missing until the entry expires.
+func updateCache(
+	c *cache.Cache, accountID string, listed map[string][]string, scanners map[string]*nuke.Scanner,
+	items []*queue.Item, s *settings.Settings, logger *logrus.Logger,
+) {
+	byRegionAndType := make(map[string]map[string][]*queue.Item)
+	for _, item := range items {
+		if byRegionAndType[item.Owner] == nil {
+			byRegionAndType[item.Owner] = make(map[string][]*queue.Item)
+		}
+		byRegionAndType[item.Owner][item.Type] = append(byRegionAndType[item.Owner][item.Type], item)
+	}
+
+	for region, resourceTypes := range listed {
+		var incomplete map[string]error
+		if scanner, ok := scanners[region]; ok {
+			incomplete = scanner.Incomplete()
+		}
+
+		for _, resourceType := range resourceTypes {
+			if err, ok := incomplete[resourceType]; ok {
+				logger.WithError(err).Debugf("not caching the resources of %s in %s", resourceType, region)
+				continue
+			}
+
+			records := inventory.New(accountID, byRegionAndType[region][resourceType])
+			if err := c.Put(accountID, region, resourceType, s.Get(resourceType), records); err != nil {
+				logger.WithError(err).Warnf("unable to cache the resources of %s in %s", resourceType, region)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/session" //nolint:staticcheck
+
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/settings"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/cache"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+const (
+	testCachedResourceType  = "TestCachedResource"
+	testFailingResourceType = "TestFailingResource"
+	testEmptyResourceType   = "TestEmptyResource"
+)
+
+type testCachedResource struct {
+	Name string
+}
+
+func (r *testCachedResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testCachedResource) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.Name)
+}
+
+type testLister struct {
+	resources []resource.Resource
+	err       error
+}
+
+func (l *testLister) List(_ context.Context, _ interface{}) ([]resource.Resource, error) {
+	return l.resources, l.err
+}
+
+func init() {
+	registry.Register(&registry.Registration{
+		Name:     testCachedResourceType,
+		Scope:    nuke.Account,
+		Resource: &testCachedResource{},
+		Lister:   &testLister{resources: []resource.Resource{&testCachedResource{Name: "listed"}}},
+	})
+
+	registry.Register(&registry.Registration{
+		Name:     testFailingResourceType,
+		Scope:    nuke.Account,
+		Resource: &testCachedResource{},
+		Lister:   &testLister{err: errors.New("AccessDenied: not authorized to list")},
+	})
+
+	registry.Register(&registry.Registration{
+		Name:     testEmptyResourceType,
+		Scope:    nuke.Account,
+		Resource: &testCachedResource{},
+		Lister:   &testLister{},
+	})
+}
+
+func TestUpdateCache_ListingFailed(t *testing.T) {
+	c, err := cache.New(t.TempDir(), time.Hour)
+	assert.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	resourceTypes := []string{testCachedResourceType, testFailingResourceType, testEmptyResourceType}
+
+	s := &nuke.Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: resourceTypes,
+		Opts: &nuke.ListerOpts{Region: nuke.NewRegion("us-east-1",
+			func(_, _ string) string { return "test" },
+			func(_, _ string) (*session.Session, error) { return session.NewSession() },
+			func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+		)},
+	}
+
+	items, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	settingsSet := &settings.Settings{}
+	updateCache(c, "000000000000", map[string][]string{"us-east-1": resourceTypes},
+		map[string]*nuke.Scanner{"us-east-1": s}, items, settingsSet, logger)
+
+	records, ok := c.Get("000000000000", "us-east-1", testCachedResourceType, settingsSet.Get(testCachedResourceType))
+	assert.True(t, ok)
+	assert.Len(t, records, 1)
+
+	// a resource type without resources is cached, so that it is not listed again
+	records, ok = c.Get("000000000000", "us-east-1", testEmptyResourceType, settingsSet.Get(testEmptyResourceType))
+	assert.True(t, ok)
+	assert.Empty(t, records)
+
+	// a resource type whose listing failed is not, it would hide its resources until the entry expires
+	_, ok = c.Get("000000000000", "us-east-1", testFailingResourceType, settingsSet.Get(testFailingResourceType))
+	assert.False(t, ok)
+}
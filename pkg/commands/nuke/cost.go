@@ -0,0 +1,42 @@
+package nuke
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/cost"
+)
+
+// maxCostLines is the number of resource types and regions that are printed in the cost estimate
+const maxCostLines = 10
+
+// printCostEstimate prints the total, per resource type and per region estimated monthly cost of the resources
+func printCostEstimate(logger *logrus.Logger, estimate *cost.Estimate, dryRun bool) {
+	printLog := logger.WithField("_handler", "println")
+
+	subject := "removed"
+	if dryRun {
+		subject = "that would be removed"
+	}
+
+	printLog.Infof("Estimated monthly cost of the resources %s: %.2f %s (%d priced, %d without a price)",
+		subject, estimate.Total, estimate.Currency, estimate.Priced, estimate.Unpriced)
+
+	if estimate.Priced == 0 {
+		return
+	}
+
+	printAmounts(printLog, "By resource type:", cost.Sorted(estimate.ByType), estimate.Currency)
+	printAmounts(printLog, "By region:", cost.Sorted(estimate.ByRegion), estimate.Currency)
+}
+
+func printAmounts(printLog *logrus.Entry, title string, amounts []cost.Amount, currency string) {
+	printLog.Info(title)
+	for i, amount := range amounts {
+		if i == maxCostLines {
+			printLog.Infof("> ... and %d more", len(amounts)-maxCostLines)
+			break
+		}
+
+		printLog.Infof("> %s: %.2f %s", amount.Name, amount.Monthly, currency)
+	}
+}
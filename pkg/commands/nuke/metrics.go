@@ -0,0 +1,123 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/metrics"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// startMetricsServer starts serving the metrics on the given address, the returned function stops the server
+func startMetricsServer(addr string, logger *logrus.Logger) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Error("metrics server stopped")
+		}
+	}()
+
+	logger.Infof("serving metrics on http://%s/metrics", listener.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(ctx)
+	}, nil
+}
+
+// registerMetrics registers the hooks that record the progress of the run for an account. The returned function is
+// called with the queue once the run has ended, only then is it known which resources failed for good.
+func registerMetrics(n *nuke.Runner, accountID string) (finish func(q *queue.Queue)) {
+	removed := make(map[*queue.Item]bool)
+
+	n.RegisterScanHook(func(q *queue.Queue) error {
+		for _, item := range q.GetItems() {
+			metrics.ResourcesTotal.Inc(accountID, item.Type, item.Owner, metrics.ResourceDiscovered)
+			switch {
+			case nuke.IsProtected(item):
+				metrics.ResourcesTotal.Inc(accountID, item.Type, item.Owner, metrics.ResourceProtected)
+			case item.GetState() == queue.ItemStateFiltered:
+				metrics.ResourcesTotal.Inc(accountID, item.Type, item.Owner, metrics.ResourceFiltered)
+			}
+		}
+
+		metrics.LastProgress.Set(float64(time.Now().Unix()), accountID)
+		updateQueueMetrics(accountID, q)
+
+		return nil
+	})
+
+	countRemoved := func(q *queue.Queue) {
+		for _, item := range q.GetItems() {
+			if item.GetState() != queue.ItemStateFinished || removed[item] {
+				continue
+			}
+
+			removed[item] = true
+			metrics.ResourcesTotal.Inc(accountID, item.Type, item.Owner, metrics.ResourceRemoved)
+			metrics.LastProgress.Set(float64(time.Now().Unix()), accountID)
+		}
+	}
+
+	n.RegisterIterationHook(func(iteration int, q *queue.Queue) error {
+		metrics.RunIteration.Set(float64(iteration), accountID)
+
+		countRemoved(q)
+		updateQueueMetrics(accountID, q)
+
+		return nil
+	})
+
+	// A failed removal is retried, a resource only counts as failed when it is still failed at the end of the run,
+	// just like in the report
+	return func(q *queue.Queue) {
+		countRemoved(q)
+
+		for _, item := range q.GetItems() {
+			if item.GetState() == queue.ItemStateFailed {
+				metrics.ResourcesTotal.Inc(accountID, item.Type, item.Owner, metrics.ResourceFailed)
+			}
+		}
+
+		updateQueueMetrics(accountID, q)
+	}
+}
+
+// updateQueueMetrics sets the number of items in the queue by resource type, region and state
+func updateQueueMetrics(accountID string, q *queue.Queue) {
+	type key struct {
+		resourceType, region string
+		state                queue.ItemState
+	}
+
+	counts := make(map[key]int)
+	for _, item := range q.GetItems() {
+		counts[key{item.Type, item.Owner, item.GetState()}]++
+	}
+
+	metrics.QueueItems.Reset(accountID)
+	for k, count := range counts {
+		metrics.QueueItems.Set(float64(count), accountID, k.resourceType, k.region, k.state.String())
+	}
+}
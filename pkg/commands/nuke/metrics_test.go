@@ -0,0 +1,110 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/session" //nolint:staticcheck
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	libnuke "github.com/ekristen/libnuke/pkg/nuke"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/metrics"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+const testMetricsResourceType = "TestMetricsResource"
+
+// testMetricsResources are the resources that currently exist, with the number of removals that still fail
+var testMetricsResources = map[string]int{}
+
+type testMetricsResource struct {
+	Name string
+}
+
+func (r *testMetricsResource) Remove(_ context.Context) error {
+	if testMetricsResources[r.Name] > 0 {
+		testMetricsResources[r.Name]--
+		return errors.New("DependencyViolation")
+	}
+
+	delete(testMetricsResources, r.Name)
+	return nil
+}
+
+func (r *testMetricsResource) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.Name)
+}
+
+type testMetricsLister struct{}
+
+func (l *testMetricsLister) List(_ context.Context, _ interface{}) ([]resource.Resource, error) {
+	var resources []resource.Resource
+	for name := range testMetricsResources {
+		resources = append(resources, &testMetricsResource{Name: name})
+	}
+
+	return resources, nil
+}
+
+func init() {
+	registry.Register(&registry.Registration{
+		Name:     testMetricsResourceType,
+		Scope:    nuke.Account,
+		Resource: &testMetricsResource{},
+		Lister:   &testMetricsLister{},
+	})
+}
+
+func TestRegisterMetrics_Failed(t *testing.T) {
+	const accountID = "333333333333"
+
+	// flaky fails to be removed twice before it is removed, broken is never removed
+	testMetricsResources = map[string]int{"flaky": 2, "broken": 1000}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	n := nuke.NewRunner(libnuke.New(&libnuke.Parameters{
+		Force:      true,
+		ForceSleep: 3,
+		NoDryRun:   true,
+	}, filter.Filters{}, nil))
+	n.SetLogger(logrus.NewEntry(logger))
+	n.SetRunSleep(time.Millisecond)
+	n.RegisterPrompt(func() error { return nil })
+
+	assert.NoError(t, n.RegisterScanner(&nuke.Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testMetricsResourceType},
+		Opts: &nuke.ListerOpts{Region: nuke.NewRegion("us-east-1",
+			func(_, _ string) string { return "test" },
+			func(_, _ string) (*session.Session, error) { return session.NewSession() },
+			func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+		)},
+	}))
+
+	finish := registerMetrics(n, accountID)
+	assert.Error(t, n.Run(context.Background()), "the run fails as broken cannot be removed")
+
+	assert.Equal(t, float64(0),
+		metrics.ResourcesTotal.Value(accountID, testMetricsResourceType, "us-east-1", metrics.ResourceFailed),
+		"nothing counts as failed before the run has ended")
+
+	finish(n.Queue)
+
+	assert.Equal(t, float64(1),
+		metrics.ResourcesTotal.Value(accountID, testMetricsResourceType, "us-east-1", metrics.ResourceFailed))
+	assert.Equal(t, float64(1),
+		metrics.ResourcesTotal.Value(accountID, testMetricsResourceType, "us-east-1", metrics.ResourceRemoved))
+}
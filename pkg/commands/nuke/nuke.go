@@ -2,13 +2,13 @@ package nuke
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
-	"strings"
+	"sync"
 	"time"
 
-	"github.com/gotidy/ptr"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
 
@@ -16,17 +16,25 @@ import (
 
 	libconfig "github.com/ekristen/libnuke/pkg/config"
 	libnuke "github.com/ekristen/libnuke/pkg/nuke"
+	"github.com/ekristen/libnuke/pkg/queue"
 	"github.com/ekristen/libnuke/pkg/registry"
 	"github.com/ekristen/libnuke/pkg/scanner"
-	"github.com/ekristen/libnuke/pkg/types"
 
+	"github.com/ekristen/aws-nuke/v3/pkg/audit"
 	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/cache"
+	"github.com/ekristen/aws-nuke/v3/pkg/checkpoint"
 	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
 	"github.com/ekristen/aws-nuke/v3/pkg/common"
 	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/cost"
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+	"github.com/ekristen/aws-nuke/v3/pkg/notify"
 	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
-
-	"github.com/ekristen/aws-nuke/v3/resources"
+	"github.com/ekristen/aws-nuke/v3/pkg/plan"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+	"github.com/ekristen/aws-nuke/v3/pkg/review"
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
 )
 
 // ConfigureCreds is a helper function to configure the awsutil.Credentials object from the cli.Context
@@ -44,23 +52,284 @@ func ConfigureCreds(c *cli.Command) (creds *awsutil.Credentials) {
 	return creds
 }
 
-func execute(baseCtx context.Context, c *cli.Command) error { //nolint:funlen,gocyclo
+func execute(ctx context.Context, c *cli.Command) error {
+	return run(ctx, c, nil)
+}
+
+// runOptions are the options that are resolved once per invocation and shared by every account that is nuked
+type runOptions struct {
+	applyPlan    *plan.Plan
+	reportPath   string
+	reportFormat report.Format
+	outPlan      string
+	stateFile    string
+	resume       bool
+	interactive  bool
+	reviewOut    string
+	minAge       time.Duration
+	priceTable   *cost.PriceTable
+	notifier     *notify.Notifier
+	auditLog     *audit.Log
+	// scanOnly stops the run once the scan has completed without prompting, nothing is removed
+	scanOnly bool
+	// inventory holds the resources of a previously captured inventory, they are used instead of scanning the account
+	inventory []*inventory.Record
+	// cache holds the resources that previous dry runs listed, nil when no cache is used
+	cache *cache.Cache
+	// onRunner is called with the runner of every account before the run starts, to register additional hooks
+	onRunner func(n *nuke.Runner)
+	// logger is the logger of the run, the standard logger when nil
+	logger *logrus.Logger
+}
+
+// run parses the configuration and then performs the full validate, prompt, scan and remove cycle against the
+// authenticated account, or against every account when running in multi-account mode. When applyPlan is set the run
+// is always a live run and only the resources that are part of the plan are removed.
+func run(baseCtx context.Context, c *cli.Command, applyPlan *plan.Plan) error { //nolint:funlen
 	ctx, cancel := context.WithCancel(baseCtx)
 	defer cancel()
 
-	defaultRegion := c.String("default-region")
-	creds := ConfigureCreds(c)
+	logger := logrus.StandardLogger()
+	logger.SetOutput(os.Stdout)
+
+	opts := &runOptions{
+		applyPlan:   applyPlan,
+		reportPath:  c.String("report"),
+		outPlan:     c.String("out-plan"),
+		stateFile:   c.String("state-file"),
+		resume:      c.Bool("resume"),
+		interactive: c.Bool("interactive"),
+		reviewOut:   c.String("review-filters"),
+	}
+
+	if opts.resume && opts.stateFile == "" {
+		return fmt.Errorf("--resume requires --state-file")
+	}
+
+	if opts.interactive {
+		if c.Bool("force") {
+			return fmt.Errorf("--interactive cannot be used with --no-prompt")
+		}
+
+		if isMultiAccount(c) && c.Int("account-concurrency") > 1 {
+			return fmt.Errorf("--interactive cannot be used with --account-concurrency greater than 1")
+		}
+
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return fmt.Errorf("--interactive requires a terminal")
+		}
+	}
+
+	if opts.outPlan != "" && (c.Bool("no-dry-run") || applyPlan != nil) {
+		return fmt.Errorf("--out-plan can only be used during a dry run")
+	}
+
+	if c.String("from-inventory") != "" {
+		switch {
+		case c.Bool("no-dry-run") || applyPlan != nil:
+			return fmt.Errorf("--from-inventory can only be used during a dry run")
+		case opts.stateFile != "":
+			return fmt.Errorf("--from-inventory cannot be used with --state-file")
+		case isMultiAccount(c):
+			return fmt.Errorf("--from-inventory cannot be used with multiple accounts")
+		}
+	}
+
+	// Validate the report format up front, there is no point in running a full scan only to fail at the end.
+	if opts.reportPath != "" {
+		var err error
+		opts.reportFormat, err = report.ParseFormat(c.String("report-format"))
+		if err != nil {
+			return err
+		}
+	}
+
+	parsedConfig, err := loadConfig(c, logger)
+	if err != nil {
+		return err
+	}
+
+	opts.notifier, err = notify.New(parsedConfig.Notifications, logger.WithField("component", "notify"))
+	if err != nil {
+		return err
+	}
+
+	opts.minAge, err = resolveMinAge(c, parsedConfig)
+	if err != nil {
+		return err
+	}
 
+	opts.priceTable, err = loadPriceTable(c)
+	if err != nil {
+		return err
+	}
+
+	opts.auditLog, err = openAuditLog(c, c.Bool("no-dry-run") || applyPlan != nil, logger)
+	if err != nil {
+		return err
+	}
+	if opts.auditLog != nil {
+		defer opts.auditLog.Close()
+	}
+
+	if c.String("metrics-listen") != "" {
+		stopMetrics, err := startMetricsServer(c.String("metrics-listen"), logger)
+		if err != nil {
+			return err
+		}
+		defer stopMetrics()
+	}
+
+	stopTracing, err := startTracing(c, logger)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	if c.String("cache-dir") != "" {
+		opts.cache, err = cache.New(c.String("cache-dir"), c.Duration("cache-ttl"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.String("from-inventory") != "" {
+		return runFromInventory(ctx, c, parsedConfig, opts)
+	}
+
+	if isMultiAccount(c) {
+		return runAccounts(ctx, c, parsedConfig, opts)
+	}
+
+	creds := ConfigureCreds(c)
 	if err := creds.Validate(); err != nil {
 		return err
 	}
 
-	// Create the parameters object that will be used to configure the nuke process.
+	creds.Retry, err = awsutil.NewRetryPolicy(parsedConfig.Retry)
+	if err != nil {
+		return err
+	}
+	creds.Throttles = awsutil.NewThrottleCounter()
+
+	// Create the AWS Account object. This will be used to get the account ID and aliases for the account.
+	account, err := awsutil.NewAccount(creds, parsedConfig.CustomEndpoints)
+	if err != nil {
+		return err
+	}
+
+	_, err = nukeAccount(ctx, c, parsedConfig, account, opts)
+	return err
+}
+
+// loadConfig parses the user supplied configuration file and sets the default region for the AWS SDK to use
+func loadConfig(c *cli.Command, logger *logrus.Logger) (*config.Config, error) {
+	defaultRegion := c.String("default-region")
+
+	// Parse the user supplied configuration file to pass in part to configure the nuke process.
+	parsedConfig, err := config.New(libconfig.Options{
+		Path:         c.String("config"),
+		Deprecations: registry.GetDeprecatedResourceTypeMapping(),
+		Log:          logger.WithField("component", "config"),
+	})
+	if err != nil {
+		logger.Errorf("Failed to parse config file %s", c.String("config"))
+		return nil, err
+	}
+
+	if err := setDefaultRegion(defaultRegion, parsedConfig.CustomEndpoints, logger); err != nil {
+		return nil, err
+	}
+
+	// The retry policy is created for every account, an invalid policy is reported before anything is scanned
+	if _, err := awsutil.NewRetryPolicy(parsedConfig.Retry); err != nil {
+		return nil, err
+	}
+
+	// The protect tag is parsed for every account, an invalid tag is reported before anything is scanned
+	if parsedConfig.ProtectTag != "" {
+		if _, err := nuke.ParseProtectTag(parsedConfig.ProtectTag); err != nil {
+			return nil, err
+		}
+	}
+
+	// The expiry filter is created for every account, an invalid policy is reported before anything is scanned
+	if parsedConfig.ExpiryTag != "" || parsedConfig.NoExpiryTag != "" {
+		if _, err := nuke.NewExpiryFilter(parsedConfig.ExpiryTag, parsedConfig.NoExpiryTag); err != nil {
+			return nil, err
+		}
+	}
+
+	return parsedConfig, nil
+}
+
+// setDefaultRegion sets the default region for the AWS SDK to use, a custom region must have its endpoints configured
+// to determine its partition
+func setDefaultRegion(defaultRegion string, customEndpoints config.CustomEndpoints, logger *logrus.Logger) error {
+	if defaultRegion == "" {
+		return nil
+	}
+
+	awsutil.DefaultRegionID = defaultRegion
+
+	partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), defaultRegion)
+	if !ok {
+		if customEndpoints.GetRegion(defaultRegion) == nil {
+			err := fmt.Errorf(
+				"the custom region '%s' must be specified in the configuration 'endpoints'"+
+					" to determine its partition", defaultRegion)
+			logger.WithError(err).Errorf("unable to resolve partition for region: %s", defaultRegion)
+			return err
+		}
+	}
+
+	awsutil.DefaultAWSPartitionID = partition.ID()
+
+	return nil
+}
+
+// hashConfig returns the hash that ties plans and checkpoints to the configuration, it covers the files that the
+// configuration includes and the environment variables it uses
+func hashConfig(path string) (string, error) {
+	composed, err := config.Compose(path)
+	if err != nil {
+		return "", err
+	}
+
+	return composed.Hash()
+}
+
+// resolveMinAge returns the minimum age of resources that are removed, the --older-than flag takes precedence over
+// min-age in the config. Zero means resources are removed regardless of their age.
+func resolveMinAge(c *cli.Command, parsedConfig *config.Config) (time.Duration, error) {
+	value := c.String("older-than")
+	if value == "" {
+		value = parsedConfig.MinAge
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	return nuke.ParseAge(value)
+}
+
+// loadPriceTable loads the price table for the cost estimate, nil is returned when no estimate was requested
+func loadPriceTable(c *cli.Command) (*cost.PriceTable, error) {
+	if !c.Bool("estimate-cost") && c.String("price-table") == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	return cost.Load(c.String("price-table"))
+}
+
+// newParameters creates the parameters object that will be used to configure the nuke process.
+func newParameters(c *cli.Command, applyPlan *plan.Plan) *libnuke.Parameters {
 	params := &libnuke.Parameters{
 		Force:          c.Bool("force"),
 		ForceSleep:     c.Int("force-sleep"),
 		Quiet:          c.Bool("quiet"),
-		NoDryRun:       c.Bool("no-dry-run"),
+		NoDryRun:       c.Bool("no-dry-run") || applyPlan != nil,
 		Includes:       c.StringSlice("include"),
 		Excludes:       c.StringSlice("exclude"),
 		Alternatives:   c.StringSlice("cloud-control"),
@@ -77,305 +346,748 @@ func execute(baseCtx context.Context, c *cli.Command) error { //nolint:funlen,go
 		}
 	}
 
-	logger := logrus.StandardLogger()
-	logger.SetOutput(os.Stdout)
+	return params
+}
 
-	// Parse the user supplied configuration file to pass in part to configure the nuke process.
-	parsedConfig, err := config.New(libconfig.Options{
-		Path:         c.String("config"),
-		Deprecations: registry.GetDeprecatedResourceTypeMapping(),
-		Log:          logger.WithField("component", "config"),
-	})
-	if err != nil {
-		logger.Errorf("Failed to parse config file %s", c.String("config"))
-		return err
+// accountResult is the outcome of a run against a single account
+type accountResult struct {
+	AccountID string
+	Alias     string
+	Counts    map[report.State]int
+	Duration  time.Duration
+	Throttles int
+}
+
+// cloudControlLock guards the dynamic registration of Cloud Control resource types, which modifies the global
+// registry and can happen from multiple accounts at once.
+var cloudControlLock sync.Mutex
+
+// nukeAccount performs the full validate, prompt, scan and remove cycle against a single account.
+func nukeAccount( //nolint:funlen,gocyclo
+	ctx context.Context, c *cli.Command, parsedConfig *config.Config, account *awsutil.Account, opts *runOptions,
+) (*accountResult, error) {
+	started := time.Now()
+	logger := logrus.StandardLogger()
+	if opts.logger != nil {
+		logger = opts.logger
+	}
+	params := newParameters(c, opts.applyPlan)
+	if opts.scanOnly {
+		params.NoDryRun = false
 	}
 
-	// Set the default region for the AWS SDK to use.
-	if defaultRegion != "" {
-		awsutil.DefaultRegionID = defaultRegion
+	// The plan, the checkpoint and the config file are tied together by a hash of the config file
+	var configHash string
+	if opts.applyPlan != nil || opts.outPlan != "" || opts.stateFile != "" {
+		var err error
+		configHash, err = hashConfig(c.String("config"))
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), defaultRegion)
-		if !ok {
-			if parsedConfig.CustomEndpoints.GetRegion(defaultRegion) == nil {
-				err = fmt.Errorf(
-					"the custom region '%s' must be specified in the configuration 'endpoints'"+
-						" to determine its partition", defaultRegion)
-				logger.WithError(err).Errorf("unable to resolve partition for region: %s", defaultRegion)
-				return err
-			}
+	if opts.applyPlan != nil {
+		if err := opts.applyPlan.Validate(account.ID(), configHash, common.AppVersion.String()); err != nil {
+			return nil, err
 		}
 
-		awsutil.DefaultAWSPartitionID = partition.ID()
+		logger.Infof("applying plan created at %s with %d resources",
+			opts.applyPlan.CreatedAt.Format(time.RFC3339), len(opts.applyPlan.Resources))
 	}
 
-	// Create the AWS Account object. This will be used to get the account ID and aliases for the account.
-	account, err := awsutil.NewAccount(creds, parsedConfig.CustomEndpoints)
-	if err != nil {
-		return err
+	// Load the checkpoint of the previous run when resuming, otherwise start with an empty one
+	var state *checkpoint.Checkpoint
+	if opts.stateFile != "" {
+		state = checkpoint.New(account.ID(), configHash, common.AppVersion.String())
+	}
+
+	if opts.resume {
+		previous, err := checkpoint.Load(opts.stateFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			logger.Warnf("no checkpoint found at %s, starting from the beginning", opts.stateFile)
+		case err != nil:
+			return nil, err
+		default:
+			if err := previous.Validate(account.ID(), configHash, common.AppVersion.String()); err != nil {
+				return nil, err
+			}
+
+			logger.Infof("resuming from checkpoint last updated at %s",
+				previous.UpdatedAt.Format(time.RFC3339))
+			state = previous
+		}
 	}
 
 	// Get the filters for the account that is being connected to via the AWS SDK.
 	filters, err := parsedConfig.Filters(account.ID())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Instantiate libnuke
-	n := libnuke.New(params, filters, parsedConfig.Settings)
+	// Instantiate libnuke, the runner drives it so that aws-nuke can hook into the different phases of the run
+	n := nuke.NewRunner(libnuke.New(params, filters, parsedConfig.Settings))
 
 	n.SetRunSleep(c.Duration("run-sleep-delay"))
 	n.SetLogger(logger.WithField("component", "libnuke"))
 	n.RegisterVersion(common.AppVersion.String())
 
-	// Register our custom validate handler that validates the account and AWS nuke unique alias checks
+	finishMetrics := registerMetrics(n, account.ID())
+
+	if opts.onRunner != nil {
+		opts.onRunner(n)
+	}
+
+	// Register our custom validate handler that validates the account and AWS nuke unique alias checks. The aliases
+	// of an inventory are unknown, nothing can be removed from it so only the account itself is validated.
 	n.RegisterValidateHandler(func() error {
-		return parsedConfig.ValidateAccount(account.ID(), account.Aliases(), c.Bool("no-alias-check"))
+		if opts.inventory != nil {
+			return parsedConfig.Config.ValidateAccount(account.ID())
+		}
+
+		if err := parsedConfig.ValidateAccount(account.ID(), account.Aliases(), c.Bool("no-alias-check")); err != nil {
+			return err
+		}
+
+		if parsedConfig.Organization == nil {
+			return nil
+		}
+
+		orgAccount, err := account.Organization(parsedConfig.Organization.RoleArn)
+		if err != nil {
+			return fmt.Errorf("unable to look up the account in AWS Organizations: %w", err)
+		}
+
+		return parsedConfig.ValidateOrganization(orgAccount)
 	})
 
+	// Create the run report if requested, it is populated from the queue once the run has completed
+	var runReport *report.Report
+	if opts.reportPath != "" {
+		runReport = report.New(account.ID(), account.Alias(), common.AppVersion.String(), !params.NoDryRun)
+	}
+
 	// Register our custom prompt handler that shows the account information
 	p := &nuke.Prompt{Parameters: params, Account: account, Logger: logger}
-	n.RegisterPrompt(p.Prompt)
 
-	// Get any specific account level configuration
-	accountConfig := parsedConfig.Accounts[account.ID()]
+	// The regions are resolved further down, the notifications only need them once the run has started
+	var regions []string
+	newEvent := func(event notify.EventType) *notify.Event {
+		e := &notify.Event{
+			Event:        event,
+			AccountID:    account.ID(),
+			AccountAlias: account.Alias(),
+			Regions:      regions,
+			DryRun:       !params.NoDryRun,
+		}
+		e.SetDuration(time.Since(started))
+		e.SetQueue(n.Queue.GetItems())
+		return e
+	}
 
-	// Get current registered resource names
-	resourceNames := registry.GetNames()
+	// A dry run only prompts once before the scan, a live run prompts a second time once the scan has completed,
+	// the prompt notification is sent when the last prompt has been passed
+	scanned := false
+	n.RegisterPrompt(func() error {
+		if opts.scanOnly {
+			return nil
+		}
 
-	// Combine all the places where alternative resource types can be defined and then dynamically
-	// register them as a Cloud Control resource type.
-	altResourceTypes := types.Collection(registry.ExpandNames(n.Parameters.Alternatives))
-	altResourceTypes = altResourceTypes.Union(parsedConfig.ResourceTypes.GetAlternatives())
-	altResourceTypes = altResourceTypes.Union(accountConfig.ResourceTypes.GetAlternatives())
-	for _, rt := range altResourceTypes {
-		if slices.Contains(resourceNames, rt) {
-			continue
+		if err := p.Prompt(); err != nil {
+			return err
 		}
 
-		resources.RegisterCloudControl(rt)
-	}
+		if !params.NoDryRun || scanned {
+			opts.notifier.Send(ctx, newEvent(notify.EventPrompt))
+		}
 
-	// Resolve the resource types to be used for the nuke process based on the parameters, global configuration, and
-	// account level configuration.
-	resourceTypes := types.ResolveResourceTypes(
-		registry.GetNames(), // note: we want to re-pull the registry here due to the dynamic registration above
-		[]types.Collection{
-			registry.ExpandNames(n.Parameters.Includes),
-			parsedConfig.ResourceTypes.GetIncludes(),
-			accountConfig.ResourceTypes.GetIncludes(),
-		},
-		[]types.Collection{
-			registry.ExpandNames(n.Parameters.Excludes),
-			parsedConfig.ResourceTypes.Excludes,
-			accountConfig.ResourceTypes.Excludes,
-		},
-		[]types.Collection{
-			registry.ExpandNames(n.Parameters.Alternatives),
-			parsedConfig.ResourceTypes.GetAlternatives(),
-			accountConfig.ResourceTypes.GetAlternatives(),
-		},
-		registry.GetAlternativeResourceTypeMapping(),
-	)
+		return nil
+	})
 
-	// If the user has specified the "all" region, then we need to get the enabled regions for the account
-	// and use those. Otherwise, we will use the regions that are specified in the configuration.
-	if slices.Contains(parsedConfig.Regions, "all") {
-		parsedConfig.Regions = account.Regions()
+	// The scanner of each region, they tell which resource types were not listed completely
+	scanners := make(map[string]*nuke.Scanner)
 
-		logger.Info(
-			`"all" detected in region list, only enabled regions and "global" will be used, all others ignored`)
+	// Once the scan has completed, record when items were discovered and restore the state of the previous run
+	n.RegisterScanHook(func(q *queue.Queue) error {
+		scanned = true
 
-		if len(parsedConfig.Regions) > 1 {
-			logger.Warnf(`additional regions defined along with "all", these will be ignored!`)
+		if runReport != nil {
+			runReport.MarkScanned(q.GetItems())
 		}
 
-		logger.Infof("The following regions are enabled for the account (%d total):", len(parsedConfig.Regions))
+		if opts.resume {
+			verified := state.Restore(q.GetItems(), n.SetAttempts)
+			logger.Infof("%d resources that were being removed by the previous run will be verified", verified)
+		}
 
-		printableRegions := make([]string, 0)
-		for i, region := range parsedConfig.Regions {
-			printableRegions = append(printableRegions, region)
-			if i%6 == 0 { // print 5 regions per line
-				logger.Infof("> %s", strings.Join(printableRegions, ", "))
-				printableRegions = make([]string, 0)
-			} else if i == len(parsedConfig.Regions)-1 {
-				logger.Infof("> %s", strings.Join(printableRegions, ", "))
+		// Anything that was discovered since the plan was created is refused
+		if opts.applyPlan != nil {
+			excluded, missing := opts.applyPlan.Restrict(q.GetItems())
+			for _, r := range missing {
+				logger.WithFields(logrus.Fields{
+					"type":   r.Type,
+					"region": r.Region,
+					"name":   r.Identifier,
+				}).Warn("planned resource no longer exists")
 			}
+
+			logger.Infof("%d resources not in the plan were excluded, %d planned resources no longer exist",
+				excluded, len(missing))
+		}
+
+		return saveCheckpoint(state, opts.stateFile, q, n, scanners)
+	})
+
+	// Protect resources that have the protect tag, whatever the filters say
+	if parsedConfig.ProtectTag != "" {
+		protectTag, err := nuke.ParseProtectTag(parsedConfig.ProtectTag)
+		if err != nil {
+			return nil, err
+		}
+		n.SetProtectTag(protectTag)
+	}
+
+	// Protect resources whose expiry tag holds a time that has not passed yet
+	if parsedConfig.ExpiryTag != "" {
+		expiryFilter, err := nuke.NewExpiryFilter(parsedConfig.ExpiryTag, parsedConfig.NoExpiryTag)
+		if err != nil {
+			return nil, err
 		}
+		n.RegisterItemFilter(expiryFilter.Filter)
+	}
+
+	// Protect resources that are younger than the minimum age, those whose age is unknown are only reported
+	if opts.minAge > 0 {
+		ageFilter := nuke.NewAgeFilter(opts.minAge)
+		n.RegisterItemFilter(ageFilter.Filter)
+		n.RegisterScanHook(func(_ *queue.Queue) error {
+			reportUnknownAge(ageFilter, logger)
+			return nil
+		})
+	}
+
+	// Record every attempt to remove a resource in the audit log
+	if opts.auditLog != nil {
+		registerAudit(n, opts.auditLog, account, logger)
 	}
 
+	// Let the operator review the resources before the final prompt, deselected resources are kept
+	if opts.interactive {
+		n.RegisterScanHook(func(q *queue.Queue) error {
+			return reviewResources(account.ID(), q, opts.reviewOut, logger)
+		})
+	}
+
+	// Record when the state of each resource changes, the plan and the review change it before anything is removed
+	if runReport != nil {
+		n.RegisterScanHook(func(q *queue.Queue) error {
+			runReport.MarkUpdated(q.GetItems())
+			return nil
+		})
+		n.RegisterRemoveHook(func(_ context.Context, item *queue.Item, _ int) {
+			runReport.MarkUpdated([]*queue.Item{item})
+		})
+	}
+
+	// Persist the progress after every iteration so that an interrupted run can be resumed
+	n.RegisterIterationHook(func(iteration int, q *queue.Queue) error {
+		if runReport != nil {
+			runReport.MarkUpdated(q.GetItems())
+		}
+
+		if state != nil {
+			state.Iteration = iteration
+		}
+
+		return saveCheckpoint(state, opts.stateFile, q, n, scanners)
+	})
+
+	// Get any specific account level configuration
+	accountConfig := parsedConfig.Accounts[account.ID()]
+
+	resourceTypes := resolveResourceTypes(n.Parameters, parsedConfig, accountConfig)
+	regions = resolveRegions(parsedConfig.Regions, account, logger)
+
+	// Consecutive dry runs reuse the resources that a previous one listed. A live run removes resources, it never
+	// uses the cache and invalidates it instead.
+	useCache := opts.cache != nil && opts.inventory == nil
+	if useCache && params.NoDryRun {
+		if err := opts.cache.Invalidate(account.ID()); err != nil {
+			return nil, fmt.Errorf("unable to invalidate the cache: %w", err)
+		}
+		useCache = false
+	}
+
+	// The resource types that are listed in each region rather than taken from the cache
+	listed := make(map[string][]string)
+
 	// Register the scanners for each region that is defined in the configuration.
-	for _, regionName := range parsedConfig.Regions {
-		// Step 1 - Create the region object
-		region := nuke.NewRegion(regionName, account.ResourceTypeToServiceType, account.NewSession, account.NewConfig)
-
-		// Step 2 - Create the scannerActual object
-		scannerActual, scannerActualErr := scanner.New(&scanner.Config{
-			Owner:         regionName,
-			ResourceTypes: resourceTypes,
-			Opts: &nuke.ListerOpts{
-				Region:    region,
-				AccountID: ptr.String(account.ID()),
-				Logger: logger.WithFields(logrus.Fields{
-					"component": "scanner",
-					"region":    regionName,
-				}),
-			},
-			Logger:          logger,
-			ParallelQueries: c.Int64("parallel-queries"),
-			QueueSize:       c.Int("max-queue-size"),
+	for _, regionName := range regions {
+		// Resource types that the previous run completely removed are not listed again when resuming
+		regionResourceTypes := resourceTypes
+		if opts.resume && len(state.Drained[regionName]) > 0 {
+			regionResourceTypes = resourceTypes.Remove(state.Drained[regionName])
+			logger.Infof("skipping %d resource types in %s that the previous run removed completely",
+				len(state.Drained[regionName]), regionName)
+		}
+
+		// Step 1 - Create the scanner object for the region, see newRegionScanner. An offline run takes the resources
+		// of the region from the inventory instead of listing them, a run with a cache those that are cached.
+		regionScanner := newRegionScanner(c, account, regionName, regionResourceTypes, logger)
+		switch {
+		case opts.inventory != nil:
+			regionScanner.Inventory = inventory.Resources(opts.inventory, regionName, regionResourceTypes)
+		case useCache:
+			regionScanner.Inventory, listed[regionName] = cachedResources(
+				opts.cache, account.ID(), regionName, regionResourceTypes, parsedConfig.Settings, logger)
+		}
+
+		// Step 2 - Register the scanner with the nuke object
+		if err := n.RegisterScanner(regionScanner); err != nil {
+			return nil, err
+		}
+
+		scanners[regionName] = regionScanner
+	}
+
+	// Once scanned the settings have been applied to the resources that were listed, they can be cached
+	if useCache {
+		n.RegisterScanHook(func(q *queue.Queue) error {
+			updateCache(opts.cache, account.ID(), listed, scanners, q.GetItems(), parsedConfig.Settings, logger)
+			return nil
 		})
-		if scannerActualErr != nil {
-			return scannerActualErr
+	}
+
+	// The run is traced as a single trace per account
+	ctx, span := tracing.Start(ctx, "nuke account",
+		tracing.String("aws.account.id", account.ID()),
+		tracing.Bool("aws_nuke.dry_run", !params.NoDryRun),
+	)
+	if span != nil {
+		logger.Infof("tracing run with trace id %s", span.TraceIDString())
+	}
+
+	opts.notifier.Send(ctx, newEvent(notify.EventStart))
+
+	runErr := n.Run(ctx)
+	finishMetrics(n.Queue)
+
+	span.SetError(runErr)
+	span.Finish()
+
+	// The run may have been cancelled, the final notification is still sent
+	if runErr != nil {
+		event := newEvent(notify.EventFail)
+		event.Error = runErr.Error()
+		opts.notifier.Send(context.WithoutCancel(ctx), event)
+	} else {
+		opts.notifier.Send(context.WithoutCancel(ctx), newEvent(notify.EventFinish))
+	}
+
+	if err := saveCheckpoint(state, opts.stateFile, n.Queue, n, scanners); err != nil {
+		logger.WithError(err).Errorf("unable to write checkpoint to %s", opts.stateFile)
+	}
+
+	result := &accountResult{
+		AccountID: account.ID(),
+		Alias:     account.Alias(),
+		Counts:    countStates(n.Queue.GetItems()),
+		Duration:  time.Since(started),
+	}
+
+	if account.Throttles != nil {
+		result.Throttles = account.Throttles.Total()
+		printThrottles(logger, account.Throttles, account.Retry)
+	}
+
+	if runErr == nil && opts.outPlan != "" {
+		outPlan := plan.New(account.ID(), configHash, common.AppVersion.String())
+		for _, r := range outPlan.Add(n.Queue.GetItems()) {
+			logger.WithFields(logrus.Fields{
+				"type":   r.Type,
+				"region": r.Region,
+				"name":   r.Identifier,
+			}).Warn("resource cannot be told apart from another one and is left out of the plan")
 		}
 
-		// Step 3 - Register a mutate function that will be called to modify the lister options for each resource type
-		// see pkg/nuke/resource.go for the MutateOpts function. Its purpose is to create the proper session for the
-		// proper region.
-		regMutateErr := scannerActual.RegisterMutateOptsFunc(nuke.MutateOpts)
-		if regMutateErr != nil {
-			return regMutateErr
+		if err := outPlan.Save(opts.outPlan); err != nil {
+			return result, err
+		}
+
+		logger.Infof("plan with %d resources written to %s", len(outPlan.Resources), opts.outPlan)
+	}
+
+	var estimate *cost.Estimate
+	if opts.priceTable != nil {
+		estimate = opts.priceTable.Estimate(n.Queue.GetItems())
+		printCostEstimate(logger, estimate, !params.NoDryRun)
+	}
+
+	if runReport != nil {
+		runReport.Collect(n.Queue.GetItems(), filters, params.UseFilterGroups)
+		if estimate != nil {
+			runReport.SetCost(estimate)
 		}
+		runReport.Finish(runErr)
 
-		// Step 4 - Register the scannerActual with the nuke object
-		regScanErr := n.RegisterScanner(nuke.Account, scannerActual)
-		if regScanErr != nil {
-			return regScanErr
+		if err := runReport.WriteFile(opts.reportPath, opts.reportFormat); err != nil {
+			logger.WithError(err).Errorf("unable to write report to %s", opts.reportPath)
+			if runErr == nil {
+				return result, err
+			}
+		} else {
+			logger.Infof("report written to %s", opts.reportPath)
 		}
 	}
 
-	return n.Run(ctx)
+	return result, runErr
 }
 
-func init() { //nolint:funlen
-	flags := []cli.Flag{
-		&cli.StringFlag{
-			Name:    "config",
-			Aliases: []string{"c"},
-			Usage:   "path to config file",
-			Value:   "config.yaml",
-			Action:  common.CheckFilePath,
-		},
-		&cli.StringSliceFlag{
-			Name:    "include",
-			Usage:   "only run against these resource types",
-			Aliases: []string{"target"},
-		},
-		&cli.StringSliceFlag{
-			Name:    "exclude",
-			Aliases: []string{"exclude-resource"},
-			Usage:   "exclude these resource types",
-		},
-		&cli.StringSliceFlag{
-			Name:  "cloud-control",
-			Usage: "use these resource types with the Cloud Control API instead of the default",
-		},
-		&cli.BoolFlag{
-			Name:    "quiet",
-			Aliases: []string{"q"},
-			Usage:   "hide filtered messages",
-		},
-		&cli.BoolFlag{
-			Name:  "no-dry-run",
-			Usage: "actually run the removal of the resources after discovery",
-		},
-		&cli.BoolFlag{
-			Name:    "no-prompt",
-			Usage:   "disable prompting for verification to run",
-			Aliases: []string{"force"},
-		},
-		&cli.IntFlag{
-			Name:    "prompt-delay",
-			Usage:   "seconds to delay after prompt before running (minimum: 3 seconds)",
-			Value:   10,
-			Aliases: []string{"force-sleep"},
-			Action:  common.CheckRealInt,
-		},
-		&cli.IntFlag{
-			Name:   "max-wait-retries",
-			Usage:  "maximum number of retries to wait for dependencies to be removed",
-			Action: common.CheckRealInt,
-		},
-		&cli.DurationFlag{
-			Name:    "run-sleep-delay",
-			Sources: cli.EnvVars("AWS_NUKE_RUN_SLEEP_DELAY"),
-			Usage:   "time to sleep between run/loops of resource deletions, default is 5 seconds",
-			Value:   5 * time.Second,
-		},
-		&cli.BoolFlag{
-			Name:  "no-alias-check",
-			Usage: "disable aws account alias check - requires entry in config as well",
-		},
-		&cli.StringSliceFlag{
-			Name:  "feature-flag",
-			Usage: "enable experimental behaviors that may not be fully tested or supported",
-		},
-		&cli.StringFlag{
-			Name:    "default-region",
-			Sources: cli.EnvVars("AWS_DEFAULT_REGION"),
-			Usage:   "the default aws region to use when setting up the aws auth session",
-		},
-		&cli.StringFlag{
-			Name:    "access-key-id",
-			Sources: cli.EnvVars("AWS_ACCESS_KEY_ID"),
-			Usage:   "the aws access key id to use when setting up the aws auth session",
-		},
-		&cli.StringFlag{
-			Name:    "secret-access-key",
-			Sources: cli.EnvVars("AWS_SECRET_ACCESS_KEY"),
-			Usage:   "the aws secret access key to use when setting up the aws auth session",
-		},
-		&cli.StringFlag{
-			Name:    "session-token",
-			Sources: cli.EnvVars("AWS_SESSION_TOKEN"),
-			Usage:   "the aws session token to use when setting up the aws auth session, typically used for temporary credentials",
-		},
-		&cli.StringFlag{
-			Name:    "profile",
-			Sources: cli.EnvVars("AWS_PROFILE"),
-			Usage:   "the aws profile to use when setting up the aws auth session, typically used for shared credentials files",
-		},
-		&cli.StringFlag{
-			Name:    "assume-role-arn",
-			Sources: cli.EnvVars("AWS_ASSUME_ROLE_ARN"),
-			Usage:   "the role arn to assume using the credentials provided in the profile or statically set",
-		},
-		&cli.StringFlag{
-			Name:    "assume-role-session-name",
-			Sources: cli.EnvVars("AWS_ASSUME_ROLE_SESSION_NAME"),
-			Usage:   "the session name to provide for the assumed role",
-		},
-		&cli.StringFlag{
-			Name:    "assume-role-external-id",
-			Sources: cli.EnvVars("AWS_ASSUME_ROLE_EXTERNAL_ID"),
-			Usage:   "the external id to provide for the assumed role",
-		},
-		&cli.IntFlag{
-			Name:    "parallel-queries",
-			Usage:   "CAUTION! ADVANCED USAGE! number of parallel resource queries to run at a time",
-			Sources: cli.EnvVars("AWS_NUKE_PARALLEL_QUERIES"),
-			Value:   scanner.DefaultParallelQueries,
-			Hidden:  true,
-		},
-		&cli.IntFlag{
-			Name:    "max-queue-size",
-			Usage:   "CAUTION! ADVANCED USAGE! the max number of items to queue up before aws-nuke will error",
-			Sources: cli.EnvVars("AWS_NUKE_MAX_QUEUE_SIZE"),
-			Value:   scanner.DefaultQueueSize,
-			Hidden:  true,
-		},
+// reportUnknownAge warns about the resources that may be removed even though their age could not be determined
+func reportUnknownAge(ageFilter *nuke.AgeFilter, logger *logrus.Logger) {
+	unknown := ageFilter.UnknownByType()
+	if len(unknown) == 0 {
+		return
+	}
+
+	resourceTypes := make([]string, 0, len(unknown))
+	for resourceType := range unknown {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	slices.Sort(resourceTypes)
+
+	logger.Warnf("the age of %d resources is unknown, they are not protected by the minimum age of %s:",
+		len(ageFilter.Unknown), nuke.FormatAge(ageFilter.MinAge))
+	for _, resourceType := range resourceTypes {
+		logger.Warnf("> %s: %d", resourceType, unknown[resourceType])
 	}
+}
+
+// printThrottles prints the number of API calls that were throttled per service, which helps to tune
+// --parallel-queries and the retry policy
+func printThrottles(logger *logrus.Logger, counter *awsutil.ThrottleCounter, policy *awsutil.RetryPolicy) {
+	printLog := logger.WithField("_handler", "println")
+
+	throttles := counter.Throttles(policy)
+	printLog.Infof("API calls throttled: %d", counter.Total())
 
+	for _, t := range throttles {
+		if t.Rate > 0 {
+			printLog.Infof("> %s: %d, limited to %.1f calls per second", t.Service, t.Throttles, t.Rate)
+		} else {
+			printLog.Infof("> %s: %d", t.Service, t.Throttles)
+		}
+	}
+}
+
+// reviewResources runs the interactive review of the resources that would be removed and writes the filters for the
+// deselected resources to path, or prints them if no path is set
+func reviewResources(accountID string, q *queue.Queue, path string, logger *logrus.Logger) error {
+	r := review.New(accountID, q.GetItems())
+	if r.Len() == 0 {
+		return nil
+	}
+
+	if err := r.Run(os.Stdin, os.Stdout); err != nil {
+		return err
+	}
+
+	if deselected := r.Apply(); deselected == 0 {
+		return nil
+	}
+
+	snippet, err := r.Snippet()
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		fmt.Printf("Add the following to the config to keep the deselected resources in future runs:\n\n%s\n", snippet)
+		return nil
+	}
+
+	if err := os.WriteFile(path, snippet, 0o600); err != nil {
+		return fmt.Errorf("unable to write review filters: %w", err)
+	}
+
+	logger.Infof("filters for the deselected resources written to %s", path)
+
+	return nil
+}
+
+// saveCheckpoint records the state of every item in the queue, and the resource types that were completely removed,
+// and writes the checkpoint to disk
+func saveCheckpoint(
+	state *checkpoint.Checkpoint, path string, q *queue.Queue, n *nuke.Runner, scanners map[string]*nuke.Scanner,
+) error {
+	if state == nil {
+		return nil
+	}
+
+	incomplete := make(map[string]map[string]error, len(scanners))
+	for region, s := range scanners {
+		incomplete[region] = s.Incomplete()
+	}
+
+	state.Update(q.GetItems(), n.Attempts)
+	state.UpdateDrained(q.GetItems(), incomplete)
+
+	if err := state.Save(path); err != nil {
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// runFlags are the flags of the run command, the apply and serve commands share most of them
+var runFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "path to config file",
+		Value:   "config.yaml",
+		Action:  common.CheckFilePath,
+	},
+	&cli.StringSliceFlag{
+		Name:    "include",
+		Usage:   "only run against these resource types",
+		Aliases: []string{"target"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "exclude",
+		Aliases: []string{"exclude-resource"},
+		Usage:   "exclude these resource types",
+	},
+	&cli.StringSliceFlag{
+		Name:  "cloud-control",
+		Usage: "use these resource types with the Cloud Control API instead of the default",
+	},
+	&cli.BoolFlag{
+		Name:    "quiet",
+		Aliases: []string{"q"},
+		Usage:   "hide filtered messages",
+	},
+	&cli.BoolFlag{
+		Name:  "no-dry-run",
+		Usage: "actually run the removal of the resources after discovery",
+	},
+	&cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "review the resources that would be removed after the scan and deselect the ones to keep",
+	},
+	&cli.StringFlag{
+		Name:  "review-filters",
+		Usage: "write the filters for the resources deselected with --interactive to this path instead of printing them",
+	},
+	&cli.StringFlag{
+		Name:  "older-than",
+		Usage: "only remove resources older than this age, for example 7d, 2w or 36h, overrides min-age in the config",
+	},
+	&cli.StringFlag{
+		Name:  "from-inventory",
+		Usage: "dry run against the resources of an inventory instead of scanning the account, no credentials needed",
+	},
+	&cli.StringFlag{
+		Name:  "cache-dir",
+		Usage: "reuse the resources listed by previous dry runs from this directory, a live run invalidates the cache",
+	},
+	&cli.DurationFlag{
+		Name:  "cache-ttl",
+		Usage: "how long the resources in --cache-dir are reused before they are listed again",
+		Value: time.Hour,
+	},
+	&cli.StringFlag{
+		Name:  "out-plan",
+		Usage: "write the resources that would be removed to this path, to be used with the apply command",
+	},
+	&cli.BoolFlag{
+		Name:    "no-prompt",
+		Usage:   "disable prompting for verification to run",
+		Aliases: []string{"force"},
+	},
+	&cli.IntFlag{
+		Name:    "prompt-delay",
+		Usage:   "seconds to delay after prompt before running (minimum: 3 seconds)",
+		Value:   10,
+		Aliases: []string{"force-sleep"},
+		Action:  common.CheckRealInt,
+	},
+	&cli.IntFlag{
+		Name:   "max-wait-retries",
+		Usage:  "maximum number of retries to wait for dependencies to be removed",
+		Action: common.CheckRealInt,
+	},
+	&cli.DurationFlag{
+		Name:    "run-sleep-delay",
+		Sources: cli.EnvVars("AWS_NUKE_RUN_SLEEP_DELAY"),
+		Usage:   "time to sleep between run/loops of resource deletions, default is 5 seconds",
+		Value:   5 * time.Second,
+	},
+	&cli.BoolFlag{
+		Name:  "no-alias-check",
+		Usage: "disable aws account alias check - requires entry in config as well",
+	},
+	&cli.StringSliceFlag{
+		Name:  "feature-flag",
+		Usage: "enable experimental behaviors that may not be fully tested or supported",
+	},
+	&cli.StringFlag{
+		Name:  "report",
+		Usage: "write a machine-readable report of every discovered resource and its final state to this path",
+	},
+	&cli.StringFlag{
+		Name:  "report-format",
+		Usage: "format of the report, one of: json, csv, ndjson",
+		Value: string(report.FormatJSON),
+	},
+	&cli.BoolFlag{
+		Name:  "estimate-cost",
+		Usage: "print the estimated monthly cost of the resources that are removed, it is also added to the report",
+	},
+	&cli.StringFlag{
+		Name:  "price-table",
+		Usage: "path to a price table that replaces the bundled prices per resource type, implies --estimate-cost",
+	},
+	&cli.StringFlag{
+		Name:  "audit-log",
+		Usage: "append a hash-chained record of every attempt to remove a resource to this path during a live run",
+	},
+	&cli.StringFlag{
+		Name:  "state-file",
+		Usage: "periodically write the progress of the run to this path so that it can be resumed",
+	},
+	&cli.BoolFlag{
+		Name:  "resume",
+		Usage: "resume the run recorded in --state-file, resource types that it removed completely are not listed again",
+	},
+	&cli.StringFlag{
+		Name:  "metrics-listen",
+		Usage: "serve prometheus metrics about the progress of the run on this address, for example :9090",
+	},
+	&cli.StringFlag{
+		Name:    "trace-endpoint",
+		Sources: cli.EnvVars("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Usage:   "export opentelemetry traces of the run to this OTLP/HTTP endpoint, for example http://localhost:4318",
+	},
+	&cli.StringSliceFlag{
+		Name:  "trace-header",
+		Usage: "header to send to the trace endpoint as key=value, for example for authentication",
+	},
+	&cli.StringFlag{
+		Name:  "trace-file",
+		Usage: "write opentelemetry traces of the run to this path, one OTLP JSON request per line",
+	},
+	&cli.StringFlag{
+		Name:    "default-region",
+		Sources: cli.EnvVars("AWS_DEFAULT_REGION"),
+		Usage:   "the default aws region to use when setting up the aws auth session",
+	},
+	&cli.StringFlag{
+		Name:    "access-key-id",
+		Sources: cli.EnvVars("AWS_ACCESS_KEY_ID"),
+		Usage:   "the aws access key id to use when setting up the aws auth session",
+	},
+	&cli.StringFlag{
+		Name:    "secret-access-key",
+		Sources: cli.EnvVars("AWS_SECRET_ACCESS_KEY"),
+		Usage:   "the aws secret access key to use when setting up the aws auth session",
+	},
+	&cli.StringFlag{
+		Name:    "session-token",
+		Sources: cli.EnvVars("AWS_SESSION_TOKEN"),
+		Usage:   "the aws session token to use when setting up the aws auth session, typically used for temporary credentials",
+	},
+	&cli.StringFlag{
+		Name:    "profile",
+		Sources: cli.EnvVars("AWS_PROFILE"),
+		Usage:   "the aws profile to use when setting up the aws auth session, typically used for shared credentials files",
+	},
+	&cli.StringFlag{
+		Name:    "assume-role-arn",
+		Sources: cli.EnvVars("AWS_ASSUME_ROLE_ARN"),
+		Usage:   "the role arn to assume using the credentials provided in the profile or statically set",
+	},
+	&cli.StringFlag{
+		Name:    "assume-role-session-name",
+		Sources: cli.EnvVars("AWS_ASSUME_ROLE_SESSION_NAME"),
+		Usage:   "the session name to provide for the assumed role",
+	},
+	&cli.StringFlag{
+		Name:    "assume-role-external-id",
+		Sources: cli.EnvVars("AWS_ASSUME_ROLE_EXTERNAL_ID"),
+		Usage:   "the external id to provide for the assumed role",
+	},
+	&cli.StringFlag{
+		Name:  "accounts-from",
+		Usage: "path to a file with one role arn per line, the role is assumed and each account is nuked in turn",
+	},
+	&cli.BoolFlag{
+		Name:  "all-accounts",
+		Usage: "nuke every account defined in the config by assuming --account-role-name in each account",
+	},
+	&cli.StringFlag{
+		Name:  "account-role-name",
+		Usage: "the name of the role to assume in each account when using --all-accounts",
+		Value: "OrganizationAccountAccessRole",
+	},
+	&cli.IntFlag{
+		Name:  "account-concurrency",
+		Usage: "the number of accounts to nuke at the same time when running against multiple accounts",
+		Value: 1,
+	},
+	&cli.IntFlag{
+		Name:    "parallel-queries",
+		Usage:   "CAUTION! ADVANCED USAGE! number of parallel resource queries to run at a time",
+		Sources: cli.EnvVars("AWS_NUKE_PARALLEL_QUERIES"),
+		Value:   scanner.DefaultParallelQueries,
+		Hidden:  true,
+	},
+	&cli.IntFlag{
+		Name:    "max-queue-size",
+		Usage:   "CAUTION! ADVANCED USAGE! the max number of items to queue up before aws-nuke will error",
+		Sources: cli.EnvVars("AWS_NUKE_MAX_QUEUE_SIZE"),
+		Value:   scanner.DefaultQueueSize,
+		Hidden:  true,
+	},
+}
+
+func init() {
 	cmd := &cli.Command{
 		Name:  "run",
 		Usage: "run nuke against an aws account and remove everything from it",
 		Aliases: []string{
 			"nuke",
 		},
-		Flags:  append(flags, global.Flags()...),
+		Flags:  append(runFlags, global.Flags()...),
 		Before: global.Before,
 		Action: execute,
 	}
 
 	common.RegisterCommand(cmd)
+
+	// The apply command shares all the flags of run, except for those that control the dry run and multiple accounts
+	applyFlags := make([]cli.Flag, 0, len(runFlags))
+	for _, f := range runFlags {
+		if slices.Contains([]string{
+			"no-dry-run", "out-plan", "accounts-from", "all-accounts", "account-role-name", "account-concurrency",
+			"interactive", "review-filters", "from-inventory",
+		}, f.Names()[0]) {
+			continue
+		}
+		applyFlags = append(applyFlags, f)
+	}
+
+	applyCmd := &cli.Command{
+		Name:      "apply",
+		Usage:     "remove exactly the resources from a plan created with run --out-plan",
+		ArgsUsage: "<plan>",
+		Description: `apply removes only the resources that are listed in a plan file created by a dry run using
+the --out-plan flag. The account is rescanned, and any resource that is not part of the plan is refused. The plan
+is rejected if the account, config file or the version of aws-nuke differ from when the plan was created.`,
+		Flags:  append(applyFlags, global.Flags()...),
+		Before: global.Before,
+		Action: executeApply,
+	}
+
+	common.RegisterCommand(applyCmd)
 }
@@ -0,0 +1,58 @@
+package nuke
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+)
+
+// runFromInventory performs a dry run against the resources of a previously captured inventory instead of scanning
+// the account. No credentials are needed and nothing is requested from AWS, the resource types, regions, settings and
+// filters of the configuration are applied the same as during a live dry run and the report is written the same.
+func runFromInventory(ctx context.Context, c *cli.Command, parsedConfig *config.Config, opts *runOptions) error {
+	logger := logrus.StandardLogger()
+
+	records, err := inventory.Load(c.String("from-inventory"))
+	if err != nil {
+		return err
+	}
+
+	var accountID string
+	var regions []string
+	for _, rec := range records {
+		switch {
+		case accountID == "":
+			accountID = rec.Account
+		case rec.Account != accountID:
+			return fmt.Errorf("the inventory %s holds resources of more than one account", c.String("from-inventory"))
+		}
+
+		if !slices.Contains(regions, rec.Region) {
+			regions = append(regions, rec.Region)
+		}
+	}
+
+	if accountID == "" {
+		return fmt.Errorf("the inventory %s holds no resources", c.String("from-inventory"))
+	}
+
+	slices.Sort(regions)
+
+	// The run is a dry run that never prompts, the notifications are only meant for runs against the account
+	opts.inventory = records
+	opts.scanOnly = true
+	opts.notifier = nil
+
+	logger.Infof("running offline against %d resources of account %s from %s",
+		len(records), accountID, c.String("from-inventory"))
+
+	_, err = nukeAccount(ctx, c, parsedConfig, awsutil.NewOfflineAccount(accountID, regions), opts)
+	return err
+}
@@ -0,0 +1,259 @@
+package nuke
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gotidy/ptr"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	libconfig "github.com/ekristen/libnuke/pkg/config"
+	libnuke "github.com/ekristen/libnuke/pkg/nuke"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/settings"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+	"github.com/ekristen/aws-nuke/v3/resources"
+)
+
+// scanFlagNames are the flags of the run command that apply to a scan, the others only affect the removal
+var scanFlagNames = []string{
+	"config", "include", "exclude", "cloud-control", "quiet", "no-alias-check", "feature-flag", "default-region",
+	"access-key-id", "secret-access-key", "session-token", "profile", "assume-role-arn", "assume-role-session-name",
+	"assume-role-external-id", "parallel-queries", "max-queue-size",
+}
+
+// ScanFlags returns the flags of the run command that the commands which only scan an account share
+func ScanFlags() []cli.Flag {
+	flags := make([]cli.Flag, 0, len(scanFlagNames))
+	for _, f := range runFlags {
+		if slices.Contains(scanFlagNames, f.Names()[0]) {
+			flags = append(flags, f)
+		}
+	}
+
+	return flags
+}
+
+// Scan scans the authenticated account with the resource types, regions and filters of the configuration, without
+// prompting, and calls onScan with the account and the queue once the scan has completed. Nothing is removed, the
+// queue holds every resource that was discovered including those that were filtered.
+func Scan(ctx context.Context, c *cli.Command, onScan func(account *awsutil.Account, q *queue.Queue) error) error {
+	logger := logrus.StandardLogger()
+
+	parsedConfig, err := loadConfig(c, logger)
+	if err != nil {
+		return err
+	}
+
+	creds := ConfigureCreds(c)
+	if err := creds.Validate(); err != nil {
+		return err
+	}
+
+	creds.Retry, err = awsutil.NewRetryPolicy(parsedConfig.Retry)
+	if err != nil {
+		return err
+	}
+
+	account, err := awsutil.NewAccount(creds, parsedConfig.CustomEndpoints)
+	if err != nil {
+		return err
+	}
+
+	opts := &runOptions{
+		scanOnly: true,
+		onRunner: func(n *nuke.Runner) {
+			n.RegisterScanHook(func(q *queue.Queue) error {
+				return onScan(account, q)
+			})
+		},
+	}
+
+	_, err = nukeAccount(ctx, c, parsedConfig, account, opts)
+	return err
+}
+
+// List lists every resource of the authenticated account and calls onList with the account, the configuration and the
+// resources once all regions have been listed. Unlike Scan it does not create a libnuke instance, the region scanners
+// are run on their own: nothing is filtered and there is no way for anything to be removed, read-only credentials are
+// enough. The configuration file is optional, without one every resource type is listed. The regions default to
+// those of the configuration, or every enabled region.
+func List(
+	ctx context.Context, c *cli.Command, regions []string,
+	onList func(account *awsutil.Account, parsedConfig *config.Config, items []*queue.Item) error,
+) error {
+	logger := logrus.StandardLogger()
+
+	parsedConfig := &config.Config{
+		Config: &libconfig.Config{
+			Accounts: make(map[string]*libconfig.Account),
+			Settings: &settings.Settings{},
+		},
+	}
+
+	if c.String("config") != "" {
+		var err error
+		parsedConfig, err = loadConfig(c, logger)
+		if err != nil {
+			return err
+		}
+	} else if err := setDefaultRegion(c.String("default-region"), nil, logger); err != nil {
+		return err
+	}
+
+	creds := ConfigureCreds(c)
+	if err := creds.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+	creds.Retry, err = awsutil.NewRetryPolicy(parsedConfig.Retry)
+	if err != nil {
+		return err
+	}
+
+	account, err := awsutil.NewAccount(creds, parsedConfig.CustomEndpoints)
+	if err != nil {
+		return err
+	}
+
+	if len(regions) == 0 {
+		regions = parsedConfig.Regions
+	}
+	if len(regions) == 0 {
+		regions = []string{"all"}
+	}
+
+	params := &libnuke.Parameters{
+		Includes:     c.StringSlice("include"),
+		Excludes:     c.StringSlice("exclude"),
+		Alternatives: c.StringSlice("cloud-control"),
+	}
+	resourceTypes := resolveResourceTypes(params, parsedConfig, parsedConfig.Accounts[account.ID()])
+
+	var items []*queue.Item
+	for _, regionName := range resolveRegions(regions, account, logger) {
+		listed, err := newRegionScanner(c, account, regionName, resourceTypes, logger).List(ctx)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, listed...)
+	}
+
+	logger.Infof("listed %d resources of %d resource types", len(items), len(resourceTypes))
+
+	return onList(account, parsedConfig, items)
+}
+
+// resolveResourceTypes registers the Cloud Control resource types that are used as an alternative and resolves the
+// resource types to scan from the parameters, the global configuration and the account configuration. The account
+// configuration may be nil.
+func resolveResourceTypes(
+	params *libnuke.Parameters, parsedConfig *config.Config, accountConfig *libconfig.Account,
+) types.Collection {
+	if accountConfig == nil {
+		accountConfig = &libconfig.Account{}
+	}
+
+	// Combine all the places where alternative resource types can be defined and then dynamically
+	// register them as a Cloud Control resource type.
+	cloudControlLock.Lock()
+	defer cloudControlLock.Unlock()
+
+	resourceNames := registry.GetNames()
+	altResourceTypes := types.Collection(registry.ExpandNames(params.Alternatives))
+	altResourceTypes = altResourceTypes.Union(parsedConfig.ResourceTypes.GetAlternatives())
+	altResourceTypes = altResourceTypes.Union(accountConfig.ResourceTypes.GetAlternatives())
+	for _, rt := range altResourceTypes {
+		if slices.Contains(resourceNames, rt) {
+			continue
+		}
+
+		resources.RegisterCloudControl(rt)
+	}
+
+	return types.ResolveResourceTypes(
+		registry.GetNames(), // note: we want to re-pull the registry here due to the dynamic registration above
+		[]types.Collection{
+			registry.ExpandNames(params.Includes),
+			parsedConfig.ResourceTypes.GetIncludes(),
+			accountConfig.ResourceTypes.GetIncludes(),
+		},
+		[]types.Collection{
+			registry.ExpandNames(params.Excludes),
+			parsedConfig.ResourceTypes.Excludes,
+			accountConfig.ResourceTypes.Excludes,
+		},
+		[]types.Collection{
+			registry.ExpandNames(params.Alternatives),
+			parsedConfig.ResourceTypes.GetAlternatives(),
+			accountConfig.ResourceTypes.GetAlternatives(),
+		},
+		registry.GetAlternativeResourceTypeMapping(),
+	)
+}
+
+// resolveRegions returns the regions to scan. If the user has specified the "all" region, then we need to get the
+// enabled regions for the account and use those. Otherwise, we will use the regions that are configured.
+func resolveRegions(configured []string, account *awsutil.Account, logger *logrus.Logger) []string {
+	if !slices.Contains(configured, "all") {
+		return configured
+	}
+
+	regions := account.Regions()
+
+	logger.Info(
+		`"all" detected in region list, only enabled regions and "global" will be used, all others ignored`)
+
+	if len(configured) > 1 {
+		logger.Warnf(`additional regions defined along with "all", these will be ignored!`)
+	}
+
+	logger.Infof("The following regions are enabled for the account (%d total):", len(regions))
+
+	printableRegions := make([]string, 0)
+	for i, region := range regions {
+		printableRegions = append(printableRegions, region)
+		if i%6 == 0 { // print 5 regions per line
+			logger.Infof("> %s", strings.Join(printableRegions, ", "))
+			printableRegions = make([]string, 0)
+		} else if i == len(regions)-1 {
+			logger.Infof("> %s", strings.Join(printableRegions, ", "))
+		}
+	}
+
+	return regions
+}
+
+// newRegionScanner creates the scanner of the resource types of a single region. For each resource type the scanner
+// calls nuke.MutateOpts with a copy of the lister options, see pkg/nuke/resource.go. Its purpose is to create the
+// proper session for the proper region.
+func newRegionScanner(
+	c *cli.Command, account *awsutil.Account, regionName string, resourceTypes []string, logger *logrus.Logger,
+) *nuke.Scanner {
+	region := nuke.NewRegion(regionName, account.ResourceTypeToServiceType, account.NewSession, account.NewConfig)
+
+	return &nuke.Scanner{
+		Owner:         regionName,
+		ResourceTypes: resourceTypes,
+		Opts: &nuke.ListerOpts{
+			Region:    region,
+			AccountID: ptr.String(account.ID()),
+			Logger: logger.WithFields(logrus.Fields{
+				"component": "scanner",
+				"region":    regionName,
+			}),
+		},
+		Logger:          logger,
+		ParallelQueries: c.Int64("parallel-queries"),
+		QueueSize:       c.Int("max-queue-size"),
+	}
+}
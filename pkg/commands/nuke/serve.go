@@ -0,0 +1,578 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/awsutil"
+	"github.com/ekristen/aws-nuke/v3/pkg/cache"
+	"github.com/ekristen/aws-nuke/v3/pkg/commands/global"
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/notify"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+	"github.com/ekristen/aws-nuke/v3/pkg/schedule"
+)
+
+const (
+	runStatusQueued    = "queued"
+	runStatusRunning   = "running"
+	runStatusSucceeded = "succeeded"
+	runStatusFailed    = "failed"
+	runStatusCancelled = "cancelled"
+
+	runPhaseConnecting = "connecting"
+	runPhaseScanning   = "scanning"
+	runPhaseRemoving   = "removing"
+
+	runTriggerSchedule = "schedule"
+	runTriggerAPI      = "api"
+)
+
+// maxRunHistory is the number of runs that are kept in memory, the reports of older runs remain on disk
+const maxRunHistory = 100
+
+// errRunActive is returned when a run is triggered for an account that already has a queued or running run
+var errRunActive = errors.New("a run is already active for this account")
+
+// serveRun is a single run of the daemon against an account, it is exposed as is through the API
+type serveRun struct {
+	ID         string               `json:"id"`
+	AccountID  string               `json:"account_id"`
+	Alias      string               `json:"account_alias,omitempty"`
+	Trigger    string               `json:"trigger"`
+	DryRun     bool                 `json:"dry_run"`
+	Status     string               `json:"status"`
+	Phase      string               `json:"phase,omitempty"`
+	Iteration  int                  `json:"iteration,omitempty"`
+	Counts     map[report.State]int `json:"counts,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Report     string               `json:"report,omitempty"`
+	QueuedAt   time.Time            `json:"queued_at"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// clock is the time source of the daemon, it decides when schedules are due
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// daemon runs nukes against the accounts of the configuration on their schedule or when triggered through the API
+type daemon struct {
+	ctx          context.Context
+	c            *cli.Command
+	parsedConfig *config.Config
+	opts         *runOptions
+	logger       *logrus.Logger
+	reportDir    string
+	roleName     string
+	apiToken     string
+	dryRun       bool
+	schedules    map[string]*schedule.Schedule
+	clock        clock
+
+	// run performs a single run, it is the full nuke cycle against the account of the run
+	run func(ctx context.Context, run *serveRun) (*accountResult, error)
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	mu     sync.Mutex
+	runs   []*serveRun
+	active map[string]*serveRun
+	next   map[string]time.Time
+}
+
+func executeServe(baseCtx context.Context, c *cli.Command) error { //nolint:funlen
+	ctx, stop := signal.NotifyContext(baseCtx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := logrus.StandardLogger()
+	logger.SetOutput(os.Stdout)
+
+	if !c.Bool("force") {
+		return fmt.Errorf("serve runs unattended and requires --no-prompt")
+	}
+
+	concurrency := c.Int("account-concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--account-concurrency must be at least 1")
+	}
+
+	reportFormat, err := report.ParseFormat(c.String("report-format"))
+	if err != nil {
+		return err
+	}
+
+	parsedConfig, err := loadConfig(c, logger)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := notify.New(parsedConfig.Notifications, logger.WithField("component", "notify"))
+	if err != nil {
+		return err
+	}
+
+	minAge, err := resolveMinAge(c, parsedConfig)
+	if err != nil {
+		return err
+	}
+
+	priceTable, err := loadPriceTable(c)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := openAuditLog(c, c.Bool("no-dry-run"), logger)
+	if err != nil {
+		return err
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+	}
+
+	var runCache *cache.Cache
+	if c.String("cache-dir") != "" {
+		runCache, err = cache.New(c.String("cache-dir"), c.Duration("cache-ttl"))
+		if err != nil {
+			return err
+		}
+	}
+
+	d := &daemon{
+		ctx:          ctx,
+		c:            c,
+		parsedConfig: parsedConfig,
+		opts: &runOptions{
+			reportFormat: reportFormat,
+			notifier:     notifier,
+			minAge:       minAge,
+			priceTable:   priceTable,
+			auditLog:     auditLog,
+			cache:        runCache,
+		},
+		logger:    logger,
+		reportDir: c.String("report-dir"),
+		roleName:  c.String("account-role-name"),
+		apiToken:  c.String("api-token"),
+		dryRun:    !c.Bool("no-dry-run"),
+		schedules: make(map[string]*schedule.Schedule),
+		clock:     realClock{},
+		sem:       make(chan struct{}, concurrency),
+		active:    make(map[string]*serveRun),
+		next:      make(map[string]time.Time),
+	}
+	d.run = d.nuke
+
+	for accountID, spec := range parsedConfig.Schedules {
+		if _, ok := parsedConfig.Accounts[accountID]; !ok {
+			return fmt.Errorf("schedule for account %s, which is not defined in the config", accountID)
+		}
+
+		d.schedules[accountID], err = schedule.Parse(spec)
+		if err != nil {
+			return fmt.Errorf("schedule for account %s: %w", accountID, err)
+		}
+	}
+
+	if len(d.schedules) == 0 {
+		logger.Warn("no schedules are defined in the config, runs can only be triggered through the api")
+	}
+
+	if err := os.MkdirAll(d.reportDir, 0o755); err != nil {
+		return err
+	}
+
+	stopTracing, err := startTracing(c, logger)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           d.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if d.apiToken == "" {
+		logger.Warn("no --api-token is set, anyone who can reach the api can trigger and cancel runs")
+	}
+
+	logger.Infof("serving api on http://%s", listener.Addr())
+
+	for accountID, s := range d.schedules {
+		logger.Infof("account %s is scheduled with '%s'", accountID, s)
+		go d.schedule(accountID, s)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		logger.Info("shutting down, cancelling active runs")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+
+	// Runs are started from the context of the daemon, so they are cancelled as well once it is done
+	stop()
+	d.wg.Wait()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// schedule triggers a run for the account every time the schedule is due until the daemon is stopped
+func (d *daemon) schedule(accountID string, s *schedule.Schedule) {
+	for {
+		now := d.clock.Now()
+		next := s.Next(now)
+		if next.IsZero() {
+			d.logger.Warnf("schedule '%s' for account %s is never due", s, accountID)
+			return
+		}
+
+		d.mu.Lock()
+		d.next[accountID] = next
+		d.mu.Unlock()
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.clock.After(next.Sub(now)):
+		}
+
+		if _, err := d.trigger(accountID, runTriggerSchedule); err != nil {
+			d.logger.WithError(err).Warnf("skipping scheduled run for account %s", accountID)
+		}
+	}
+}
+
+// trigger queues a run for the account, the run starts as soon as fewer than --account-concurrency runs are active
+func (d *daemon) trigger(accountID, trigger string) (*serveRun, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if active := d.active[accountID]; active != nil {
+		return active, errRunActive
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
+
+	now := d.clock.Now().UTC()
+	run := &serveRun{
+		ID:        fmt.Sprintf("%s-%s", now.Format("20060102T150405Z"), accountID),
+		AccountID: accountID,
+		Trigger:   trigger,
+		DryRun:    d.dryRun,
+		Status:    runStatusQueued,
+		QueuedAt:  now,
+		cancel:    cancel,
+	}
+
+	d.runs = append(d.runs, run)
+	if len(d.runs) > maxRunHistory {
+		d.runs = d.runs[len(d.runs)-maxRunHistory:]
+	}
+	d.active[accountID] = run
+
+	d.logger.Infof("queued run %s for account %s (%s)", run.ID, accountID, trigger)
+
+	d.wg.Add(1)
+	go d.execute(ctx, run)
+
+	return run, nil
+}
+
+// cancel cancels a queued or running run
+func (d *daemon) cancel(run *serveRun) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if run.Status != runStatusQueued && run.Status != runStatusRunning {
+		return false
+	}
+
+	d.logger.Infof("cancelling run %s for account %s", run.ID, run.AccountID)
+	run.cancel()
+
+	return true
+}
+
+// update changes the run while holding the lock, so that the API never sees a partial update
+func (d *daemon) update(run *serveRun, fn func(r *serveRun)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fn(run)
+}
+
+// execute waits for a free slot and then runs the full nuke cycle against the account of the run
+func (d *daemon) execute(ctx context.Context, run *serveRun) {
+	defer d.wg.Done()
+	defer run.cancel()
+
+	var (
+		result *accountResult
+		err    error
+	)
+
+	select {
+	case d.sem <- struct{}{}:
+		defer func() { <-d.sem }()
+
+		d.update(run, func(r *serveRun) {
+			started := d.clock.Now().UTC()
+			r.StartedAt = &started
+			r.Status = runStatusRunning
+		})
+
+		result, err = d.run(ctx, run)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	finished := d.clock.Now().UTC()
+	run.FinishedAt = &finished
+	run.Phase = ""
+
+	switch {
+	case err == nil:
+		run.Status = runStatusSucceeded
+	case ctx.Err() != nil:
+		run.Status = runStatusCancelled
+		run.Error = err.Error()
+	default:
+		run.Status = runStatusFailed
+		run.Error = err.Error()
+	}
+
+	if result != nil {
+		run.Alias = result.Alias
+		run.Counts = result.Counts
+	}
+
+	if run.Report != "" {
+		if _, statErr := os.Stat(filepath.Join(d.reportDir, run.Report)); statErr != nil {
+			run.Report = ""
+		}
+	}
+
+	delete(d.active, run.AccountID)
+
+	d.logger.WithField("status", run.Status).
+		Infof("run %s for account %s finished with status %s", run.ID, run.AccountID, run.Status)
+}
+
+// nuke connects to the account of the run and nukes it, the progress of the run is recorded on the run
+func (d *daemon) nuke(ctx context.Context, run *serveRun) (*accountResult, error) {
+	d.update(run, func(r *serveRun) {
+		r.Phase = runPhaseConnecting
+	})
+
+	target := accountTarget{AccountID: run.AccountID}
+	if d.roleName != "" {
+		target.RoleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", awsutil.DefaultAWSPartitionID, run.AccountID, d.roleName)
+	}
+
+	account, err := connectAccount(d.c, d.parsedConfig, target)
+	if err != nil {
+		return nil, err
+	}
+
+	reportName := fmt.Sprintf("%s.%s", run.ID, d.opts.reportFormat)
+
+	d.update(run, func(r *serveRun) {
+		r.Alias = account.Alias()
+		r.Phase = runPhaseScanning
+		r.Report = reportName
+	})
+
+	opts := *d.opts
+	opts.reportPath = filepath.Join(d.reportDir, reportName)
+	opts.onRunner = func(n *nuke.Runner) {
+		n.RegisterScanHook(func(q *queue.Queue) error {
+			counts := countStates(q.GetItems())
+			d.update(run, func(r *serveRun) {
+				r.Counts = counts
+				if n.Parameters.NoDryRun {
+					r.Phase = runPhaseRemoving
+				}
+			})
+			return nil
+		})
+
+		n.RegisterIterationHook(func(iteration int, q *queue.Queue) error {
+			counts := countStates(q.GetItems())
+			d.update(run, func(r *serveRun) {
+				r.Iteration = iteration
+				r.Counts = counts
+			})
+			return nil
+		})
+	}
+
+	return nukeAccount(ctx, d.c, d.parsedConfig, account, &opts)
+}
+
+// snapshot returns copies of the runs, newest first, so they can be encoded without holding the lock
+func (d *daemon) snapshot() []serveRun {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	runs := make([]serveRun, 0, len(d.runs))
+	for i := len(d.runs) - 1; i >= 0; i-- {
+		run := *d.runs[i]
+		run.Counts = countsCopy(d.runs[i].Counts)
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// find returns the run with the given ID
+func (d *daemon) find(id string) *serveRun {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, run := range d.runs {
+		if run.ID == id {
+			return run
+		}
+	}
+
+	return nil
+}
+
+// accountIDs returns the accounts that can be nuked, which are all accounts defined in the config
+func (d *daemon) accountIDs() []string {
+	ids := make([]string, 0, len(d.parsedConfig.Accounts))
+	for id := range d.parsedConfig.Accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// countStates counts the items of the queue per report state
+func countStates(items []*queue.Item) map[report.State]int {
+	counts := make(map[report.State]int)
+	for _, item := range items {
+		counts[report.StateOf(item)]++
+	}
+
+	return counts
+}
+
+func countsCopy(counts map[report.State]int) map[report.State]int {
+	if counts == nil {
+		return nil
+	}
+
+	c := make(map[report.State]int, len(counts))
+	for state, count := range counts {
+		c[state] = count
+	}
+
+	return c
+}
+
+func init() {
+	serveFlags := make([]cli.Flag, 0, len(runFlags))
+	for _, f := range runFlags {
+		if slices.Contains([]string{
+			"out-plan", "report", "state-file", "resume", "metrics-listen", "interactive", "review-filters",
+			"accounts-from", "all-accounts", "account-role-name", "from-inventory",
+		}, f.Names()[0]) {
+			continue
+		}
+		serveFlags = append(serveFlags, f)
+	}
+
+	serveFlags = append(serveFlags,
+		&cli.StringFlag{
+			Name:    "listen",
+			Sources: cli.EnvVars("AWS_NUKE_LISTEN"),
+			Usage:   "the address to serve the api on",
+			Value:   "127.0.0.1:8080",
+		},
+		&cli.StringFlag{
+			Name:    "api-token",
+			Sources: cli.EnvVars("AWS_NUKE_API_TOKEN"),
+			Usage:   "require this bearer token on every api request except /healthz",
+		},
+		&cli.StringFlag{
+			Name:  "report-dir",
+			Usage: "the directory the report of every run is written to",
+			Value: "reports",
+		},
+		&cli.StringFlag{
+			Name: "account-role-name",
+			Usage: "the name of the role to assume in each account, by default the credentials are used as is " +
+				"and must belong to the account being nuked",
+		},
+	)
+
+	cmd := &cli.Command{
+		Name:  "serve",
+		Usage: "run nuke against the accounts in the config on a schedule, controlled through an http api",
+		Description: `serve runs as a daemon that nukes the accounts of the config on the cron schedules defined
+in the 'schedules' section of the config. Runs can also be triggered, followed and cancelled through the http api,
+and the report of every run is written to --report-dir. Runs are unattended, so --no-prompt is required.`,
+		Flags:  append(serveFlags, global.Flags()...),
+		Before: global.Before,
+		Action: executeServe,
+	}
+
+	common.RegisterCommand(cmd)
+}
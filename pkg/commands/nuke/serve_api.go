@@ -0,0 +1,266 @@
+package nuke
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/metrics"
+)
+
+// reportContentTypes maps the extension of a report to the content type it is served with
+var reportContentTypes = map[string]string{
+	".json":   "application/json",
+	".csv":    "text/csv",
+	".ndjson": "application/x-ndjson",
+}
+
+// serveAccount is an account that can be nuked by the daemon
+type serveAccount struct {
+	AccountID string     `json:"account_id"`
+	Schedule  string     `json:"schedule,omitempty"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	ActiveRun string     `json:"active_run,omitempty"`
+}
+
+// serveReport is a report that was written by a run
+type serveReport struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// routes returns the handler of the api
+//
+//	GET  /healthz               liveness check, never requires the api token
+//	GET  /metrics               prometheus metrics of all runs
+//	GET  /accounts              the accounts that can be nuked, with their schedule
+//	GET  /runs                  the most recent runs, newest first
+//	POST /runs                  trigger a run, the body is {"account_id": "..."}
+//	GET  /runs/{id}             the status and progress of a run
+//	POST /runs/{id}/cancel      cancel a queued or running run
+//	GET  /runs/{id}/report      the report of a run
+//	GET  /reports               all reports in --report-dir, including those of runs before a restart
+//	GET  /reports/{name}        a single report
+func (d *daemon) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+	mux.HandleFunc("GET /accounts", d.handleAccounts)
+	mux.HandleFunc("GET /runs", d.handleListRuns)
+	mux.HandleFunc("POST /runs", d.handleTriggerRun)
+	mux.HandleFunc("GET /runs/{id}", d.handleGetRun)
+	mux.HandleFunc("POST /runs/{id}/cancel", d.handleCancelRun)
+	mux.HandleFunc("GET /runs/{id}/report", d.handleRunReport)
+	mux.HandleFunc("GET /reports", d.handleListReports)
+	mux.HandleFunc("GET /reports/{name}", func(w http.ResponseWriter, r *http.Request) {
+		d.serveReport(w, r, r.PathValue("name"))
+	})
+
+	return d.authenticate(mux)
+}
+
+// authenticate requires the api token on every request except the health check, when a token is configured
+func (d *daemon) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.apiToken != "" && r.URL.Path != "/healthz" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(d.apiToken)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or invalid api token"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *daemon) handleAccounts(w http.ResponseWriter, _ *http.Request) {
+	d.mu.Lock()
+	accounts := make([]serveAccount, 0, len(d.parsedConfig.Accounts))
+	for _, id := range d.accountIDs() {
+		account := serveAccount{AccountID: id}
+
+		if s, ok := d.schedules[id]; ok {
+			account.Schedule = s.String()
+		}
+
+		if next, ok := d.next[id]; ok {
+			account.NextRun = &next
+		}
+
+		if active := d.active[id]; active != nil {
+			account.ActiveRun = active.ID
+		}
+
+		accounts = append(accounts, account)
+	}
+	d.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+func (d *daemon) handleListRuns(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, d.snapshot())
+}
+
+func (d *daemon) handleTriggerRun(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		AccountID string `json:"account_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body, expected {\"account_id\": \"...\"}"))
+		return
+	}
+
+	if _, ok := d.parsedConfig.Accounts[body.AccountID]; !ok {
+		writeError(w, http.StatusNotFound, errors.New("account is not defined in the config"))
+		return
+	}
+
+	run, err := d.trigger(body.AccountID, runTriggerAPI)
+	if errors.Is(err, errRunActive) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	d.writeRun(w, http.StatusAccepted, run)
+}
+
+func (d *daemon) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	run := d.find(r.PathValue("id"))
+	if run == nil {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+
+	d.writeRun(w, http.StatusOK, run)
+}
+
+func (d *daemon) handleCancelRun(w http.ResponseWriter, r *http.Request) {
+	run := d.find(r.PathValue("id"))
+	if run == nil {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+
+	if !d.cancel(run) {
+		writeError(w, http.StatusConflict, errors.New("run is not active"))
+		return
+	}
+
+	d.writeRun(w, http.StatusAccepted, run)
+}
+
+func (d *daemon) handleRunReport(w http.ResponseWriter, r *http.Request) {
+	run := d.find(r.PathValue("id"))
+	if run == nil {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+
+	d.mu.Lock()
+	status, name := run.Status, run.Report
+	d.mu.Unlock()
+
+	if status == runStatusQueued || status == runStatusRunning {
+		writeError(w, http.StatusConflict, errors.New("the report is written once the run has finished"))
+		return
+	}
+
+	if name == "" {
+		writeError(w, http.StatusNotFound, errors.New("run has no report"))
+		return
+	}
+
+	d.serveReport(w, r, name)
+}
+
+func (d *daemon) handleListReports(w http.ResponseWriter, _ *http.Request) {
+	entries, err := os.ReadDir(d.reportDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	reports := make([]serveReport, 0, len(entries))
+	for _, entry := range entries {
+		// only the reports that serveReport is willing to serve are listed
+		if _, ok := reportContentTypes[filepath.Ext(entry.Name())]; !ok || !entry.Type().IsRegular() ||
+			strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		reports = append(reports, serveReport{Name: entry.Name(), Size: info.Size(), ModifiedAt: info.ModTime().UTC()})
+	}
+
+	// reports are named after the run, which starts with the time it was queued
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Name > reports[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// serveReport serves a report from the report directory, the name may not point outside of it
+func (d *daemon) serveReport(w http.ResponseWriter, r *http.Request, name string) {
+	contentType, ok := reportContentTypes[filepath.Ext(name)]
+	if !ok || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		writeError(w, http.StatusNotFound, errors.New("report not found"))
+		return
+	}
+
+	f, err := os.Open(filepath.Join(d.reportDir, name))
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("report not found"))
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// writeRun writes a copy of the run, so that it is not modified while it is being encoded
+func (d *daemon) writeRun(w http.ResponseWriter, status int, run *serveRun) {
+	d.mu.Lock()
+	c := *run
+	c.Counts = countsCopy(run.Counts)
+	d.mu.Unlock()
+
+	writeJSON(w, status, c)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
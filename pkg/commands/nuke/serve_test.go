@@ -0,0 +1,309 @@
+package nuke
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	libconfig "github.com/ekristen/libnuke/pkg/config"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+	"github.com/ekristen/aws-nuke/v3/pkg/schedule"
+)
+
+const testServeAccount = "000000000000"
+
+// testTimer is a timer that was requested from the testClock, it fires when the test sends on it
+type testTimer struct {
+	d  time.Duration
+	ch chan time.Time
+}
+
+type testClock struct {
+	now    time.Time
+	timers chan *testTimer
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	t := &testTimer{d: d, ch: make(chan time.Time, 1)}
+	c.timers <- t
+	return t.ch
+}
+
+// newTestDaemon creates a daemon whose runs block until release is closed or the run is cancelled
+func newTestDaemon(t *testing.T, apiToken string) (d *daemon, release chan struct{}, server *httptest.Server) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	release = make(chan struct{})
+
+	d = &daemon{
+		ctx: ctx,
+		parsedConfig: &config.Config{
+			Config: &libconfig.Config{
+				Accounts: map[string]*libconfig.Account{testServeAccount: {}},
+			},
+		},
+		opts:      &runOptions{reportFormat: report.FormatJSON},
+		logger:    logger,
+		reportDir: t.TempDir(),
+		apiToken:  apiToken,
+		dryRun:    true,
+		schedules: make(map[string]*schedule.Schedule),
+		clock: &testClock{
+			now:    time.Date(2024, 5, 10, 12, 0, 30, 0, time.UTC),
+			timers: make(chan *testTimer, 1),
+		},
+		sem:    make(chan struct{}, 1),
+		active: make(map[string]*serveRun),
+		next:   make(map[string]time.Time),
+	}
+
+	d.run = func(ctx context.Context, _ *serveRun) (*accountResult, error) {
+		select {
+		case <-release:
+			return &accountResult{Alias: "sandbox", Counts: map[report.State]int{report.StateWouldRemove: 2}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	server = httptest.NewServer(d.routes())
+
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+		d.wg.Wait()
+	})
+
+	return d, release, server
+}
+
+func doRequest(t *testing.T, method, url, token, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(data, &decoded)
+
+	return resp.StatusCode, decoded
+}
+
+// waitForStatus waits until the run has the status
+func waitForStatus(t *testing.T, server *httptest.Server, token, id, status string) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		code, run := doRequest(t, http.MethodGet, server.URL+"/runs/"+id, token, "")
+		return code == http.StatusOK && run["status"] == status
+	}, 5*time.Second, 10*time.Millisecond, "run %s never reached status %s", id, status)
+}
+
+func TestServe_Authenticate(t *testing.T) {
+	_, _, server := newTestDaemon(t, "secret")
+
+	code, _ := doRequest(t, http.MethodGet, server.URL+"/healthz", "", "")
+	assert.Equal(t, http.StatusOK, code, "the health check never requires the token")
+
+	code, body := doRequest(t, http.MethodGet, server.URL+"/accounts", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "missing or invalid api token", body["error"])
+
+	code, _ = doRequest(t, http.MethodGet, server.URL+"/accounts", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = doRequest(t, http.MethodPost, server.URL+"/runs", "wrong", `{"account_id": "000000000000"}`)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = doRequest(t, http.MethodGet, server.URL+"/accounts", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestServe_Trigger(t *testing.T) {
+	_, release, server := newTestDaemon(t, "")
+
+	code, body := doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "111111111111"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "account is not defined in the config", body["error"])
+
+	code, _ = doRequest(t, http.MethodPost, server.URL+"/runs", "", `not json`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, run := doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "000000000000"}`)
+	require.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, runTriggerAPI, run["trigger"])
+	assert.Equal(t, true, run["dry_run"])
+	id := run["id"].(string)
+
+	// the account already has a run in progress
+	waitForStatus(t, server, "", id, runStatusRunning)
+	code, body = doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "000000000000"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, errRunActive.Error(), body["error"])
+
+	code, body = doRequest(t, http.MethodGet, server.URL+"/runs/"+id+"/report", "", "")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "the report is written once the run has finished", body["error"])
+
+	close(release)
+	waitForStatus(t, server, "", id, runStatusSucceeded)
+
+	_, run = doRequest(t, http.MethodGet, server.URL+"/runs/"+id, "", "")
+	assert.Equal(t, "sandbox", run["account_alias"])
+	assert.Equal(t, map[string]interface{}{"would-remove": float64(2)}, run["counts"])
+
+	// once finished another run can be triggered
+	code, _ = doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "000000000000"}`)
+	assert.Equal(t, http.StatusAccepted, code)
+}
+
+func TestServe_Cancel(t *testing.T) {
+	_, _, server := newTestDaemon(t, "")
+
+	code, _ := doRequest(t, http.MethodPost, server.URL+"/runs/unknown/cancel", "", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, run := doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "000000000000"}`)
+	require.Equal(t, http.StatusAccepted, code)
+	id := run["id"].(string)
+
+	code, _ = doRequest(t, http.MethodPost, server.URL+"/runs/"+id+"/cancel", "", "")
+	assert.Equal(t, http.StatusAccepted, code)
+
+	waitForStatus(t, server, "", id, runStatusCancelled)
+
+	_, run = doRequest(t, http.MethodGet, server.URL+"/runs/"+id, "", "")
+	assert.Equal(t, context.Canceled.Error(), run["error"])
+
+	code, body := doRequest(t, http.MethodPost, server.URL+"/runs/"+id+"/cancel", "", "")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "run is not active", body["error"])
+
+	// a cancelled run no longer blocks the account
+	code, _ = doRequest(t, http.MethodPost, server.URL+"/runs", "", `{"account_id": "000000000000"}`)
+	assert.Equal(t, http.StatusAccepted, code)
+}
+
+func TestServe_Reports(t *testing.T) {
+	d, _, server := newTestDaemon(t, "")
+
+	require.NoError(t, os.WriteFile(filepath.Join(d.reportDir, "run.json"), []byte(`{"resources": []}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(d.reportDir, ".hidden.json"), []byte(`{}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(d.reportDir, "notes.txt"), []byte(`notes`), 0o600))
+
+	// a file next to the report directory, which may never be served
+	outside := filepath.Join(filepath.Dir(d.reportDir), "secret.json")
+	require.NoError(t, os.WriteFile(outside, []byte(`{"secret": true}`), 0o600))
+	t.Cleanup(func() { _ = os.Remove(outside) })
+
+	resp, err := http.Get(server.URL + "/reports/run.json")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	_ = resp.Body.Close()
+
+	for _, name := range []string{
+		"..%2Fsecret.json",
+		"..%2F" + filepath.Base(d.reportDir) + "%2Frun.json",
+		"%2Fetc%2Fpasswd.json",
+		".hidden.json",
+		"notes.txt",
+		"missing.json",
+	} {
+		code, body := doRequest(t, http.MethodGet, server.URL+"/reports/"+name, "", "")
+		assert.Equal(t, http.StatusNotFound, code, name)
+		assert.Equal(t, "report not found", body["error"], name)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/reports", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var reports []serveReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "run.json", reports[0].Name)
+}
+
+func TestServe_Schedule(t *testing.T) {
+	d, release, server := newTestDaemon(t, "")
+	close(release)
+
+	s, err := schedule.Parse("*/15 * * * *")
+	require.NoError(t, err)
+	d.schedules[testServeAccount] = s
+
+	go d.schedule(testServeAccount, s)
+
+	c := d.clock.(*testClock)
+
+	var timer *testTimer
+	select {
+	case timer = <-c.timers:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the schedule never waited for its next run")
+	}
+
+	// the clock is at 12:00:30, the next run is due at 12:15
+	assert.Equal(t, 14*time.Minute+30*time.Second, timer.d)
+
+	var accounts []serveAccount
+	resp, err := http.Get(server.URL + "/accounts")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accounts))
+	_ = resp.Body.Close()
+	require.Len(t, accounts, 1)
+	assert.Equal(t, "*/15 * * * *", accounts[0].Schedule)
+	assert.Equal(t, time.Date(2024, 5, 10, 12, 15, 0, 0, time.UTC), accounts[0].NextRun.UTC())
+
+	assert.Empty(t, d.snapshot(), "nothing runs before the schedule is due")
+
+	timer.ch <- c.now.Add(timer.d)
+
+	assert.Eventually(t, func() bool {
+		runs := d.snapshot()
+		return len(runs) == 1 && runs[0].Trigger == runTriggerSchedule && runs[0].Status == runStatusSucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the schedule waits for the run after that
+	select {
+	case <-c.timers:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the schedule did not wait for the run after that")
+	}
+}
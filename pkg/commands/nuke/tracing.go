@@ -0,0 +1,66 @@
+package nuke
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/common"
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
+)
+
+// startTracing sets up the exporters that were requested and enables tracing, the returned function exports any
+// remaining spans and disables tracing again. If no exporter was requested tracing stays disabled.
+func startTracing(c *cli.Command, logger *logrus.Logger) (func(), error) {
+	var exporters []tracing.Exporter
+
+	if endpoint := c.String("trace-endpoint"); endpoint != "" {
+		headers := make(map[string]string)
+		for _, h := range c.StringSlice("trace-header") {
+			k, v, ok := strings.Cut(h, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid trace header '%s', expected key=value", h)
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+
+		exporters = append(exporters, tracing.NewHTTPExporter(endpoint, headers))
+		logger.Infof("exporting traces to %s", endpoint)
+	}
+
+	if path := c.String("trace-file"); path != "" {
+		exporter, err := tracing.NewFileExporter(path)
+		if err != nil {
+			return nil, err
+		}
+
+		exporters = append(exporters, exporter)
+		logger.Infof("writing traces to %s", path)
+	}
+
+	if len(exporters) == 0 {
+		return func() {}, nil
+	}
+
+	tracer := tracing.NewTracer(&tracing.Resource{
+		ServiceName:    common.AppVersion.Name,
+		ServiceVersion: common.AppVersion.Summary,
+	}, logger.WithField("component", "tracing"), exporters...)
+
+	tracing.SetTracer(tracer)
+
+	return func() {
+		tracing.SetTracer(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := tracer.Shutdown(ctx); err != nil {
+			logger.WithError(err).Warn("unable to export traces")
+		}
+	}, nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+)
+
+// createdAtProperty is the normalized creation time that nuke.SetCreatedAt adds to the properties of many resources,
+// it is not declared on the resource structs
+const createdAtProperty = "CreatedAt"
+
+// cloudControlPattern matches the Cloud Control types, which are registered when they are used as an alternative
+var cloudControlPattern = regexp.MustCompile(`^AWS::[A-Za-z0-9]+::[A-Za-z0-9]+$`)
+
+// ResourceType is what the configuration can refer to of a single resource type
+type ResourceType struct {
+	// Properties are the properties that the resources can be filtered by, nil when they are not known because the
+	// resource builds its properties by hand
+	Properties []string
+
+	// TagPrefixes are the prefixes of the tag properties, for example tag: or tag:vpc:
+	TagPrefixes []string
+
+	// Settings are the settings that the resource type can be configured with
+	Settings []string
+
+	// NoProperties is true when the resources have no properties, they can only be filtered by their value
+	NoProperties bool
+
+	// NoValue is true when the resources have no value, they can only be filtered by their properties
+	NoValue bool
+}
+
+// HasProperty returns true if the resources can be filtered by the property, or if the properties are not known
+func (r *ResourceType) HasProperty(name string) bool {
+	if r.Properties == nil || name == "" || slices.Contains(r.Properties, name) {
+		return true
+	}
+
+	for _, prefix := range r.TagPrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Catalog are the resource types that the configuration can refer to, it is built from the registry
+type Catalog struct {
+	ResourceTypes map[string]*ResourceType
+
+	// Deprecated maps the deprecated names of resource types to their replacement
+	Deprecated map[string]string
+}
+
+// NewCatalog builds the catalog from the registrations of the resource types. The properties are read from the
+// property and description struct tags of the resources.
+func NewCatalog(regs registry.Registrations, deprecated map[string]string) *Catalog {
+	c := &Catalog{
+		ResourceTypes: make(map[string]*ResourceType, len(regs)),
+		Deprecated:    deprecated,
+	}
+
+	for name, reg := range regs {
+		rt := &ResourceType{Settings: reg.Settings}
+
+		if !cloudControlPattern.MatchString(name) && reg.Resource != nil {
+			_, hasProperties := reg.Resource.(resource.PropertyGetter)
+			_, hasValue := reg.Resource.(resource.LegacyStringer)
+			rt.NoProperties, rt.NoValue = !hasProperties, !hasValue
+
+			rt.Properties, rt.TagPrefixes = resourceProperties(reg.Resource)
+		}
+
+		c.ResourceTypes[name] = rt
+	}
+
+	return c
+}
+
+// Names returns the names of the resource types in alphabetical order
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.ResourceTypes))
+	for name := range c.ResourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns the resource type with the given name. Deprecated names return the resource type they were
+// replaced by, and any Cloud Control type is known as it is registered once it is used.
+func (c *Catalog) Lookup(name string) (*ResourceType, bool) {
+	if rt, ok := c.ResourceTypes[name]; ok {
+		return rt, true
+	}
+
+	if replacement, ok := c.Deprecated[name]; ok {
+		return c.Lookup(replacement)
+	}
+
+	if cloudControlPattern.MatchString(name) || name == filter.Global {
+		return &ResourceType{}, true
+	}
+
+	return nil, false
+}
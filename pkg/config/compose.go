@@ -0,0 +1,565 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentVariable selects the overlay that is applied on top of the configuration
+const EnvironmentVariable = "AWS_NUKE_ENVIRONMENT"
+
+const (
+	includeKey   = "include"
+	overlaysKey  = "overlays"
+	expandEnvKey = "expand-env"
+)
+
+// envPattern matches ${NAME} and ${NAME:-default}, $${ is an escaped ${
+var envPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// Source is where an element of the configuration is defined
+type Source struct {
+	File string
+	Line int
+
+	// Environment is set when the element is defined by the overlay of the environment
+	Environment string
+}
+
+func (s Source) String() string {
+	if s.Environment != "" {
+		return fmt.Sprintf("%s:%d (overlay %s)", s.File, s.Line, s.Environment)
+	}
+
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// Sources are the places the elements of the configuration are defined, keyed by their path such as presets.common or
+// accounts.000000000000.filters.IAMRole. Elements that are merged from several files have more than one source.
+type Sources map[string][]Source
+
+// Get returns the sources of the element at the path
+func (s Sources) Get(path ...string) []Source {
+	return s[strings.Join(path, ".")]
+}
+
+// Composition is a configuration file merged with the files it includes and the overlay of the environment
+type Composition struct {
+	// Document is the merged document, its nodes keep the line they were defined on in their own file
+	Document *yaml.Node
+
+	// Files are the files that were loaded, in the order they were merged
+	Files []string
+
+	// Environment is the environment whose overlay was applied, if any
+	Environment string
+
+	// ExpandEnv is whether the environment variables were expanded, the main configuration file opts in to it
+	ExpandEnv bool
+
+	// Include are the files that were merged through the include lists of the files, in the order they were merged
+	Include []string
+
+	// Overlays are the files of the overlay of each environment, as defined by the main configuration file
+	Overlays map[string][]string
+
+	// Sources are the places the elements of the merged document are defined
+	Sources Sources
+
+	// files is the file each node was loaded from
+	files map[*yaml.Node]string
+}
+
+// File returns the file the node was loaded from
+func (c *Composition) File(node *yaml.Node) string {
+	return c.files[node]
+}
+
+// Hash returns a hash of the merged document, it changes when any of the files changes or when an environment variable
+// that is used changes
+func (c *Composition) Hash() (string, error) {
+	data, err := yaml.Marshal(c.Document)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Compose loads the configuration file at path. When the file sets expand-env, environment variables are expanded in
+// every value and key of every file, using ${NAME} or ${NAME:-default}. The files listed under include are merged into
+// the file, and the overlay of the environment selected by AWS_NUKE_ENVIRONMENT is then applied on top. Included files
+// are merged as equals, so the same preset defined twice or the same value set differently is a conflict, while an
+// overlay replaces what it sets.
+func Compose(path string) (*Composition, error) {
+	c := &composer{
+		Composition: &Composition{
+			Environment: os.Getenv(EnvironmentVariable),
+			files:       make(map[*yaml.Node]string),
+		},
+		extra: make(Sources),
+	}
+
+	root, overlays, err := c.load(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.Overlays = overlays
+
+	if c.Environment != "" {
+		patterns, ok := overlays[c.Environment]
+		if !ok {
+			return nil, fmt.Errorf("%s is set to '%s', but %s has no overlay for it", EnvironmentVariable,
+				c.Environment, path)
+		}
+
+		for _, overlayPath := range patterns {
+			overlay, _, err := c.load(overlayPath, []string{filepath.Clean(path)})
+			if err != nil {
+				return nil, err
+			}
+
+			c.override(root, overlay, "")
+		}
+	}
+
+	if len(c.conflicts) > 0 {
+		return nil, errors.Join(c.conflicts...)
+	}
+
+	c.Document = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	c.Sources = make(Sources)
+	c.record(root, "")
+	for key, sources := range c.extra {
+		for _, source := range sources {
+			if !containsSource(c.Sources[key], source) {
+				c.Sources[key] = append(c.Sources[key], source)
+			}
+		}
+	}
+
+	return c.Composition, nil
+}
+
+type composer struct {
+	*Composition
+
+	// overlayFiles are the files of the overlay, their sources are marked with the environment
+	overlayFiles map[string]bool
+
+	// extra are the sources of the keys that were merged into a key of another file, which is the only one that
+	// remains in the merged document
+	extra Sources
+
+	conflicts []error
+}
+
+// load reads a file, expands the environment variables and merges the files it includes. The overlays are only
+// returned for the main configuration file, other files cannot define them.
+func (c *composer) load(path string, stack []string) (*yaml.Node, map[string][]string, error) {
+	path = filepath.Clean(path)
+	if slices.Contains(stack, path) {
+		return nil, nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 && !isNull(doc.Content[0]) {
+		root = doc.Content[0]
+	}
+
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%s:%d: the configuration must be a mapping", path, root.Line)
+	}
+
+	// Expansion is an opt-in of the main configuration file, a value of an existing configuration that happens to
+	// contain ${ must load the same as it always did
+	if node := takeKey(root, expandEnvKey, true); node != nil {
+		if len(stack) > 0 {
+			return nil, nil, fmt.Errorf("%s:%d: %s can only be set in the main configuration file",
+				path, node.Line, expandEnvKey)
+		}
+
+		if err := resolve(node).Decode(&c.ExpandEnv); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %s must be true or false", path, node.Line, expandEnvKey)
+		}
+	}
+
+	c.Files = append(c.Files, path)
+	if err := c.prepare(root, path); err != nil {
+		return nil, nil, err
+	}
+
+	includes, err := c.paths(root, includeKey, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var overlays map[string][]string
+	if node := takeKey(root, overlaysKey, true); node != nil {
+		if len(stack) > 0 {
+			return nil, nil, fmt.Errorf("%s:%d: overlays can only be defined in the main configuration file",
+				path, node.Line)
+		}
+
+		overlays, err = c.overlays(node, path)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, include := range includes {
+		// A file that is included more than once, through different files, is only merged once
+		if slices.Contains(c.Files, include) && !slices.Contains(stack, include) {
+			continue
+		}
+
+		included, _, err := c.load(include, append(stack, path))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Include = append(c.Include, include)
+
+		c.merge(root, included, "")
+	}
+
+	return root, overlays, nil
+}
+
+// prepare expands the environment variables in every scalar of the file, when expansion is enabled, and records the
+// file of every node
+func (c *composer) prepare(node *yaml.Node, path string) error {
+	c.files[node] = path
+
+	if c.ExpandEnv && node.Kind == yaml.ScalarNode {
+		expanded, err := expandEnv(node.Value)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, node.Line, err)
+		}
+
+		if expanded != node.Value {
+			node.Value = expanded
+
+			// A plain value is resolved again, so that an expanded number or boolean is not kept as a string
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		if err := c.prepare(child, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandEnv replaces ${NAME} and ${NAME:-default} with the value of the environment variable. A variable that is not
+// set and has no default is an error, the default is also used when the variable is empty.
+func expandEnv(value string) (string, error) {
+	var err error
+	expanded := envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		groups := envPattern.FindStringSubmatch(match)
+		name, hasDefault := groups[1], strings.Contains(match, ":-")
+
+		v, ok := os.LookupEnv(name)
+		switch {
+		case v != "":
+			return v
+		case hasDefault:
+			return groups[2]
+		case !ok && err == nil:
+			err = fmt.Errorf("environment variable %s is not set and has no default", name)
+		}
+
+		return v
+	})
+
+	return expanded, err
+}
+
+// paths removes the key from the mapping and returns the paths it lists, relative to the directory of the file. Glob
+// patterns are expanded in lexical order.
+func (c *composer) paths(root *yaml.Node, key, path string) ([]string, error) {
+	node := takeKey(root, key, true)
+	if node == nil || isNull(node) {
+		return nil, nil
+	}
+
+	return c.expandPaths(resolve(node), key, path)
+}
+
+func (c *composer) expandPaths(node *yaml.Node, key, path string) ([]string, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: %s must be a list of paths", path, node.Line, key)
+	}
+
+	var paths []string
+	for _, item := range node.Content {
+		item = resolve(item)
+		if item.Kind != yaml.ScalarNode || item.Value == "" {
+			return nil, fmt.Errorf("%s:%d: %s must be a list of paths", path, item.Line, key)
+		}
+
+		pattern := item.Value
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern '%s': %w", path, item.Line, item.Value, err)
+		}
+		sort.Strings(matches)
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// overlays returns the paths of the overlay of each environment
+func (c *composer) overlays(node *yaml.Node, path string) (map[string][]string, error) {
+	node = resolve(node)
+	if isNull(node) {
+		return nil, nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: %s must be a mapping of environments to lists of paths",
+			path, node.Line, overlaysKey)
+	}
+
+	overlays := make(map[string][]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		environment := node.Content[i].Value
+
+		paths, err := c.expandPaths(resolve(node.Content[i+1]), join(overlaysKey, environment), path)
+		if err != nil {
+			return nil, err
+		}
+
+		overlays[environment] = paths
+
+		if environment == c.Environment {
+			c.overlayFiles = make(map[string]bool)
+			for _, p := range paths {
+				c.overlayFiles[p] = true
+			}
+		}
+	}
+
+	return overlays, nil
+}
+
+// takeKey returns the value of the key of the mapping, the key is removed from the mapping when remove is set
+func takeKey(mapping *yaml.Node, key string, remove bool) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+
+		value := mapping.Content[i+1]
+		if remove {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		}
+
+		return value
+	}
+
+	return nil
+}
+
+// merge merges the mapping src of an included file into dst. Mappings are merged and lists are concatenated, a
+// preset that is defined in both or a value that is set differently is a conflict.
+func (c *composer) merge(dst, src *yaml.Node, path string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcKey, srcValue := src.Content[i], src.Content[i+1]
+		keyPath := join(path, srcKey.Value)
+
+		j := indexKey(dst, srcKey.Value)
+		if j < 0 || srcKey.Value == "<<" {
+			dst.Content = append(dst.Content, srcKey, srcValue)
+			continue
+		}
+
+		dstKey, dstValue := dst.Content[j], dst.Content[j+1]
+
+		if path == "presets" {
+			c.conflict("preset '%s' is defined in both %s and %s", srcKey.Value, c.position(dstKey), c.position(srcKey))
+			continue
+		}
+
+		a, b := resolve(dstValue), resolve(srcValue)
+		switch {
+		case isNull(b):
+			continue
+		case isNull(a):
+			dst.Content[j], dst.Content[j+1] = srcKey, srcValue
+			continue
+		case a.Kind == yaml.MappingNode && b.Kind == yaml.MappingNode:
+			c.merge(a, b, keyPath)
+		case a.Kind == yaml.SequenceNode && b.Kind == yaml.SequenceNode:
+			for _, item := range b.Content {
+				if !containsScalar(a, item) {
+					a.Content = append(a.Content, item)
+				}
+			}
+		case a.Kind == yaml.ScalarNode && b.Kind == yaml.ScalarNode:
+			if a.Value != b.Value {
+				c.conflict("conflicting values for '%s': '%s' in %s and '%s' in %s",
+					keyPath, a.Value, c.position(dstKey), b.Value, c.position(srcKey))
+				continue
+			}
+		default:
+			c.conflict("conflicting values for '%s': %s in %s and %s in %s",
+				keyPath, describeNode(a), c.position(dstKey), describeNode(b), c.position(srcKey))
+			continue
+		}
+
+		c.extra[keyPath] = append(c.extra[keyPath], c.source(srcKey))
+	}
+}
+
+// override applies the mapping src of an overlay to dst. Mappings are merged, anything else replaces the value.
+func (c *composer) override(dst, src *yaml.Node, path string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcKey, srcValue := src.Content[i], src.Content[i+1]
+		keyPath := join(path, srcKey.Value)
+
+		j := indexKey(dst, srcKey.Value)
+		if j < 0 || srcKey.Value == "<<" {
+			dst.Content = append(dst.Content, srcKey, srcValue)
+			continue
+		}
+
+		a, b := resolve(dst.Content[j+1]), resolve(srcValue)
+		if a.Kind == yaml.MappingNode && b.Kind == yaml.MappingNode {
+			c.override(a, b, keyPath)
+			c.extra[keyPath] = append(c.extra[keyPath], c.source(srcKey))
+			continue
+		}
+
+		// The replaced value is no longer defined by the files that defined it before
+		for key := range c.extra {
+			if key == keyPath || strings.HasPrefix(key, keyPath+".") {
+				delete(c.extra, key)
+			}
+		}
+
+		dst.Content[j], dst.Content[j+1] = srcKey, srcValue
+	}
+}
+
+// record records the source of every key of the merged document
+func (c *composer) record(node *yaml.Node, path string) {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		keyPath := join(path, key.Value)
+
+		c.Sources[keyPath] = append(c.Sources[keyPath], c.source(key))
+		c.record(node.Content[i+1], keyPath)
+	}
+}
+
+func (c *composer) source(node *yaml.Node) Source {
+	file := c.files[node]
+
+	source := Source{File: file, Line: node.Line}
+	if c.overlayFiles[file] {
+		source.Environment = c.Environment
+	}
+
+	return source
+}
+
+func (c *composer) position(node *yaml.Node) string {
+	return fmt.Sprintf("%s:%d", c.files[node], node.Line)
+}
+
+func (c *composer) conflict(format string, args ...interface{}) {
+	c.conflicts = append(c.conflicts, fmt.Errorf(format, args...))
+}
+
+func indexKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func containsScalar(sequence, item *yaml.Node) bool {
+	item = resolve(item)
+	if item.Kind != yaml.ScalarNode {
+		return false
+	}
+
+	for _, existing := range sequence.Content {
+		if existing = resolve(existing); existing.Kind == yaml.ScalarNode && existing.Value == item.Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSource(sources []Source, source Source) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeNode describes the kind of value of a node for a conflict
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return "a single value"
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	libconfig "github.com/ekristen/libnuke/pkg/config"
+	"github.com/ekristen/libnuke/pkg/filter"
+)
+
+func setComposeEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("TEST_ACCOUNT_ID", "000000000000")
+	t.Setenv("TEST_MAX_ATTEMPTS", "8")
+	t.Setenv(EnvironmentVariable, "")
+}
+
+func TestCompose(t *testing.T) {
+	setComposeEnv(t)
+
+	composed, err := Compose("testdata/compose/config.yaml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"testdata/compose/config.yaml",
+		"testdata/compose/teams/data.yaml",
+		"testdata/compose/teams/networking.yaml",
+	}, composed.Files)
+	assert.Equal(t, "", composed.Environment)
+
+	assert.Equal(t, []Source{
+		{File: "testdata/compose/config.yaml", Line: 19},
+		{File: "testdata/compose/teams/networking.yaml", Line: 13},
+	}, composed.Sources.Get("accounts", "000000000000", "filters", "IAMRole"))
+	assert.Equal(t, []Source{
+		{File: "testdata/compose/teams/data.yaml", Line: 2},
+	}, composed.Sources.Get("presets", "data"))
+	assert.Nil(t, composed.Sources.Get("include"))
+}
+
+func TestConfig_LoadComposed(t *testing.T) {
+	setComposeEnv(t)
+
+	cfg, err := New(libconfig.Options{
+		Path: "testdata/compose/config.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"us-east-1", "us-west-2"}, cfg.Regions)
+	assert.Equal(t, "7d", cfg.MinAge)
+	assert.Equal(t, 8, cfg.Retry.MaxAttempts)
+	assert.True(t, cfg.ExpandEnv)
+	assert.Equal(t, []string{
+		"testdata/compose/teams/data.yaml",
+		"testdata/compose/teams/networking.yaml",
+	}, cfg.Include)
+	assert.Equal(t, map[string][]string{
+		"staging": {"testdata/compose/overlays/staging.yaml"},
+	}, cfg.Overlays)
+	assert.Len(t, cfg.Presets, 2)
+
+	filters, err := cfg.Filters("000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, []filter.Filter{
+		filter.NewExactFilter("admin"),
+		filter.NewExactFilter("network-admin"),
+	}, filters["IAMRole"])
+	assert.Len(t, filters["S3Bucket"], 1)
+	assert.Len(t, filters["EC2VPC"], 1)
+}
+
+func TestConfig_LoadOverlay(t *testing.T) {
+	setComposeEnv(t)
+	t.Setenv(EnvironmentVariable, "staging")
+	t.Setenv("TEST_MIN_AGE", "3d")
+
+	composed, err := Compose("testdata/compose/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "staging", composed.Environment)
+	assert.Equal(t, []Source{
+		{File: "testdata/compose/overlays/staging.yaml", Line: 1, Environment: "staging"},
+	}, composed.Sources.Get("regions"))
+
+	cfg, err := New(libconfig.Options{
+		Path: "testdata/compose/config.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"eu-west-1"}, cfg.Regions)
+	assert.Equal(t, "1d", cfg.MinAge)
+	assert.Equal(t, []string{"1234567890"}, cfg.Blocklist)
+}
+
+func TestCompose_NoExpandEnv(t *testing.T) {
+	t.Setenv(EnvironmentVariable, "")
+
+	// A configuration that does not opt in to the expansion loads the same as before, ${ included
+	composed, err := Compose("testdata/compose/literal.yaml")
+	assert.NoError(t, err)
+	assert.False(t, composed.ExpandEnv)
+
+	cfg, err := New(libconfig.Options{
+		Path: "testdata/compose/literal.yaml",
+	})
+	assert.NoError(t, err)
+
+	filters, err := cfg.Filters("000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, []filter.Filter{
+		filter.NewExactFilter("/app/${UNSET_VARIABLE}/password"),
+		filter.NewExactFilter("/app/$${ESCAPED}/password"),
+	}, filters["SSMParameter"])
+}
+
+func TestCompose_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "missing-variable",
+			path: "testdata/compose/config.yaml",
+			env:  map[string]string{"TEST_MAX_ATTEMPTS": "8"},
+			want: []string{"testdata/compose/config.yaml:14: environment variable TEST_ACCOUNT_ID is not set"},
+		},
+		{
+			name: "unknown-environment",
+			path: "testdata/compose/config.yaml",
+			env:  map[string]string{"TEST_ACCOUNT_ID": "1", "TEST_MAX_ATTEMPTS": "8", EnvironmentVariable: "prod"},
+			want: []string{"AWS_NUKE_ENVIRONMENT is set to 'prod', but testdata/compose/config.yaml has no overlay"},
+		},
+		{
+			name: "conflicts",
+			path: "testdata/compose/conflict/config.yaml",
+			want: []string{
+				"conflicting values for 'min-age': '7d' in testdata/compose/conflict/config.yaml:3 and '14d' in " +
+					"testdata/compose/conflict/other.yaml:1",
+				"preset 'common' is defined in both testdata/compose/conflict/config.yaml:5 and " +
+					"testdata/compose/conflict/other.yaml:3",
+			},
+		},
+		{
+			name: "expand-env-in-include",
+			path: "testdata/compose/expand-include/config.yaml",
+			want: []string{
+				"testdata/compose/expand-include/other.yaml:1: expand-env can only be set in the main configuration file",
+			},
+		},
+		{
+			name: "cycle",
+			path: "testdata/compose/cycle/a.yaml",
+			want: []string{
+				"include cycle: testdata/compose/cycle/a.yaml -> testdata/compose/cycle/b.yaml -> " +
+					"testdata/compose/cycle/a.yaml",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvironmentVariable, "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Compose(tc.path)
+			assert.Error(t, err)
+			for _, want := range tc.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TEST_SET", "value")
+	t.Setenv("TEST_EMPTY", "")
+
+	cases := []struct {
+		value string
+		want  string
+		err   bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: "${TEST_SET}", want: "value"},
+		{value: "prefix-${TEST_SET}-suffix", want: "prefix-value-suffix"},
+		{value: "${TEST_EMPTY}", want: ""},
+		{value: "${TEST_EMPTY:-default}", want: "default"},
+		{value: "${TEST_UNSET:-default}", want: "default"},
+		{value: "${TEST_UNSET:-}", want: ""},
+		{value: "$${TEST_SET}", want: "${TEST_SET}"},
+		{value: "^name$", want: "^name$"},
+		{value: "${TEST_UNSET}", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := expandEnv(tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	setComposeEnv(t)
+
+	issues, err := ValidateFile("testdata/compose/config.yaml", testCatalog())
+	assert.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{
+			File: "testdata/compose/config.yaml", Line: 19, Column: 7, Path: "accounts.000000000000.filters.IAMRole",
+			Severity: SeverityError, Message: "unknown resource type 'IAMRole'",
+		},
+		{
+			File: "testdata/compose/teams/networking.yaml", Line: 7, Column: 7, Path: "presets.networking.filters.EC2VPC",
+			Severity: SeverityError, Message: "unknown resource type 'EC2VPC'",
+		},
+	}, issues)
+}
+
+func TestComposition_Hash(t *testing.T) {
+	setComposeEnv(t)
+
+	hash := func() string {
+		composed, err := Compose("testdata/compose/config.yaml")
+		assert.NoError(t, err)
+
+		h, err := composed.Hash()
+		assert.NoError(t, err)
+		return h
+	}
+
+	first := hash()
+	assert.Len(t, first, 64)
+	assert.Equal(t, first, hash())
+
+	t.Setenv("TEST_ACCOUNT_ID", "111111111111")
+	assert.NotEqual(t, first, hash())
+}
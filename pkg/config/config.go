@@ -2,35 +2,63 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"slices"
 	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/sirupsen/logrus"
 
 	"github.com/ekristen/libnuke/pkg/config"
 	"github.com/ekristen/libnuke/pkg/settings"
 )
 
 // New creates a new extended configuration from a file. This is necessary because we are extended the default
-// libnuke configuration to contain additional attributes that are specific to the AWS Nuke tool.
+// libnuke configuration to contain additional attributes that are specific to the AWS Nuke tool. The libnuke config
+// is loaded as part of the extended config, as libnuke can only load a single file and the configuration can include
+// other files.
 func New(opts config.Options) (*Config, error) {
-	// Step 1 - Create the libnuke config
-	cfg, err := config.New(opts)
-	if err != nil {
-		return nil, err
+	// Step 1 - Create the libnuke config, with the same defaults as libnuke
+	cfg := &config.Config{
+		Accounts:     make(map[string]*config.Account),
+		Presets:      make(map[string]config.Preset),
+		Deprecations: make(map[string]string),
+		Settings:     &settings.Settings{},
+		Log:          opts.Log,
+	}
+
+	if cfg.Log == nil {
+		// The only way output is logged is if the instantiating tool provides a logger
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		cfg.Log = logger.WithField("component", "config")
+	}
+
+	if len(opts.Deprecations) > 0 {
+		cfg.Deprecations = opts.Deprecations
 	}
 
 	// Step 2 - Instantiate the extended config
 	c := &Config{
+		Config:          cfg,
 		CustomEndpoints: make(CustomEndpoints, 0),
 	}
 
-	// Step 3 - Load the same config file against the extended config
-	// Intentionally ignored, this will never error because we already validated the file exists
-	_ = c.Load(opts.Path)
+	// Step 3 - Load the config file, together with the files it includes and the overlay of the environment
+	if err := c.Load(opts.Path); err != nil {
+		return nil, err
+	}
 
-	// Step 4 - Set the libnuke config on the extended config
-	c.Config = cfg
+	// Step 4 - Resolve the blocklist and the deprecated resource types, as libnuke does
+	if !opts.NoResolveBlacklist {
+		c.Blocklist = c.ResolveBlocklist()
+	}
+
+	if !opts.NoResolveDeprecations {
+		if err := c.ResolveDeprecations(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Step 5 - Resolve any deprecated feature flags
 	c.ResolveDeprecatedFeatureFlags()
@@ -65,21 +93,129 @@ type Config struct {
 
 	// CustomEndpoints is a collection of custom endpoints that can be used to override the default AWS endpoints.
 	CustomEndpoints CustomEndpoints `yaml:"endpoints"`
+
+	// Notifications is a collection of webhooks that receive a summary of the run when it starts, when the prompt has
+	// been passed and when it finishes or fails.
+	Notifications []*Notification `yaml:"notifications"`
+
+	// Schedules is a map of account IDs to the cron expression on which the account is nuked when running the serve
+	// command.
+	Schedules map[string]string `yaml:"schedules"`
+
+	// MinAge protects resources that were created more recently than this age, for example 7d or 36h. Resources whose
+	// creation time is unknown are not protected. The --older-than flag takes precedence.
+	MinAge string `yaml:"min-age"`
+
+	// ExpiryTag is the tag that holds the time a resource expires at, as an RFC3339 time or an age since the resource
+	// was created. Resources are protected until they have expired.
+	ExpiryTag string `yaml:"expiry-tag"`
+
+	// NoExpiryTag is what happens to resources without the expiry tag, one of delete, keep or keep-for-<age>. The
+	// default is delete.
+	NoExpiryTag string `yaml:"no-expiry-tag"`
+
+	// ProtectTag is the tag, as key=value or just key, that protects every resource that has it. It is checked for
+	// every resource whatever the filters and presets say, and cannot be overridden.
+	ProtectTag string `yaml:"protect-tag"`
+
+	// Organization restricts the accounts that can be nuked by their place in AWS Organizations. When it is set the
+	// account is looked up in Organizations before anything is scanned and the run aborts if it cannot be.
+	Organization *Organization `yaml:"organization"`
+
+	// Retry is the retry policy of the calls made to the AWS APIs, with overrides per service.
+	Retry *Retry `yaml:"retry"`
+
+	// ExpandEnv enables the expansion of environment variables in every value and key of the configuration and the
+	// files it includes. Load sets it from the main configuration file.
+	ExpandEnv bool `yaml:"expand-env"`
+
+	// Include are the files, or glob patterns of files, that are merged into the configuration, relative to the file
+	// that includes them. Load sets it to the files that were merged, in the order they were merged.
+	Include []string `yaml:"include"`
+
+	// Overlays are the files that are applied on top of the configuration for each environment, the environment is
+	// selected with AWS_NUKE_ENVIRONMENT. Load sets it to the files of each environment, with the patterns resolved.
+	Overlays map[string][]string `yaml:"overlays"`
 }
 
-// Load loads a configuration from a file and parses it into a Config struct.
-func (c *Config) Load(path string) error {
-	var err error
+// Organization are the checks of an account against AWS Organizations. The management account and the accounts that
+// are delegated to administer a service are always refused, and the name of the account is checked against the
+// blocklist terms the same as its aliases.
+type Organization struct {
+	// RoleArn is the role that is assumed to look up the account in Organizations, in the management account or an
+	// account that is delegated to administer Organizations. Without it the credentials of the account are used.
+	RoleArn string `yaml:"role-arn"`
+
+	// AllowedOUs are the IDs of the organizational units whose accounts can be nuked, an account in an organizational
+	// unit nested below one of them is allowed as well.
+	AllowedOUs []string `yaml:"allowed-ous"`
+
+	// RequiredTags are the tags an account must have to be nuked, an empty value allows any value of the tag.
+	RequiredTags map[string]string `yaml:"required-tags"`
+}
+
+// OrganizationAccount is an account as it is known to AWS Organizations
+type OrganizationAccount struct {
+	// ID is the ID of the account
+	ID string
+
+	// Name is the name of the account
+	Name string
+
+	// ManagementAccountID is the ID of the management account of the organization of the account
+	ManagementAccountID string
+
+	// Parents are the IDs of the organizational units the account is in, from its direct parent up to the root
+	Parents []string
+
+	// Tags are the tags of the account
+	Tags map[string]string
+
+	// DelegatedAdministrator is whether the account is delegated to administer any service of the organization
+	DelegatedAdministrator bool
+}
 
-	raw, err := os.ReadFile(path)
+// Retry is the retry policy of the calls made to the AWS APIs. Services can override any of the settings, they are
+// keyed by the service ID in lower case without spaces, for example iam, cloudcontrol or route53.
+type Retry struct {
+	RetrySettings `yaml:",inline"`
+
+	Services map[string]*RetrySettings `yaml:"services"`
+}
+
+// RetrySettings are the settings of a retry policy, unset values are inherited from the global policy or the defaults.
+type RetrySettings struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the first one.
+	MaxAttempts int `yaml:"max-attempts"`
+
+	// BaseBackoff is the backoff before the first retry, it doubles with every retry.
+	BaseBackoff time.Duration `yaml:"base-backoff"`
+
+	// MaxBackoff is the maximum backoff between two attempts.
+	MaxBackoff time.Duration `yaml:"max-backoff"`
+
+	// Adaptive enables client side rate limiting, the rate of calls to a service is lowered when it is throttled
+	// and slowly raised again once it is no longer throttled.
+	Adaptive *bool `yaml:"adaptive"`
+}
+
+// Load loads a configuration from a file, merged with the files it includes and the overlay of the environment, and
+// parses it into a Config struct. See Compose for how the files are merged and where each element is defined.
+func (c *Config) Load(path string) error {
+	composed, err := Compose(path)
 	if err != nil {
 		return err
 	}
 
-	if err := yaml.Unmarshal(raw, c); err != nil {
+	if err := composed.Document.Decode(c); err != nil {
 		return err
 	}
 
+	// The keys that compose the configuration are not part of the merged document, they are taken from the composition
+	c.ExpandEnv = composed.ExpandEnv
+	c.Include = composed.Include
+	c.Overlays = composed.Overlays
+
 	if !c.NoBlocklistTermsDefault {
 		c.BlocklistTerms = append(c.BlocklistTerms, "prod")
 	}
@@ -87,6 +223,48 @@ func (c *Config) Load(path string) error {
 	return nil
 }
 
+// ValidateOrganization validates the account against the organization checks of the configuration. Unlike the alias
+// checks they cannot be bypassed, an account that fails any of them is never nuked.
+func (c *Config) ValidateOrganization(account *OrganizationAccount) error {
+	if c.Organization == nil {
+		return nil
+	}
+
+	if account.ID == account.ManagementAccountID {
+		return fmt.Errorf("you are trying to nuke the account '%s', "+
+			"but it is the management account of its organization. Aborting", account.ID)
+	}
+
+	if account.DelegatedAdministrator {
+		return fmt.Errorf("you are trying to nuke the account '%s', "+
+			"but it is a delegated administrator of its organization. Aborting", account.ID)
+	}
+
+	for _, keyword := range c.BlocklistTerms {
+		if strings.Contains(strings.ToLower(account.Name), keyword) {
+			return fmt.Errorf("you are trying to nuke an account with the name '%s', "+
+				"but it contains the blocklisted keyword '%s'. Aborting", account.Name, keyword)
+		}
+	}
+
+	if len(c.Organization.AllowedOUs) > 0 && !slices.ContainsFunc(account.Parents, func(parent string) bool {
+		return slices.Contains(c.Organization.AllowedOUs, parent)
+	}) {
+		return fmt.Errorf("you are trying to nuke the account '%s', "+
+			"but it is not in any of the allowed organizational units. Aborting", account.ID)
+	}
+
+	for key, value := range c.Organization.RequiredTags {
+		actual, ok := account.Tags[key]
+		if !ok || (value != "" && actual != value) {
+			return fmt.Errorf("you are trying to nuke the account '%s', "+
+				"but it does not have the required tag '%s'. Aborting", account.ID, key)
+		}
+	}
+
+	return nil
+}
+
 // InBypassAliasCheckAccounts returns true if the specified account ID is in the bypass alias check accounts list.
 func (c *Config) InBypassAliasCheckAccounts(accountID string) bool {
 	for _, id := range c.BypassAliasCheckAccounts {
@@ -259,3 +437,23 @@ func (endpoints CustomEndpoints) GetURL(region, serviceType string) string {
 	}
 	return s.URL
 }
+
+// Notification is a webhook that receives a summary of the run
+type Notification struct {
+	// URL is the webhook the summary is posted to.
+	URL string `yaml:"url"`
+
+	// Format is the format of the payload, one of slack, teams or json. Defaults to json.
+	Format string `yaml:"format"`
+
+	// Events limits the events that are sent to the webhook, one or more of start, prompt, finish or fail. Defaults to
+	// all events.
+	Events []string `yaml:"events"`
+
+	// Template is a Go template that replaces the default message for the slack and teams formats, or the entire
+	// payload for the json format.
+	Template string `yaml:"template"`
+
+	// Headers are additional headers that are sent with the request, for example for authentication.
+	Headers map[string]string `yaml:"headers"`
+}
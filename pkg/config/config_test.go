@@ -6,7 +6,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gotidy/ptr"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
@@ -501,3 +503,166 @@ func TestConfig_ValidateAccount_Blocklist(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_Notifications(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/notifications.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*Notification{
+		{
+			URL:    "https://hooks.slack.com/services/T000/B000/XXXX",
+			Format: "slack",
+			Events: []string{"finish", "fail"},
+		},
+		{
+			URL:      "http://localhost:8080/webhook",
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+			Template: `{"text": "{{ .Event }} {{ .AccountID }}"}`,
+		},
+	}, config.Notifications)
+}
+
+func TestConfig_Schedules(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/schedules.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"555133742": "0 2 * * *",
+		"555133743": "@weekly",
+	}, config.Schedules)
+}
+
+func TestConfig_MinAge(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/min-age.yaml",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "7d", config.MinAge)
+
+	config, err = New(libconfig.Options{
+		Path: "testdata/schedules.yaml",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "", config.MinAge)
+}
+
+func TestConfig_ExpiryTag(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/expiry-tag.yaml",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "nuke:expires-at", config.ExpiryTag)
+	assert.Equal(t, "keep-for-7d", config.NoExpiryTag)
+}
+
+func TestConfig_ProtectTag(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/protect-tag.yaml",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws-nuke:protect=true", config.ProtectTag)
+}
+
+func TestConfig_ValidateOrganization(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/organization.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, &Organization{
+		RoleArn:    "arn:aws:iam::111111111111:role/aws-nuke-organizations",
+		AllowedOUs: []string{"ou-abcd-sandbox"},
+		RequiredTags: map[string]string{
+			"environment": "sandbox",
+			"owner":       "",
+		},
+	}, config.Organization)
+
+	allowed := func() *OrganizationAccount {
+		return &OrganizationAccount{
+			ID:                  "555133742",
+			Name:                "team-sandbox",
+			ManagementAccountID: "111111111111",
+			Parents:             []string{"ou-abcd-team", "ou-abcd-sandbox", "r-abcd"},
+			Tags:                map[string]string{"environment": "sandbox", "owner": "team"},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		modify func(a *OrganizationAccount)
+		error  string
+	}{
+		{
+			name:   "allowed",
+			modify: func(_ *OrganizationAccount) {},
+		},
+		{
+			name:   "management account",
+			modify: func(a *OrganizationAccount) { a.ManagementAccountID = a.ID },
+			error:  "it is the management account of its organization",
+		},
+		{
+			name:   "delegated administrator",
+			modify: func(a *OrganizationAccount) { a.DelegatedAdministrator = true },
+			error:  "it is a delegated administrator of its organization",
+		},
+		{
+			name:   "blocklisted name",
+			modify: func(a *OrganizationAccount) { a.Name = "Team-Production" },
+			error:  "it contains the blocklisted keyword 'prod'",
+		},
+		{
+			name:   "outside allowed ous",
+			modify: func(a *OrganizationAccount) { a.Parents = []string{"ou-abcd-workloads", "r-abcd"} },
+			error:  "it is not in any of the allowed organizational units",
+		},
+		{
+			name:   "missing tag",
+			modify: func(a *OrganizationAccount) { delete(a.Tags, "owner") },
+			error:  "it does not have the required tag 'owner'",
+		},
+		{
+			name:   "wrong tag value",
+			modify: func(a *OrganizationAccount) { a.Tags["environment"] = "production" },
+			error:  "it does not have the required tag 'environment'",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			account := allowed()
+			tc.modify(account)
+
+			err := config.ValidateOrganization(account)
+			if tc.error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.error)
+			}
+		})
+	}
+
+	// Without the organization section nothing is checked
+	config.Organization = nil
+	assert.NoError(t, config.ValidateOrganization(&OrganizationAccount{ID: "111111111111", ManagementAccountID: "111111111111"}))
+}
+
+func TestConfig_Retry(t *testing.T) {
+	config, err := New(libconfig.Options{
+		Path: "testdata/retry.yaml",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, config.Retry.MaxAttempts)
+	assert.Equal(t, 200*time.Millisecond, config.Retry.BaseBackoff)
+	assert.Equal(t, 30*time.Second, config.Retry.MaxBackoff)
+	assert.Nil(t, config.Retry.Adaptive)
+
+	assert.Equal(t, 12, config.Retry.Services["cloudcontrol"].MaxAttempts)
+	assert.Equal(t, ptr.Bool(false), config.Retry.Services["iam"].Adaptive)
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/ekristen/libnuke/pkg/docs"
+	"github.com/ekristen/libnuke/pkg/resource"
+)
+
+// sampleValue is the value of every string of the sample resource, it shows up in properties whose names are taken
+// from the data of the resource, such as tags
+const sampleValue = "aws-nuke-sample"
+
+// sampleDepth is how deep the fields of the sample resource are filled in, it keeps the clients of resources small
+const sampleDepth = 4
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// resourceProperties returns the properties of a resource type and the prefixes of its tags. Many resources build
+// their properties by hand rather than from the property struct tags, so the properties are taken from a sample
+// resource whose fields are all filled in, together with the properties that the struct tags describe. Nil is
+// returned when the properties cannot be known, for example when their names depend on the data of the resource.
+func resourceProperties(res interface{}) (properties, tagPrefixes []string) {
+	sample, ok := sampleProperties(res)
+	if !ok {
+		return nil, nil
+	}
+
+	for key := range sample {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+
+		if strings.HasPrefix(key, "tag:") && strings.HasSuffix(key, sampleValue) {
+			tagPrefixes = append(tagPrefixes, strings.TrimSuffix(key, sampleValue))
+			continue
+		}
+
+		if strings.Contains(key, sampleValue) {
+			return nil, nil
+		}
+
+		properties = append(properties, key)
+	}
+
+	for property := range docs.GeneratePropertiesMap(res) {
+		if prefix, ok := strings.CutSuffix(property, "<key>:"); ok {
+			tagPrefixes = append(tagPrefixes, prefix)
+			continue
+		}
+
+		properties = append(properties, property)
+	}
+
+	properties = append(properties, createdAtProperty)
+
+	sort.Strings(properties)
+	sort.Strings(tagPrefixes)
+
+	return slices.Compact(properties), slices.Compact(tagPrefixes)
+}
+
+// sampleProperties returns the properties of a sample of the resource, false if the resource has no properties or
+// they could not be built from the sample
+func sampleProperties(res interface{}) (properties map[string]string, ok bool) {
+	if res == nil {
+		return nil, false
+	}
+
+	t := reflect.TypeOf(res)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	sample := reflect.New(t.Elem())
+	fill(sample.Elem(), 0)
+
+	getter, ok := sample.Interface().(resource.PropertyGetter)
+	if !ok {
+		return nil, false
+	}
+
+	defer func() {
+		if recover() != nil {
+			properties, ok = nil, false
+		}
+	}()
+
+	return getter.Properties(), true
+}
+
+// fill sets every field of the value to a sample value, including unexported fields as properties are often built
+// from those
+func fill(v reflect.Value, depth int) { //nolint:gocyclo
+	if depth > sampleDepth {
+		return
+	}
+
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem() //nolint:gosec
+	}
+
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(sampleValue)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		fill(elem.Elem(), depth+1)
+		v.Set(elem)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), 1, 1)
+		fill(slice.Index(0), depth+1)
+		v.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMapWithSize(v.Type(), 1)
+		key := reflect.New(v.Type().Key()).Elem()
+		value := reflect.New(v.Type().Elem()).Elem()
+		fill(key, depth+1)
+		fill(value, depth+1)
+		m.SetMapIndex(key, value)
+		v.Set(m)
+	case reflect.Struct:
+		// locks and other synchronization primitives are left as they are
+		if strings.HasPrefix(v.Type().PkgPath(), "sync") {
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			fill(v.Field(i), depth+1)
+		}
+	default:
+		// interfaces, functions and channels are left empty
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/settings"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// The types of the configuration that are described by the registry rather than by their Go type
+var (
+	filtersType    = reflect.TypeOf(filter.Filters{})
+	settingsType   = reflect.TypeOf(settings.Settings{})
+	collectionType = reflect.TypeOf(types.Collection{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+)
+
+// FilterTypes are the types of filters, an empty type is an exact match
+var FilterTypes = []string{
+	string(filter.Exact), string(filter.Glob), string(filter.Regex), string(filter.Contains),
+	string(filter.DateOlderThan), string(filter.DateOlderThanNow), string(filter.Prefix), string(filter.Suffix),
+	string(filter.In), string(filter.NotIn),
+}
+
+// filterKeys are the keys of a filter that is not just a value
+var filterKeys = []string{"group", "type", "property", "value", "values", "invert"}
+
+// globPattern matches the entries of a list of resource types that are glob patterns
+const globPattern = `[*?\[]`
+
+// yamlField is a field of a struct as it appears in the configuration file
+type yamlField struct {
+	name string
+	typ  reflect.Type
+}
+
+// yamlFields returns the fields of a struct by their yaml name, fields of inlined structs are included
+func yamlFields(t reflect.Type) []yamlField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var fields []yamlField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if strings.Contains(options, "inline") {
+			fields = append(fields, yamlFields(f.Type)...)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		fields = append(fields, yamlField{name: name, typ: f.Type})
+	}
+
+	return fields
+}
+
+// Schema returns a JSON Schema of the configuration file. The resource types, the properties they can be filtered by
+// and the settings they support are taken from the catalog.
+func Schema(catalog *Catalog) map[string]interface{} {
+	g := &schemaGenerator{
+		catalog: catalog,
+		defs:    make(map[string]interface{}),
+	}
+
+	root := g.schema(reflect.TypeOf(Config{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "aws-nuke configuration"
+	root["$defs"] = g.defs
+
+	return root
+}
+
+type schemaGenerator struct {
+	catalog *Catalog
+	defs    map[string]interface{}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} { //nolint:gocyclo
+	switch t {
+	case filtersType:
+		return g.ref("filters", g.filters)
+	case settingsType:
+		return g.ref("settings", g.settings)
+	case collectionType:
+		return g.ref("resource-types", g.resourceTypes)
+	case durationType:
+		return map[string]interface{}{
+			"type":        []string{"string", "integer"},
+			"description": "a duration such as 500ms, 30s or 5m",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for _, f := range yamlFields(t) {
+			properties[f.name] = g.schema(f.typ)
+		}
+
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schema(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.schema(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// ref returns a reference to a definition, the definition is generated the first time it is referenced
+func (g *schemaGenerator) ref(name string, generate func() map[string]interface{}) map[string]interface{} {
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = map[string]interface{}{} // guards against recursion
+		g.defs[name] = generate()
+	}
+
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) filters() map[string]interface{} {
+	properties := map[string]interface{}{
+		filter.Global: g.filterList(&ResourceType{}, ""),
+	}
+
+	for name, rt := range g.catalog.ResourceTypes {
+		properties[name] = g.filterList(rt, name)
+	}
+
+	for name, replacement := range g.catalog.Deprecated {
+		list := g.filterList(&ResourceType{}, "")
+		list["deprecated"] = true
+		list["description"] = "deprecated, use " + replacement
+		properties[name] = list
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"description":          "the filters of the resources that are kept, by resource type",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// filterList is the list of filters of a resource type, the resource types whose properties are known get their own
+// definition that lists the properties
+func (g *schemaGenerator) filterList(rt *ResourceType, name string) map[string]interface{} {
+	def := "filter"
+	if rt.Properties != nil {
+		def = "filter-" + name
+	}
+
+	return map[string]interface{}{
+		"type": "array",
+		"items": g.ref(def, func() map[string]interface{} {
+			return filterSchema(rt)
+		}),
+	}
+}
+
+func filterSchema(rt *ResourceType) map[string]interface{} {
+	property := map[string]interface{}{"type": "string"}
+	if rt.Properties != nil {
+		anyOf := []interface{}{map[string]interface{}{"enum": rt.Properties}}
+		if len(rt.TagPrefixes) > 0 {
+			prefixes := make([]string, 0, len(rt.TagPrefixes))
+			for _, prefix := range rt.TagPrefixes {
+				prefixes = append(prefixes, regexp.QuoteMeta(prefix))
+			}
+
+			anyOf = append(anyOf, map[string]interface{}{
+				"type":    "string",
+				"pattern": "^(" + strings.Join(prefixes, "|") + ").+",
+			})
+		}
+
+		property = map[string]interface{}{"anyOf": anyOf}
+	}
+
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{
+				"type":        "string",
+				"description": "the value that the resource is matched against exactly",
+			},
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group":    map[string]interface{}{"type": "string"},
+					"type":     map[string]interface{}{"enum": append([]string{""}, FilterTypes...)},
+					"property": property,
+					"value":    map[string]interface{}{"type": "string"},
+					"values": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"type": "string"},
+					},
+					"invert": map[string]interface{}{"type": []string{"boolean", "string"}},
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+func (g *schemaGenerator) settings() map[string]interface{} {
+	properties := make(map[string]interface{})
+	for name, rt := range g.catalog.ResourceTypes {
+		if len(rt.Settings) == 0 {
+			continue
+		}
+
+		settingProperties := make(map[string]interface{}, len(rt.Settings))
+		for _, setting := range rt.Settings {
+			settingProperties[setting] = map[string]interface{}{}
+		}
+
+		properties[name] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           settingProperties,
+			"additionalProperties": false,
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"description":          "the settings of the resource types that support them, by resource type",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func (g *schemaGenerator) resourceTypes() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"enum": g.catalog.Names()},
+				map[string]interface{}{"type": "string", "pattern": cloudControlPattern.String()},
+				map[string]interface{}{"type": "string", "pattern": globPattern},
+			},
+		},
+	}
+}
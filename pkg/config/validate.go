@@ -0,0 +1,552 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	libconfig "github.com/ekristen/libnuke/pkg/config"
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// Severity is how serious an issue in the configuration is
+type Severity string
+
+const (
+	// SeverityError is a mistake that makes the configuration behave differently than intended, or fail at runtime
+	SeverityError Severity = "error"
+
+	// SeverityWarning is something that works, but is likely unintended or deprecated
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in the configuration file
+type Issue struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+}
+
+// deprecatedKeys are the keys of the configuration that still work but have been replaced
+var deprecatedKeys = map[string]string{
+	"account-blacklist": "blocklist",
+	"account-blocklist": "blocklist",
+	"feature-flags":     "settings",
+	"targets":           "includes",
+	"cloud-control":     "alternatives",
+}
+
+var (
+	accountType = reflect.TypeOf(libconfig.Account{})
+	configType  = reflect.TypeOf(Config{})
+)
+
+// Validate checks the configuration file against the catalog of resource types, without connecting to AWS. It reports
+// unknown keys, unknown resource types, properties and settings, values of the wrong type and presets that are not
+// defined or not used, with the line they are found on. An error is only returned when the file is not valid YAML.
+func Validate(data []byte, catalog *Catalog) ([]Issue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return validate(&doc, catalog, nil), nil
+}
+
+// ValidateFile checks the configuration file like Validate once the files it includes and the overlay of the
+// environment have been merged into it, the issues hold the file they are found in. An error is returned when the
+// files cannot be loaded or merged.
+func ValidateFile(path string, catalog *Catalog) ([]Issue, error) {
+	composed, err := Compose(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return validate(composed.Document, catalog, composed), nil
+}
+
+func validate(doc *yaml.Node, catalog *Catalog, composed *Composition) []Issue {
+	v := &validator{
+		catalog:    catalog,
+		composed:   composed,
+		presets:    make(map[string]*yaml.Node),
+		presetRefs: make(map[string][]*yaml.Node),
+	}
+
+	if len(doc.Content) > 0 {
+		v.walk(doc.Content[0], configType, "")
+	}
+
+	v.checkPresets()
+
+	fileOrder := func(issue Issue) int {
+		if composed == nil {
+			return 0
+		}
+		return slices.Index(composed.Files, issue.File)
+	}
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		a, b := v.issues[i], v.issues[j]
+		if fileOrder(a) != fileOrder(b) {
+			return fileOrder(a) < fileOrder(b)
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return v.issues
+}
+
+type validator struct {
+	catalog *Catalog
+	issues  []Issue
+
+	// composed is the composition the document was merged from, nil when it is a single file
+	composed *Composition
+
+	// presets are the presets that are defined, presetRefs the references to presets by the accounts
+	presets    map[string]*yaml.Node
+	presetRefs map[string][]*yaml.Node
+}
+
+func (v *validator) report(node *yaml.Node, path string, severity Severity, format string, args ...interface{}) {
+	var file string
+	if v.composed != nil {
+		file = v.composed.File(node)
+	}
+
+	v.issues = append(v.issues, Issue{
+		File:     file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// resolve follows aliases to the node they refer to
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// pairs returns the key and value nodes of a mapping, the keys of merged mappings are included
+func pairs(node *yaml.Node) [][2]*yaml.Node {
+	var result [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolve(node.Content[i+1])
+		if key.Value == "<<" && key.Tag == "!!merge" {
+			merged := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				merged = value.Content
+			}
+
+			for _, m := range merged {
+				if m = resolve(m); m.Kind == yaml.MappingNode {
+					result = append(result, pairs(m)...)
+				}
+			}
+			continue
+		}
+
+		result = append(result, [2]*yaml.Node{key, value})
+	}
+
+	return result
+}
+
+// expect reports when the node is not of the expected kind, null values are always allowed
+func (v *validator) expect(node *yaml.Node, path string, kind yaml.Kind, description string) bool {
+	if isNull(node) {
+		return false
+	}
+
+	if node.Kind != kind {
+		name := path
+		if name == "" {
+			name = "the configuration"
+		}
+
+		v.report(node, path, SeverityError, "%s must be %s", name, description)
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) walk(node *yaml.Node, t reflect.Type, path string) { //nolint:gocyclo
+	node = resolve(node)
+
+	switch t {
+	case filtersType:
+		v.filters(node, path)
+		return
+	case settingsType:
+		v.settings(node, path)
+		return
+	case collectionType:
+		v.resourceTypes(node, path)
+		return
+	case durationType:
+		if v.expect(node, path, yaml.ScalarNode, "a duration such as 30s") {
+			var d time.Duration
+			if err := node.Decode(&d); err != nil {
+				v.report(node, path, SeverityError, "%s must be a duration such as 30s, not '%s'", path, node.Value)
+			}
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.walk(node, t.Elem(), path)
+	case reflect.Struct:
+		v.walkStruct(node, t, path)
+	case reflect.Map:
+		if !v.expect(node, path, yaml.MappingNode, "a mapping") {
+			return
+		}
+
+		for _, kv := range pairs(node) {
+			v.walk(kv[1], t.Elem(), join(path, kv[0].Value))
+		}
+	case reflect.Slice, reflect.Array:
+		if !v.expect(node, path, yaml.SequenceNode, "a list") {
+			return
+		}
+
+		for i, item := range node.Content {
+			v.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Interface:
+	default:
+		if !v.expect(node, path, yaml.ScalarNode, "a single value") {
+			return
+		}
+
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			v.report(node, path, SeverityError, "%s must be %s, not '%s'", path, describeKind(t.Kind()), node.Value)
+		}
+	}
+}
+
+// describeKind describes the values of a kind of scalar for an issue
+func describeKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	default:
+		return "an integer"
+	}
+}
+
+func (v *validator) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	if !v.expect(node, path, yaml.MappingNode, "a mapping") {
+		return
+	}
+
+	fields := yamlFields(t)
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+
+	for _, kv := range pairs(node) {
+		key, value := kv[0], kv[1]
+		keyPath := join(path, key.Value)
+
+		i := slices.IndexFunc(fields, func(f yamlField) bool { return f.name == key.Value })
+		if i < 0 {
+			v.report(key, keyPath, SeverityError, "unknown key '%s'%s", key.Value, suggest(key.Value, names))
+			continue
+		}
+
+		if replacement, ok := deprecatedKeys[key.Value]; ok {
+			v.report(key, keyPath, SeverityWarning, "'%s' is deprecated, use '%s' instead", key.Value, replacement)
+		}
+
+		switch {
+		case t == configType && key.Value == "presets" && value.Kind == yaml.MappingNode:
+			for _, preset := range pairs(value) {
+				v.presets[preset[0].Value] = preset[0]
+			}
+		case t == accountType && key.Value == "presets" && value.Kind == yaml.SequenceNode:
+			for _, ref := range value.Content {
+				ref = resolve(ref)
+				v.presetRefs[ref.Value] = append(v.presetRefs[ref.Value], ref)
+			}
+		}
+
+		v.walk(value, fields[i].typ, keyPath)
+	}
+}
+
+// checkPresets reports the presets that accounts refer to but are not defined, and the presets that no account uses
+func (v *validator) checkPresets() {
+	names := make([]string, 0, len(v.presets))
+	for name := range v.presets {
+		names = append(names, name)
+	}
+
+	for name, refs := range v.presetRefs {
+		if _, ok := v.presets[name]; ok {
+			continue
+		}
+
+		for _, ref := range refs {
+			v.report(ref, "presets", SeverityError, "preset '%s' is not defined%s", name, suggest(name, names))
+		}
+	}
+
+	for name, node := range v.presets {
+		if len(v.presetRefs[name]) == 0 {
+			v.report(node, join("presets", name), SeverityWarning,
+				"preset '%s' is not used by any account, its filters are never applied", name)
+		}
+	}
+}
+
+// resourceType reports an unknown or deprecated resource type and returns it when it is known
+func (v *validator) resourceType(node *yaml.Node, path string) (*ResourceType, bool) {
+	name := node.Value
+
+	rt, ok := v.catalog.Lookup(name)
+	if !ok {
+		v.report(node, path, SeverityError, "unknown resource type '%s'%s", name, suggest(name, v.catalog.Names()))
+		return nil, false
+	}
+
+	if replacement, ok := v.catalog.Deprecated[name]; ok {
+		v.report(node, path, SeverityWarning, "resource type '%s' is deprecated, use '%s' instead", name, replacement)
+	}
+
+	return rt, true
+}
+
+func (v *validator) resourceTypes(node *yaml.Node, path string) {
+	if !v.expect(node, path, yaml.SequenceNode, "a list of resource types") {
+		return
+	}
+
+	names := v.catalog.Names()
+	glob := regexp.MustCompile(globPattern)
+	for i, item := range node.Content {
+		item = resolve(item)
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if !v.expect(item, itemPath, yaml.ScalarNode, "a resource type") {
+			continue
+		}
+
+		if glob.MatchString(item.Value) {
+			// a pattern that matches nothing is kept as is by the expansion
+			if expanded := (types.Collection{item.Value}).Expand(names); len(expanded) == 1 && expanded[0] == item.Value {
+				v.report(item, itemPath, SeverityWarning, "pattern '%s' does not match any resource type", item.Value)
+			}
+			continue
+		}
+
+		v.resourceType(item, itemPath)
+	}
+}
+
+func (v *validator) settings(node *yaml.Node, path string) {
+	if !v.expect(node, path, yaml.MappingNode, "a mapping of resource types") {
+		return
+	}
+
+	for _, kv := range pairs(node) {
+		typePath := join(path, kv[0].Value)
+		rt, ok := v.resourceType(kv[0], typePath)
+		if !ok || !v.expect(kv[1], typePath, yaml.MappingNode, "a mapping of settings") {
+			continue
+		}
+
+		for _, setting := range pairs(kv[1]) {
+			if slices.Contains(rt.Settings, setting[0].Value) {
+				continue
+			}
+
+			if len(rt.Settings) == 0 {
+				v.report(setting[0], join(typePath, setting[0].Value), SeverityError,
+					"unknown setting '%s', %s has no settings", setting[0].Value, kv[0].Value)
+				continue
+			}
+
+			v.report(setting[0], join(typePath, setting[0].Value), SeverityError,
+				"unknown setting '%s' of %s, expected one of: %s",
+				setting[0].Value, kv[0].Value, strings.Join(rt.Settings, ", "))
+		}
+	}
+}
+
+func (v *validator) filters(node *yaml.Node, path string) {
+	if !v.expect(node, path, yaml.MappingNode, "a mapping of resource types") {
+		return
+	}
+
+	for _, kv := range pairs(node) {
+		typePath := join(path, kv[0].Value)
+		rt, ok := v.resourceType(kv[0], typePath)
+		if !ok || !v.expect(kv[1], typePath, yaml.SequenceNode, "a list of filters") {
+			continue
+		}
+
+		for i, item := range kv[1].Content {
+			v.filter(resolve(item), rt, kv[0].Value, fmt.Sprintf("%s[%d]", typePath, i))
+		}
+	}
+}
+
+// filter checks a single filter, which is either a value or a mapping
+func (v *validator) filter(node *yaml.Node, rt *ResourceType, resourceType, path string) { //nolint:funlen,gocyclo
+	if node.Kind == yaml.ScalarNode {
+		if rt.NoValue {
+			v.report(node, path, SeverityError, "%s can only be filtered by a property", resourceType)
+		}
+		return
+	}
+
+	if !v.expect(node, path, yaml.MappingNode, "a value or a filter with a property") {
+		return
+	}
+
+	values := make(map[string]*yaml.Node)
+	for _, kv := range pairs(node) {
+		key, value := kv[0], kv[1]
+		keyPath := join(path, key.Value)
+
+		if !slices.Contains(filterKeys, key.Value) {
+			v.report(key, keyPath, SeverityError, "unknown key '%s' of a filter%s", key.Value, suggest(key.Value, filterKeys))
+			continue
+		}
+		values[key.Value] = value
+
+		switch key.Value {
+		case "values":
+			v.walk(value, reflect.TypeOf([]string{}), keyPath)
+		case "invert":
+			v.walk(value, reflect.TypeOf(true), keyPath)
+		default:
+			// the filter is decoded into a map, any other type than a string fails when the filter is loaded
+			if value.Kind != yaml.ScalarNode || value.Tag != "!!str" {
+				v.report(value, keyPath, SeverityError, "%s must be a string, quote the value", key.Value)
+				delete(values, key.Value)
+			}
+		}
+	}
+
+	property, ok := values["property"]
+	switch {
+	case (!ok || property.Value == "") && rt.NoValue:
+		v.report(node, path, SeverityError, "%s can only be filtered by a property", resourceType)
+	case ok && property.Value != "" && rt.NoProperties:
+		v.report(property, join(path, "property"), SeverityError,
+			"%s has no properties, it can only be filtered by its value", resourceType)
+	case ok && !rt.HasProperty(property.Value):
+		v.report(property, join(path, "property"), SeverityError, "unknown property '%s' of %s%s",
+			property.Value, resourceType, suggest(property.Value, rt.Properties))
+	}
+
+	filterType := ""
+	if typeNode, ok := values["type"]; ok {
+		filterType = typeNode.Value
+		if filterType != "" && !slices.Contains(FilterTypes, filterType) {
+			v.report(typeNode, join(path, "type"), SeverityError, "unknown filter type '%s'%s",
+				filterType, suggest(filterType, FilterTypes))
+			return
+		}
+	}
+
+	value, ok := values["value"]
+	if !ok {
+		return
+	}
+
+	switch filter.Type(filterType) {
+	case filter.DateOlderThan, filter.DateOlderThanNow:
+		if _, err := time.ParseDuration(value.Value); err != nil {
+			v.report(value, join(path, "value"), SeverityError, "%s filter needs a duration such as 24h, not '%s'",
+				filterType, value.Value)
+		}
+	case filter.Regex:
+		if _, err := regexp.Compile(value.Value); err != nil {
+			v.report(value, join(path, "value"), SeverityError, "invalid regex: %s", err)
+		}
+	}
+}
+
+// suggest returns a hint with the candidate that is closest to the value, if any is close enough
+func suggest(value string, candidates []string) string {
+	best, bestDistance := "", len(value)/3+1
+	for _, candidate := range candidates {
+		if d := distance(strings.ToLower(value), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean '%s'?", best)
+}
+
+// distance is the Levenshtein distance between two strings
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testEC2Instance struct {
+	svc interface{} //nolint:unused
+
+	InstanceID   *string
+	InstanceType *string
+	LaunchTime   *string
+	Tags         map[string]string
+}
+
+func (r *testEC2Instance) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testEC2Instance) Properties() types.Properties {
+	return types.NewPropertiesFromStruct(r)
+}
+
+// testS3Bucket builds its properties by hand from unexported fields
+type testS3Bucket struct {
+	name *string
+	tags map[string]string
+}
+
+func (r *testS3Bucket) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testS3Bucket) String() string {
+	return *r.name
+}
+
+func (r *testS3Bucket) Properties() types.Properties {
+	properties := types.NewProperties().Set("Name", r.name)
+	for key, value := range r.tags {
+		properties.SetTagWithPrefix("bucket", &key, &value)
+	}
+
+	return properties
+}
+
+// testInventory names its properties after its data
+type testInventory struct {
+	counts map[string]int
+}
+
+func (r *testInventory) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testInventory) Properties() types.Properties {
+	properties := types.NewProperties()
+	for key, count := range r.counts {
+		properties.Set(key, count)
+	}
+
+	return properties
+}
+
+func (r *testInventory) String() string {
+	return "inventory"
+}
+
+type testLegacyResource struct {
+	name string
+}
+
+func (r *testLegacyResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLegacyResource) String() string {
+	return r.name
+}
+
+func testCatalog() *Catalog {
+	return NewCatalog(registry.Registrations{
+		"EC2Instance": {
+			Name:     "EC2Instance",
+			Resource: &testEC2Instance{},
+			Settings: []string{"DisableDeletionProtection", "DisableStopProtection"},
+		},
+		"S3Bucket": {
+			Name:     "S3Bucket",
+			Resource: &testS3Bucket{},
+		},
+		"Inventory": {
+			Name:     "Inventory",
+			Resource: &testInventory{},
+		},
+		"LegacyResource": {
+			Name:     "LegacyResource",
+			Resource: &testLegacyResource{},
+		},
+	}, map[string]string{"OldS3Bucket": "S3Bucket"})
+}
+
+func TestCatalog(t *testing.T) {
+	c := testCatalog()
+
+	assert.Equal(t, []string{"EC2Instance", "Inventory", "LegacyResource", "S3Bucket"}, c.Names())
+
+	ec2, ok := c.Lookup("EC2Instance")
+	require.True(t, ok)
+	assert.Equal(t, []string{"CreatedAt", "InstanceID", "InstanceType", "LaunchTime"}, ec2.Properties)
+	assert.True(t, ec2.HasProperty("tag:Name"))
+	assert.False(t, ec2.HasProperty("tag:"))
+	assert.False(t, ec2.HasProperty("Name"))
+	assert.True(t, ec2.NoValue)
+
+	s3, ok := c.Lookup("OldS3Bucket")
+	require.True(t, ok)
+	assert.Equal(t, []string{"CreatedAt", "Name"}, s3.Properties)
+	assert.True(t, s3.HasProperty("tag:bucket:Owner"))
+	assert.False(t, s3.HasProperty("tag:Owner"))
+
+	inventory, ok := c.Lookup("Inventory")
+	require.True(t, ok)
+	assert.Nil(t, inventory.Properties)
+	assert.True(t, inventory.HasProperty("Anything"))
+
+	legacy, ok := c.Lookup("LegacyResource")
+	require.True(t, ok)
+	assert.True(t, legacy.NoProperties)
+	assert.False(t, legacy.NoValue)
+
+	_, ok = c.Lookup("AWS::EC2::VPC")
+	assert.True(t, ok)
+
+	_, ok = c.Lookup("EC2Instanse")
+	assert.False(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	data, err := os.ReadFile("testdata/validate.yaml")
+	require.NoError(t, err)
+
+	issues, err := Validate(data, testCatalog())
+	require.NoError(t, err)
+
+	actual := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		actual = append(actual, issue.String())
+	}
+
+	assert.Equal(t, []string{
+		"8:1: error: unknown key 'blocklist-term', did you mean 'blocklist-terms'?",
+		"13:7: error: unknown resource type 'EC2Instanse', did you mean 'EC2Instance'?",
+		"15:7: warning: pattern 'Lambda*' does not match any resource type",
+		"22:5: error: unknown setting 'BypassGovernance', S3Bucket has no settings",
+		"28:9: error: preset 'sandbox' is not defined",
+		"33:21: error: unknown property 'InstanceTyp' of EC2Instance, did you mean 'InstanceType'?",
+		"39:18: error: dateOlderThan filter needs a duration such as 24h, not '7days'",
+		"43:18: error: value must be a string, quote the value",
+		"45:17: error: unknown filter type 'globb', did you mean 'glob'?",
+		"47:7: error: unknown resource type 'EC2Instanse', did you mean 'EC2Instance'?",
+		"49:7: warning: resource type 'OldS3Bucket' is deprecated, use 'S3Bucket' instead",
+		"56:21: error: unknown property 'Whatever' of S3Bucket",
+		"58:3: warning: preset 'unused' is not used by any account, its filters are never applied",
+		"61:11: error: EC2Instance can only be filtered by a property",
+		"63:21: error: LegacyResource has no properties, it can only be filtered by its value",
+		"70:17: error: retry.max-attempts must be an integer, not 'five'",
+	}, actual)
+}
+
+func TestValidate_InvalidYAML(t *testing.T) {
+	_, err := Validate([]byte("regions: [us-east-1\n"), testCatalog())
+	assert.Error(t, err)
+}
+
+func TestValidate_Example(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.yaml")
+	require.NoError(t, err)
+
+	catalog := NewCatalog(registry.Registrations{
+		"DynamoDBTable":           {Name: "DynamoDBTable"},
+		"S3Bucket":                {Name: "S3Bucket", Resource: &testS3Bucket{}},
+		"S3Object":                {Name: "S3Object"},
+		"IAMRole":                 {Name: "IAMRole"},
+		"IAMRolePolicyAttachment": {Name: "IAMRolePolicyAttachment"},
+	}, nil)
+
+	issues, err := Validate(data, catalog)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema(testCatalog())
+
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, key := range []string{
+		"regions", "blocklist", "accounts", "presets", "settings", "resource-types", "endpoints",
+		"blocklist-terms", "bypass-alias-check-accounts", "retry",
+	} {
+		assert.Contains(t, properties, key)
+	}
+	assert.NotContains(t, properties, "Log")
+
+	defs := schema["$defs"].(map[string]interface{})
+	assert.Contains(t, defs, "filters")
+	assert.Contains(t, defs, "filter-EC2Instance")
+	assert.Contains(t, defs, "filter")
+	assert.Contains(t, defs, "settings")
+
+	settings := defs["settings"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Contains(t, settings, "EC2Instance")
+	assert.NotContains(t, settings, "S3Bucket")
+}
@@ -0,0 +1,231 @@
+// Package cost estimates what the discovered resources cost per month from a price table, without calling the AWS
+// Pricing API so that it also works without network access. A price table is bundled, it can be replaced per resource
+// type with a user supplied table.
+package cost
+
+import (
+	_ "embed" // the bundled price table
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// HoursPerMonth is the number of hours that hourly prices are multiplied by to get a monthly price
+const HoursPerMonth = 730
+
+// Any is the key of the price that is used when the resource type has no property, or the value is not listed
+const Any = "*"
+
+//go:embed prices.yaml
+var bundled []byte
+
+// Rule is how the price of the resources of a single resource type is determined
+type Rule struct {
+	// Property is the property whose value the price is looked up by, for example InstanceType
+	Property string `yaml:"property"`
+
+	// Quantity is the numeric property that the price is multiplied by, for example the size of a volume in GiB
+	Quantity string `yaml:"quantity"`
+
+	// Hourly are the hourly prices by the value of the property
+	Hourly map[string]float64 `yaml:"hourly"`
+
+	// Monthly are the monthly prices by the value of the property
+	Monthly map[string]float64 `yaml:"monthly"`
+}
+
+// monthly returns the monthly price of a resource with the given properties, false if the price is not known
+func (r *Rule) monthly(props types.Properties) (float64, bool) {
+	key := Any
+	if r.Property != "" {
+		key = props.Get(r.Property)
+	}
+
+	price, ok := r.lookup(key)
+	if !ok && key != Any {
+		price, ok = r.lookup(Any)
+	}
+
+	if !ok {
+		return 0, false
+	}
+
+	if r.Quantity != "" {
+		quantity, err := strconv.ParseFloat(props.Get(r.Quantity), 64)
+		if err != nil {
+			return 0, false
+		}
+
+		price *= quantity
+	}
+
+	return price, true
+}
+
+func (r *Rule) lookup(key string) (float64, bool) {
+	if price, ok := r.Hourly[key]; ok {
+		return price * HoursPerMonth, true
+	}
+
+	price, ok := r.Monthly[key]
+	return price, ok
+}
+
+// PriceTable is the set of rules by resource type
+type PriceTable struct {
+	Currency      string           `yaml:"currency"`
+	ResourceTypes map[string]*Rule `yaml:"resource-types"`
+}
+
+// Bundled returns the price table that is bundled with aws-nuke
+func Bundled() (*PriceTable, error) {
+	return parse(bundled)
+}
+
+// Load returns the bundled price table with the rules of the price table at path added to it. A resource type in the
+// user supplied table replaces the bundled rule for that resource type entirely.
+func Load(path string) (*PriceTable, error) {
+	table, err := Bundled()
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return table, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price table %s: %w", path, err)
+	}
+
+	if custom.Currency != "" {
+		table.Currency = custom.Currency
+	}
+
+	for resourceType, rule := range custom.ResourceTypes {
+		table.ResourceTypes[resourceType] = rule
+	}
+
+	return table, nil
+}
+
+func parse(data []byte) (*PriceTable, error) {
+	table := &PriceTable{}
+	if err := yaml.Unmarshal(data, table); err != nil {
+		return nil, err
+	}
+
+	for resourceType, rule := range table.ResourceTypes {
+		if rule == nil || (len(rule.Hourly) == 0 && len(rule.Monthly) == 0) {
+			return nil, fmt.Errorf("resource type %s has no hourly or monthly prices", resourceType)
+		}
+	}
+
+	if table.ResourceTypes == nil {
+		table.ResourceTypes = make(map[string]*Rule)
+	}
+
+	return table, nil
+}
+
+// Monthly returns the estimated monthly price of the resource, false if the price is not known
+func (t *PriceTable) Monthly(resourceType string, res resource.Resource) (float64, bool) {
+	rule, ok := t.ResourceTypes[resourceType]
+	if !ok {
+		return 0, false
+	}
+
+	getter, ok := res.(resource.PropertyGetter)
+	if !ok {
+		return 0, false
+	}
+
+	return rule.monthly(getter.Properties())
+}
+
+// Estimate is the estimated monthly cost of the resources that are removed
+type Estimate struct {
+	Currency string             `json:"currency"`
+	Total    float64            `json:"total"`
+	ByType   map[string]float64 `json:"by_type"`
+	ByRegion map[string]float64 `json:"by_region"`
+
+	// Priced is the number of resources with a price, Unpriced the number of resources without one
+	Priced   int `json:"priced"`
+	Unpriced int `json:"unpriced"`
+
+	prices map[*queue.Item]float64
+}
+
+// Estimate estimates the monthly cost of the items that are not filtered, which are the resources that would be or
+// were removed
+func (t *PriceTable) Estimate(items []*queue.Item) *Estimate {
+	e := &Estimate{
+		Currency: t.Currency,
+		ByType:   make(map[string]float64),
+		ByRegion: make(map[string]float64),
+		prices:   make(map[*queue.Item]float64),
+	}
+
+	for _, item := range items {
+		if item.GetState() == queue.ItemStateFiltered {
+			continue
+		}
+
+		price, ok := t.Monthly(item.Type, item.Resource)
+		if !ok {
+			e.Unpriced++
+			continue
+		}
+
+		e.Priced++
+		e.Total += price
+		e.ByType[item.Type] += price
+		e.ByRegion[item.Owner] += price
+		e.prices[item] = price
+	}
+
+	return e
+}
+
+// Price returns the estimated monthly price of the item, false if it has no price or was filtered
+func (e *Estimate) Price(item *queue.Item) (float64, bool) {
+	price, ok := e.prices[item]
+	return price, ok
+}
+
+// Amount is the estimated monthly cost of a resource type or region
+type Amount struct {
+	Name    string
+	Monthly float64
+}
+
+// Sorted returns the amounts from the highest to the lowest
+func Sorted(amounts map[string]float64) []Amount {
+	sorted := make([]Amount, 0, len(amounts))
+	for name, monthly := range amounts {
+		sorted = append(sorted, Amount{Name: name, Monthly: monthly})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Monthly != sorted[j].Monthly {
+			return sorted[i].Monthly > sorted[j].Monthly
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
@@ -0,0 +1,132 @@
+package cost
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	props types.Properties
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return r.props
+}
+
+func testItem(resourceType, region string, state queue.ItemState, props types.Properties) *queue.Item {
+	return &queue.Item{Type: resourceType, Owner: region, State: state, Resource: &testResource{props: props}}
+}
+
+func TestBundled(t *testing.T) {
+	table, err := Bundled()
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", table.Currency)
+
+	price, ok := table.Monthly("EC2Instance", &testResource{props: types.NewProperties().Set("InstanceType", "t3.micro")})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.0104*HoursPerMonth, price, 0.001)
+
+	price, ok = table.Monthly("EC2Volume", &testResource{props: types.NewProperties().
+		Set("VolumeType", "gp3").
+		Set("Size", 100)})
+	assert.True(t, ok)
+	assert.InDelta(t, 8.0, price, 0.001)
+
+	price, ok = table.Monthly("EC2NATGateway", &testResource{props: types.NewProperties()})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.045*HoursPerMonth, price, 0.001)
+
+	_, ok = table.Monthly("EC2Instance", &testResource{props: types.NewProperties().Set("InstanceType", "x9.huge")})
+	assert.False(t, ok)
+
+	_, ok = table.Monthly("EC2Volume", &testResource{props: types.NewProperties().Set("VolumeType", "gp3")})
+	assert.False(t, ok, "a volume without a size has no price")
+
+	_, ok = table.Monthly("S3Bucket", &testResource{props: types.NewProperties()})
+	assert.False(t, ok)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+currency: EUR
+resource-types:
+  EC2Instance:
+    property: InstanceType
+    hourly:
+      t3.micro: 0.01
+      "*": 0.5
+  S3Bucket:
+    monthly:
+      "*": 1
+`), 0o600))
+
+	table, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", table.Currency)
+
+	// the custom rule replaces the bundled rule
+	price, ok := table.Monthly("EC2Instance", &testResource{props: types.NewProperties().Set("InstanceType", "m5.large")})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.5*HoursPerMonth, price, 0.001)
+
+	price, ok = table.Monthly("S3Bucket", &testResource{props: types.NewProperties()})
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, price, 0.001)
+
+	// bundled rules for other resource types are kept
+	_, ok = table.Monthly("EC2NATGateway", &testResource{props: types.NewProperties()})
+	assert.True(t, ok)
+
+	assert.NoError(t, os.WriteFile(path, []byte("resource-types:\n  EC2Instance:\n    property: InstanceType\n"), 0o600))
+	_, err = Load(path)
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestEstimate(t *testing.T) {
+	table := &PriceTable{
+		Currency: "USD",
+		ResourceTypes: map[string]*Rule{
+			"EC2NATGateway": {Hourly: map[string]float64{Any: 0.1}},
+			"EC2Volume":     {Quantity: "Size", Monthly: map[string]float64{Any: 0.1}},
+		},
+	}
+
+	nat := testItem("EC2NATGateway", "us-east-1", queue.ItemStateNew, types.NewProperties())
+	volume := testItem("EC2Volume", "eu-west-1", queue.ItemStateNew, types.NewProperties().Set("Size", 50))
+	filtered := testItem("EC2NATGateway", "eu-west-1", queue.ItemStateFiltered, types.NewProperties())
+	unknown := testItem("S3Bucket", "us-east-1", queue.ItemStateNew, types.NewProperties())
+
+	e := table.Estimate([]*queue.Item{nat, volume, filtered, unknown})
+
+	assert.InDelta(t, 78.0, e.Total, 0.001)
+	assert.Equal(t, 2, e.Priced)
+	assert.Equal(t, 1, e.Unpriced)
+	assert.InDelta(t, 73.0, e.ByType["EC2NATGateway"], 0.001)
+	assert.InDelta(t, 5.0, e.ByRegion["eu-west-1"], 0.001)
+
+	price, ok := e.Price(volume)
+	assert.True(t, ok)
+	assert.InDelta(t, 5.0, price, 0.001)
+
+	_, ok = e.Price(filtered)
+	assert.False(t, ok)
+
+	sorted := Sorted(e.ByRegion)
+	assert.Equal(t, "us-east-1", sorted[0].Name)
+	assert.Equal(t, "eu-west-1", sorted[1].Name)
+}
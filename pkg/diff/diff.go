@@ -0,0 +1,151 @@
+// Package diff compares two snapshots of the resources of an account, as stored by the inventory command or by the
+// report of a run, and finds the resources that were added, removed, changed or that came back after a run removed
+// them.
+package diff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind is the kind of difference of a single resource
+type Kind string
+
+const (
+	KindAdded   Kind = "added"
+	KindRemoved Kind = "removed"
+	KindChanged Kind = "changed"
+
+	// KindReturned is a resource that the run of the old snapshot removed, but that exists again in the new one
+	KindReturned Kind = "returned"
+)
+
+// PropertyChange is the difference of a single property, an empty value means the property is not set
+type PropertyChange struct {
+	Property string `json:"property"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+// Difference is the difference of a single resource between the snapshots
+type Difference struct {
+	Kind         Kind             `json:"kind"`
+	Region       string           `json:"region"`
+	ResourceType string           `json:"resource_type"`
+	Identifier   string           `json:"identifier"`
+	Changes      []PropertyChange `json:"changes,omitempty"`
+}
+
+// Diff is the difference between two snapshots of the same account
+type Diff struct {
+	Account     string        `json:"account"`
+	Differences []*Difference `json:"differences"`
+}
+
+// Count returns the number of differences of the given kind
+func (d *Diff) Count(kind Kind) int {
+	count := 0
+	for _, difference := range d.Differences {
+		if difference.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Compare returns the differences between the snapshot before and the one after, sorted by region, resource type and
+// identifier. A resource that a snapshot records as removed by a run counts as not existing, unless the snapshot
+// before recorded it as removed and it exists again after, in which case it has returned.
+func Compare(before, after *Snapshot) (*Diff, error) {
+	if before.Account != "" && after.Account != "" && before.Account != after.Account {
+		return nil, fmt.Errorf("the snapshots are of different accounts, %s and %s", before.Account, after.Account)
+	}
+
+	d := &Diff{
+		Account:     before.Account,
+		Differences: make([]*Difference, 0),
+	}
+	if d.Account == "" {
+		d.Account = after.Account
+	}
+
+	// A key that either snapshot shares between resources is replaced by the full key in both, so that they line up
+	shared := make(map[string]bool)
+	for _, s := range []*Snapshot{before, after} {
+		for key := range s.shared {
+			shared[key] = true
+		}
+	}
+
+	beforeResources, afterResources := before.keyed(shared), after.keyed(shared)
+
+	for key, o := range beforeResources {
+		n, ok := afterResources[key]
+		exists := ok && !n.Removed
+
+		switch {
+		case o.Removed && exists:
+			d.add(KindReturned, n, nil)
+		case o.Removed:
+			continue
+		case !exists:
+			d.add(KindRemoved, o, nil)
+		default:
+			if changes := compareProperties(o.Properties, n.Properties); len(changes) > 0 {
+				d.add(KindChanged, n, changes)
+			}
+		}
+	}
+
+	for key, n := range afterResources {
+		if _, ok := beforeResources[key]; !ok && !n.Removed {
+			d.add(KindAdded, n, nil)
+		}
+	}
+
+	sort.SliceStable(d.Differences, func(i, j int) bool {
+		a, b := d.Differences[i], d.Differences[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Identifier < b.Identifier
+	})
+
+	return d, nil
+}
+
+func (d *Diff) add(kind Kind, r *Resource, changes []PropertyChange) {
+	d.Differences = append(d.Differences, &Difference{
+		Kind:         kind,
+		Region:       r.Region,
+		ResourceType: r.ResourceType,
+		Identifier:   r.Identifier,
+		Changes:      changes,
+	})
+}
+
+// compareProperties returns the properties that differ, sorted by name
+func compareProperties(before, after map[string]string) []PropertyChange {
+	var changes []PropertyChange
+
+	for k, o := range before {
+		if n, ok := after[k]; !ok || n != o {
+			changes = append(changes, PropertyChange{Property: k, Old: o, New: after[k]})
+		}
+	}
+
+	for k, n := range after {
+		if _, ok := before[k]; !ok {
+			changes = append(changes, PropertyChange{Property: k, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Property < changes[j].Property
+	})
+
+	return changes
+}
@@ -0,0 +1,201 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/inventory"
+)
+
+type testResource struct {
+	props types.Properties
+	name  string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return r.props
+}
+
+func (r *testResource) String() string {
+	return r.name
+}
+
+type testPropertiesResource struct {
+	props types.Properties
+}
+
+func (r *testPropertiesResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testPropertiesResource) Properties() types.Properties {
+	return r.props
+}
+
+// writeInventory writes the inventory that follows the run of testdata/report.json in the given format
+func writeInventory(t *testing.T, format inventory.Format) string {
+	t.Helper()
+
+	items := []*queue.Item{
+		{Type: "IAMRole", Owner: "global", Resource: &testResource{
+			name: "admin", props: types.NewProperties().Set("Name", "admin").Set("Path", "/"),
+		}},
+		{Type: "IAMRole", Owner: "global", Resource: &testResource{
+			name: "ci", props: types.NewProperties().Set("Name", "ci").Set("Path", "/"),
+		}},
+		{Type: "EC2Instance", Owner: "us-east-1", Resource: &testPropertiesResource{
+			props: types.NewProperties().Set("Identifier", "i-1").Set("InstanceState", "stopped"),
+		}},
+		{Type: "S3Bucket", Owner: "us-east-1", Resource: &testResource{name: "s3://logs"}},
+		{Type: "SNSTopic", Owner: "us-west-2", Resource: &testPropertiesResource{
+			props: types.NewProperties().Set("TopicARN", "arn:aws:sns:us-west-2:000000000000:alerts"),
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "inventory."+string(format))
+
+	var out bytes.Buffer
+	assert.NoError(t, inventory.Write(&out, inventory.New("000000000000", items), format))
+	assert.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+
+	return path
+}
+
+func TestCompare(t *testing.T) {
+	before, err := Load("testdata/report.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "000000000000", before.Account)
+	assert.Len(t, before.Resources, 6)
+
+	for _, format := range inventory.Formats {
+		t.Run(string(format), func(t *testing.T) {
+			after, err := Load(writeInventory(t, format))
+			assert.NoError(t, err)
+			assert.Len(t, after.Resources, 5)
+
+			d, err := Compare(before, after)
+			assert.NoError(t, err)
+
+			assert.Equal(t, []*Difference{
+				{Kind: KindReturned, Region: "global", ResourceType: "IAMRole", Identifier: "ci"},
+				{
+					Kind: KindChanged, Region: "us-east-1", ResourceType: "EC2Instance",
+					Identifier: "Identifier=i-1,InstanceState=stopped",
+					Changes:    []PropertyChange{{Property: "InstanceState", Old: "running", New: "stopped"}},
+				},
+				{Kind: KindRemoved, Region: "us-east-1", ResourceType: "EC2Instance", Identifier: "Identifier=i-2"},
+				{
+					Kind: KindAdded, Region: "us-west-2", ResourceType: "SNSTopic",
+					Identifier: "TopicARN=arn:aws:sns:us-west-2:000000000000:alerts",
+				},
+			}, d.Differences)
+		})
+	}
+}
+
+func TestCompare_Accounts(t *testing.T) {
+	_, err := Compare(&Snapshot{Account: "000000000000"}, &Snapshot{Account: "111111111111"})
+	assert.Error(t, err)
+}
+
+func TestLoad_ReportCSV(t *testing.T) {
+	s, err := Load("testdata/report.csv")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "000000000000", s.Account)
+	assert.Equal(t, map[string]*Resource{
+		"global|IAMRole|Name=admin": {
+			Region: "global", ResourceType: "IAMRole", Identifier: "admin",
+			Properties: map[string]string{"Name": "admin", "Path": "/"},
+		},
+		"global|IAMRole|Name=ci": {
+			Region: "global", ResourceType: "IAMRole", Identifier: "ci",
+			Properties: map[string]string{"Name": "ci", "Path": "/"}, Removed: true,
+		},
+	}, s.Resources)
+}
+
+func writeSnapshot(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "inventory.ndjson")
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	return path
+}
+
+func TestCompare_Versions(t *testing.T) {
+	// the versions of a layer share their name, the version tells them apart
+	before, err := Load(writeSnapshot(t, `
+{"region": "global", "resource_type": "Layer", "identifier": "lib", "properties": {"Name": "lib", "Version": "1"}}
+{"region": "global", "resource_type": "Layer", "identifier": "lib", "properties": {"Name": "lib", "Version": "2"}}
+{"region": "global", "resource_type": "Layer", "identifier": "other", "properties": {"Name": "other", "Version": "1"}}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, before.Resources, 3)
+
+	// the snapshot after has a single version left, it still lines up with the same version before
+	after, err := Load(writeSnapshot(t, `
+{"region": "global", "resource_type": "Layer", "identifier": "lib", "properties": {"Name": "lib", "Version": "2"}}
+{"region": "global", "resource_type": "Layer", "identifier": "other", "properties": {"Name": "other", "Version": "2"}}
+`))
+	assert.NoError(t, err)
+
+	d, err := Compare(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, d.Count(KindRemoved))
+	assert.Equal(t, 0, d.Count(KindAdded))
+	assert.Equal(t, 1, d.Count(KindChanged))
+	assert.Equal(t, "lib", d.Differences[0].Identifier)
+	assert.Equal(t, KindRemoved, d.Differences[0].Kind)
+	assert.Equal(t, "other", d.Differences[1].Identifier)
+	assert.Equal(t, []PropertyChange{{Property: "Version", Old: "1", New: "2"}}, d.Differences[1].Changes)
+}
+
+func TestLoad_Duplicate(t *testing.T) {
+	_, err := Load(writeSnapshot(t, `
+{"account": "000000000000", "region": "global", "resource_type": "IAMRole", "identifier": "admin"}
+{"account": "000000000000", "region": "global", "resource_type": "IAMRole", "identifier": "admin"}
+`))
+	assert.ErrorContains(t, err, "holds more than one IAMRole admin in global with the same properties")
+}
+
+func TestWrite(t *testing.T) {
+	d := &Diff{
+		Account: "000000000000",
+		Differences: []*Difference{
+			{Kind: KindReturned, Region: "global", ResourceType: "IAMRole", Identifier: "ci"},
+			{
+				Kind: KindChanged, Region: "us-east-1", ResourceType: "EC2Instance", Identifier: "i-1",
+				Changes: []PropertyChange{{Property: "InstanceState", Old: "running", New: "stopped"}},
+			},
+			{Kind: KindRemoved, Region: "us-east-1", ResourceType: "EC2Instance", Identifier: "i-2"},
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(&out, d, FormatText))
+	assert.Equal(t, `global
+  IAMRole
+    ! ci (returned after it was removed)
+us-east-1
+  EC2Instance
+    ~ i-1
+        InstanceState: "running" -> "stopped"
+    - i-2
+
+0 added, 1 removed, 1 changed, 1 returned after they were removed
+`, out.String())
+}
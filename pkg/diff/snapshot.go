@@ -0,0 +1,286 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+)
+
+// Resource is a single resource of a snapshot
+type Resource struct {
+	Region       string
+	ResourceType string
+	Identifier   string
+	Properties   map[string]string
+
+	// Removed is true when the snapshot is the report of a run that removed the resource
+	Removed bool
+}
+
+// id identifies the resource within its region and type by the first of the identity properties it has, otherwise by
+// its name
+func (r *Resource) id() string {
+	if property, value := nuke.IdentityProperty(r.Properties); property != "" {
+		return property + "=" + value
+	}
+
+	return r.Identifier
+}
+
+// key identifies the resource within a snapshot, it is stable across the inventory and the report formats
+func (r *Resource) key() string {
+	return strings.Join([]string{r.Region, r.ResourceType, r.id()}, "|")
+}
+
+// fullKey identifies the resource by all of its properties, for resources that share their key with another one such
+// as the versions of a Lambda layer, which all have the same name
+func (r *Resource) fullKey() string {
+	return strings.Join([]string{r.key(), nuke.JoinProperties(r.Properties)}, "|")
+}
+
+// Snapshot are the resources of an account at a point in time
+type Snapshot struct {
+	Account   string
+	Resources map[string]*Resource
+
+	// shared are the keys that more than one resource of the snapshot has, those resources are keyed by fullKey
+	shared map[string]bool
+}
+
+// keyed returns the resources keyed by fullKey when their key is one of the shared keys, otherwise by their key
+func (s *Snapshot) keyed(shared map[string]bool) map[string]*Resource {
+	resources := make(map[string]*Resource, len(s.Resources))
+	for _, r := range s.Resources {
+		if shared[r.key()] {
+			resources[r.fullKey()] = r
+		} else {
+			resources[r.key()] = r
+		}
+	}
+
+	return resources
+}
+
+// record is a single resource of either an inventory or a run report, they only differ in how they name the resource
+type record struct {
+	Account      string            `json:"account"`
+	Region       string            `json:"region"`
+	ResourceType string            `json:"resource_type"`
+	Identifier   string            `json:"identifier"`
+	Name         string            `json:"name"`
+	Properties   map[string]string `json:"properties"`
+	State        report.State      `json:"state"`
+}
+
+// Load reads a snapshot from an inventory, in any of its formats, or from the report of a run, in any of its formats
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	s := &Snapshot{
+		Resources: make(map[string]*Resource, len(records)),
+		shared:    make(map[string]bool),
+	}
+
+	resources := make([]*Resource, 0, len(records))
+	keys := make(map[string]int, len(records))
+	for _, rec := range records {
+		if s.Account == "" {
+			s.Account = rec.Account
+		} else if rec.Account != "" && rec.Account != s.Account {
+			return nil, fmt.Errorf("%s holds resources of more than one account", path)
+		}
+
+		r := &Resource{
+			Region:       rec.Region,
+			ResourceType: rec.ResourceType,
+			Identifier:   rec.Identifier,
+			Properties:   rec.Properties,
+			Removed:      rec.State == report.StateRemoved,
+		}
+		if r.Identifier == "" {
+			r.Identifier = rec.Name
+		}
+		if r.Identifier == "" {
+			r.Identifier = r.id()
+		}
+
+		resources = append(resources, r)
+		keys[r.key()]++
+	}
+
+	// Resources that share their key, such as the versions of a Lambda layer, are told apart by all of their properties
+	for _, r := range resources {
+		key := r.key()
+		if keys[key] > 1 {
+			s.shared[key] = true
+			key = r.fullKey()
+		}
+
+		if _, ok := s.Resources[key]; ok {
+			return nil, fmt.Errorf("%s holds more than one %s %s in %s with the same properties",
+				path, r.ResourceType, r.id(), r.Region)
+		}
+
+		s.Resources[key] = r
+	}
+
+	return s, nil
+}
+
+// parse detects the format of the data: the JSON report of a run, the columnar inventory, NDJSON of either, or CSV
+// of either
+func parse(data []byte) ([]*record, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if trimmed[0] != '{' {
+		return parseCSV(trimmed)
+	}
+
+	var document struct {
+		Resources []*record `json:"resources"`
+		Rows      *int      `json:"rows"`
+
+		Account      []string            `json:"account"`
+		Region       []string            `json:"region"`
+		ResourceType []string            `json:"resource_type"`
+		Identifier   []string            `json:"identifier"`
+		Properties   []map[string]string `json:"properties"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	if err := dec.Decode(&document); err == nil && !dec.More() {
+		switch {
+		case document.Resources != nil:
+			return document.Resources, nil
+		case document.Rows != nil:
+			return columnsToRecords(document.Rows, document.Account, document.Region, document.ResourceType,
+				document.Identifier, document.Properties)
+		}
+	}
+
+	return parseNDJSON(trimmed)
+}
+
+func columnsToRecords(
+	rows *int, account, region, resourceType, identifier []string, properties []map[string]string,
+) ([]*record, error) {
+	for _, column := range [][]string{account, region, resourceType, identifier} {
+		if len(column) != *rows {
+			return nil, fmt.Errorf("the columns do not all have %d rows", *rows)
+		}
+	}
+
+	records := make([]*record, 0, *rows)
+	for i := 0; i < *rows; i++ {
+		rec := &record{
+			Account:      account[i],
+			Region:       region[i],
+			ResourceType: resourceType[i],
+			Identifier:   identifier[i],
+		}
+		if i < len(properties) {
+			rec.Properties = properties[i]
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+func parseNDJSON(data []byte) ([]*record, error) {
+	var records []*record
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		rec := &record{}
+		if err := json.Unmarshal(text, rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+func parseCSV(data []byte) ([]*record, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	for _, required := range []string{"region", "resource_type"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the csv header has no %s column", required)
+		}
+	}
+
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []*record
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec := &record{
+			Account:      value(row, "account"),
+			Region:       value(row, "region"),
+			ResourceType: value(row, "resource_type"),
+			Identifier:   value(row, "identifier"),
+			Name:         value(row, "name"),
+			State:        report.State(value(row, "state")),
+		}
+
+		if props := value(row, "properties"); props != "" {
+			if err := json.Unmarshal([]byte(props), &rec.Properties); err != nil {
+				return nil, fmt.Errorf("properties of %s %s: %w", rec.ResourceType, rec.Identifier, err)
+			}
+		}
+
+		records = append(records, rec)
+	}
+}
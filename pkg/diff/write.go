@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is the output format of a diff
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Formats is the list of supported diff formats
+var Formats = []Format{FormatText, FormatJSON}
+
+// ParseFormat converts a string into a Format, returning an error if the format is not supported
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported diff format '%s', must be one of: text, json", s)
+}
+
+// symbols mark the kind of difference of every resource in the text format
+var symbols = map[Kind]string{
+	KindAdded:    "+",
+	KindRemoved:  "-",
+	KindChanged:  "~",
+	KindReturned: "!",
+}
+
+// Write writes the diff to the writer in the given format
+func Write(w io.Writer, d *Diff, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case FormatText:
+		return writeText(w, d)
+	default:
+		return fmt.Errorf("unsupported diff format '%s'", format)
+	}
+}
+
+// writeText writes the differences grouped by region and resource type, followed by a summary
+func writeText(w io.Writer, d *Diff) error {
+	var b strings.Builder
+
+	region, resourceType := "", ""
+	for i, difference := range d.Differences {
+		if i == 0 || difference.Region != region {
+			region, resourceType = difference.Region, ""
+			fmt.Fprintf(&b, "%s\n", region)
+		}
+
+		if difference.ResourceType != resourceType {
+			resourceType = difference.ResourceType
+			fmt.Fprintf(&b, "  %s\n", resourceType)
+		}
+
+		fmt.Fprintf(&b, "    %s %s", symbols[difference.Kind], difference.Identifier)
+		if difference.Kind == KindReturned {
+			b.WriteString(" (returned after it was removed)")
+		}
+		b.WriteString("\n")
+
+		for _, change := range difference.Changes {
+			fmt.Fprintf(&b, "        %s: %q -> %q\n", change.Property, change.Old, change.New)
+		}
+	}
+
+	if len(d.Differences) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed, %d returned after they were removed\n",
+		d.Count(KindAdded), d.Count(KindRemoved), d.Count(KindChanged), d.Count(KindReturned))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
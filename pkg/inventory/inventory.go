@@ -0,0 +1,221 @@
+// Package inventory provides an export of every resource that was listed in an account, with their properties. It has
+// no notion of removing resources, it only records what exists.
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// Format is the output format of the inventory
+type Format string
+
+const (
+	FormatNDJSON   Format = "ndjson"
+	FormatCSV      Format = "csv"
+	FormatColumnar Format = "columnar"
+)
+
+// Formats is the list of supported inventory formats
+var Formats = []Format{FormatNDJSON, FormatCSV, FormatColumnar}
+
+// ParseFormat converts a string into a Format, returning an error if the format is not supported
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported inventory format '%s', must be one of: ndjson, csv, columnar", s)
+}
+
+// Record is a single resource of the inventory
+type Record struct {
+	Account      string            `json:"account"`
+	Region       string            `json:"region"`
+	ResourceType string            `json:"resource_type"`
+	Identifier   string            `json:"identifier"`
+	Properties   map[string]string `json:"properties,omitempty"`
+
+	// Refused is the reason the resource itself refused to be removed when the inventory was taken, such as a default
+	// VPC or a service-linked role. It depends on the settings that were applied to the resource at that time.
+	Refused string `json:"refused,omitempty"`
+}
+
+// New creates the records of the items, sorted by region, resource type and identifier. Internal properties, those
+// starting with an underscore, are left out. The settings of the resources must be applied before the records are
+// created, the resources that refuse to be removed record their reason.
+func New(accountID string, items []*queue.Item) []*Record {
+	records := make([]*Record, 0, len(items))
+
+	for _, item := range items {
+		rec := &Record{
+			Account:      accountID,
+			Region:       item.Owner,
+			ResourceType: item.Type,
+			Identifier:   nuke.ResourceIdentifier(item),
+		}
+
+		if f, ok := item.Resource.(resource.Filter); ok {
+			if err := f.Filter(); err != nil {
+				rec.Refused = err.Error()
+			}
+		}
+
+		if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+			for k, v := range getter.Properties() {
+				if strings.HasPrefix(k, "_") {
+					continue
+				}
+
+				if rec.Properties == nil {
+					rec.Properties = make(map[string]string)
+				}
+				rec.Properties[k] = v
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Identifier < b.Identifier
+	})
+
+	return records
+}
+
+// Filter returns the items that a run would remove: the items that the protect tag, which may be nil, does not protect,
+// whose resource does not refuse to be removed and that do not match any of the filters. The settings of the
+// resources must be applied before they are filtered.
+func Filter(
+	items []*queue.Item, filters filter.Filters, useGroups bool, protectTag *nuke.ProtectTag,
+) ([]*queue.Item, error) {
+	kept := make([]*queue.Item, 0, len(items))
+
+	for _, item := range items {
+		if protectTag != nil && protectTag.Protected(item.Resource) {
+			continue
+		}
+
+		if f, ok := item.Resource.(resource.Filter); ok {
+			if err := f.Filter(); err != nil {
+				continue
+			}
+		}
+
+		matches, err := nuke.MatchFilters(filters, item, useGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept, nil
+}
+
+// Write writes the records to the writer in the given format
+func Write(w io.Writer, records []*Record, format Format) error {
+	switch format {
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, records)
+	case FormatColumnar:
+		return writeColumnar(w, records)
+	default:
+		return fmt.Errorf("unsupported inventory format '%s'", format)
+	}
+}
+
+// csvHeader is the header row of the CSV format, properties are encoded as a JSON object in a single column
+var csvHeader = []string{"account", "region", "resource_type", "identifier", "properties", "refused"}
+
+func writeCSV(w io.Writer, records []*Record) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		props := ""
+		if len(rec.Properties) > 0 {
+			data, err := json.Marshal(rec.Properties)
+			if err != nil {
+				return err
+			}
+			props = string(data)
+		}
+
+		row := []string{rec.Account, rec.Region, rec.ResourceType, rec.Identifier, props, rec.Refused}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// columns is the columnar form of the records, every column holds the value of each record at the same index, the
+// way columnar formats such as Parquet lay out their data
+type columns struct {
+	Rows         int                 `json:"rows"`
+	Account      []string            `json:"account"`
+	Region       []string            `json:"region"`
+	ResourceType []string            `json:"resource_type"`
+	Identifier   []string            `json:"identifier"`
+	Properties   []map[string]string `json:"properties"`
+	Refused      []string            `json:"refused"`
+}
+
+func writeColumnar(w io.Writer, records []*Record) error {
+	cols := &columns{
+		Rows:         len(records),
+		Account:      make([]string, 0, len(records)),
+		Region:       make([]string, 0, len(records)),
+		ResourceType: make([]string, 0, len(records)),
+		Identifier:   make([]string, 0, len(records)),
+		Properties:   make([]map[string]string, 0, len(records)),
+		Refused:      make([]string, 0, len(records)),
+	}
+
+	for _, rec := range records {
+		cols.Account = append(cols.Account, rec.Account)
+		cols.Region = append(cols.Region, rec.Region)
+		cols.ResourceType = append(cols.ResourceType, rec.ResourceType)
+		cols.Identifier = append(cols.Identifier, rec.Identifier)
+		cols.Properties = append(cols.Properties, rec.Properties)
+		cols.Refused = append(cols.Refused, rec.Refused)
+	}
+
+	return json.NewEncoder(w).Encode(cols)
+}
@@ -0,0 +1,222 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	name    string
+	managed bool
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.name).Set("_internal", "hidden")
+}
+
+func (r *testResource) String() string {
+	return r.name
+}
+
+func (r *testResource) Filter() error {
+	if r.managed {
+		return errors.New("cannot delete managed resource")
+	}
+
+	return nil
+}
+
+type testLegacyResource struct {
+	name string
+}
+
+func (r *testLegacyResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLegacyResource) String() string {
+	return r.name
+}
+
+type testPropertiesResource struct {
+	name string
+}
+
+func (r *testPropertiesResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testPropertiesResource) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.name)
+}
+
+func testItems() []*queue.Item {
+	return []*queue.Item{
+		{Type: "IAMRole", Owner: "global", Resource: &testResource{name: "web"}},
+		{Type: "EC2Instance", Owner: "us-east-1", Resource: &testResource{name: "i-1"}},
+		{Type: "IAMRole", Owner: "global", Resource: &testResource{name: "admin"}},
+		{Type: "S3Bucket", Owner: "global", Resource: &testLegacyResource{name: "s3://logs"}},
+		{Type: "IAMRole", Owner: "global", Resource: &testResource{name: "sso", managed: true}},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	_, err = ParseFormat("parquet")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	records := New("000000000000", testItems())
+
+	assert.Equal(t, []*Record{
+		{
+			Account: "000000000000", Region: "global", ResourceType: "IAMRole", Identifier: "admin",
+			Properties: map[string]string{"Name": "admin"},
+		},
+		{
+			Account: "000000000000", Region: "global", ResourceType: "IAMRole", Identifier: "sso",
+			Properties: map[string]string{"Name": "sso"}, Refused: "cannot delete managed resource",
+		},
+		{
+			Account: "000000000000", Region: "global", ResourceType: "IAMRole", Identifier: "web",
+			Properties: map[string]string{"Name": "web"},
+		},
+		{Account: "000000000000", Region: "global", ResourceType: "S3Bucket", Identifier: "s3://logs"},
+		{
+			Account: "000000000000", Region: "us-east-1", ResourceType: "EC2Instance", Identifier: "i-1",
+			Properties: map[string]string{"Name": "i-1"},
+		},
+	}, records)
+}
+
+func TestFilter(t *testing.T) {
+	filters := filter.Filters{
+		"IAMRole": {{Property: "Name", Type: filter.Exact, Value: "admin"}},
+	}
+
+	kept, err := Filter(testItems(), filters, false, nil)
+	assert.NoError(t, err)
+
+	var identifiers []string
+	for _, rec := range New("000000000000", kept) {
+		identifiers = append(identifiers, rec.Identifier)
+	}
+	assert.Equal(t, []string{"web", "s3://logs", "i-1"}, identifiers)
+}
+
+func TestWrite(t *testing.T) {
+	records := New("000000000000", testItems()[:2])
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(&out, records, FormatNDJSON))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var first Record
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, *records[0], first)
+
+	out.Reset()
+	assert.NoError(t, Write(&out, records, FormatCSV))
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		csvHeader,
+		{"000000000000", "global", "IAMRole", "web", `{"Name":"web"}`, ""},
+		{"000000000000", "us-east-1", "EC2Instance", "i-1", `{"Name":"i-1"}`, ""},
+	}, rows)
+
+	out.Reset()
+	assert.NoError(t, Write(&out, records, FormatColumnar))
+
+	var cols map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &cols))
+	assert.Equal(t, float64(2), cols["rows"])
+	assert.Equal(t, []interface{}{"global", "us-east-1"}, cols["region"])
+	assert.Equal(t, []interface{}{"web", "i-1"}, cols["identifier"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"Name": "web"},
+		map[string]interface{}{"Name": "i-1"},
+	}, cols["properties"])
+}
+
+func TestLoad(t *testing.T) {
+	records := New("000000000000", testItems())
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			assert.NoError(t, Write(&out, records, format))
+
+			path := filepath.Join(t.TempDir(), "inventory")
+			assert.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+
+			loaded, err := Load(path)
+			assert.NoError(t, err)
+			assert.Equal(t, records, loaded)
+		})
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	registry.Register(&registry.Registration{
+		Name:     "TestInventoryResource",
+		Resource: &testResource{},
+	})
+	registry.Register(&registry.Registration{
+		Name:     "TestInventoryPropertiesResource",
+		Resource: &testPropertiesResource{},
+	})
+
+	rec := &Record{
+		ResourceType: "TestInventoryResource",
+		Identifier:   "sso",
+		Properties:   map[string]string{"Name": "sso"},
+		Refused:      "cannot delete managed resource",
+	}
+
+	r := NewResource(rec)
+	assert.Equal(t, "sso", r.(resource.LegacyStringer).String())
+	assert.Equal(t, "sso", r.(resource.PropertyGetter).Properties().Get("Name"))
+	assert.EqualError(t, r.(resource.Filter).Filter(), "cannot delete managed resource")
+	assert.ErrorIs(t, r.Remove(context.Background()), ErrOffline)
+
+	rec = &Record{ResourceType: "TestInventoryPropertiesResource", Identifier: "Name=web"}
+	r = NewResource(rec)
+	_, ok := r.(resource.LegacyStringer)
+	assert.False(t, ok)
+	assert.NoError(t, r.(resource.Filter).Filter())
+
+	byType := Resources([]*Record{
+		{Region: "global", ResourceType: "IAMRole", Identifier: "admin"},
+		{Region: "us-east-1", ResourceType: "EC2Instance", Identifier: "i-1"},
+	}, "global", []string{"IAMRole", "EC2Instance"})
+	assert.Len(t, byType, 2)
+	assert.Len(t, byType["IAMRole"], 1)
+	assert.Empty(t, byType["EC2Instance"])
+}
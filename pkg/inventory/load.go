@@ -0,0 +1,158 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Load reads the records of an inventory in any of its formats, the format is detected from the content of the file
+func Load(path string) ([]*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parse(bytes.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse inventory %s: %w", path, err)
+	}
+
+	return records, nil
+}
+
+// parse detects the format of the data: a single JSON object with rows is columnar, other JSON is NDJSON and
+// anything else is CSV
+func parse(data []byte) ([]*Record, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] != '{' {
+		return parseCSV(data)
+	}
+
+	var probe struct {
+		Rows *int `json:"rows"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&probe); err == nil && !dec.More() && probe.Rows != nil {
+		cols := &columns{}
+		if err := json.Unmarshal(data, cols); err != nil {
+			return nil, err
+		}
+		return parseColumnar(cols)
+	}
+
+	return parseNDJSON(data)
+}
+
+func parseNDJSON(data []byte) ([]*Record, error) {
+	var records []*Record
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		rec := &Record{}
+		if err := json.Unmarshal(text, rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+func parseCSV(data []byte) ([]*Record, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+
+	for _, required := range []string{"account", "region", "resource_type", "identifier"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("the csv header has no %s column", required)
+		}
+	}
+
+	value := func(row []string, name string) string {
+		if i, ok := index[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []*Record
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec := &Record{
+			Account:      value(row, "account"),
+			Region:       value(row, "region"),
+			ResourceType: value(row, "resource_type"),
+			Identifier:   value(row, "identifier"),
+			Refused:      value(row, "refused"),
+		}
+
+		if props := value(row, "properties"); props != "" {
+			if err := json.Unmarshal([]byte(props), &rec.Properties); err != nil {
+				return nil, fmt.Errorf("properties of %s %s: %w", rec.ResourceType, rec.Identifier, err)
+			}
+		}
+
+		records = append(records, rec)
+	}
+}
+
+func parseColumnar(cols *columns) ([]*Record, error) {
+	for _, column := range [][]string{cols.Account, cols.Region, cols.ResourceType, cols.Identifier} {
+		if len(column) != cols.Rows {
+			return nil, fmt.Errorf("the columns do not all have %d rows", cols.Rows)
+		}
+	}
+
+	records := make([]*Record, 0, cols.Rows)
+	for i := 0; i < cols.Rows; i++ {
+		rec := &Record{
+			Account:      cols.Account[i],
+			Region:       cols.Region[i],
+			ResourceType: cols.ResourceType[i],
+			Identifier:   cols.Identifier[i],
+		}
+		if i < len(cols.Properties) {
+			rec.Properties = cols.Properties[i]
+		}
+		if i < len(cols.Refused) {
+			rec.Refused = cols.Refused[i]
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// ErrOffline is returned when a resource of an inventory is removed, they only exist as a record
+var ErrOffline = errors.New("the resource was loaded from an inventory and cannot be removed")
+
+// Resource is a resource of a previously captured inventory. It has the properties the resource had when the
+// inventory was taken and refuses to be removed for the same reason, it is used to evaluate the filters of a run
+// without access to the account.
+type Resource struct {
+	Record *Record
+}
+
+func (r *Resource) Remove(_ context.Context) error {
+	return ErrOffline
+}
+
+func (r *Resource) Properties() types.Properties {
+	props := types.NewProperties()
+	for k, v := range r.Record.Properties {
+		props.Set(k, v)
+	}
+
+	return props
+}
+
+func (r *Resource) Filter() error {
+	if r.Record.Refused != "" {
+		return errors.New(r.Record.Refused)
+	}
+
+	return nil
+}
+
+// StringResource is a Resource of a resource type that has a legacy string ID, the identifier of the record. Filters
+// without a property match against it, the same as they would against the listed resource.
+type StringResource struct {
+	Resource
+}
+
+func (r *StringResource) String() string {
+	return r.Record.Identifier
+}
+
+// NewResource creates the resource of the record. Its resource type decides whether the resource has a legacy string
+// ID, unknown resource types are assumed to have one.
+func NewResource(rec *Record) resource.Resource {
+	if reg := registry.GetRegistration(rec.ResourceType); reg != nil && reg.Resource != nil {
+		if _, ok := reg.Resource.(resource.LegacyStringer); !ok {
+			return &Resource{Record: rec}
+		}
+	}
+
+	return &StringResource{Resource{Record: rec}}
+}
+
+// Resources returns the resources of the records that are in the region, for each of the resource types. Every
+// resource type has an entry, those without any records an empty one.
+func Resources(records []*Record, region string, resourceTypes []string) map[string][]resource.Resource {
+	byType := make(map[string][]resource.Resource, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		byType[resourceType] = make([]resource.Resource, 0)
+	}
+
+	for _, rec := range records {
+		if _, ok := byType[rec.ResourceType]; ok && rec.Region == region {
+			byType[rec.ResourceType] = append(byType[rec.ResourceType], NewResource(rec))
+		}
+	}
+
+	return byType
+}
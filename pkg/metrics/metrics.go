@@ -0,0 +1,240 @@
+// Package metrics provides counters and gauges for long-running nukes, exposed in the Prometheus text format so they
+// can be scraped while a run is in progress. It intentionally only implements the small subset of the exposition
+// format that aws-nuke needs.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type metricType string
+
+const (
+	typeCounter metricType = "counter"
+	typeGauge   metricType = "gauge"
+)
+
+// Registry holds a set of metric families and renders them
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type family struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	kind   metricType
+	labels []string
+	values map[string]*sample
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+func (r *Registry) register(name, help string, kind metricType, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.families {
+		if f.name == name {
+			panic(fmt.Sprintf("metric %s is already registered", name))
+		}
+	}
+
+	f := &family{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		values: make(map[string]*sample),
+	}
+	r.families = append(r.families, f)
+
+	return f
+}
+
+func (f *family) get(labelValues []string) *sample {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string{}, labelValues...)}
+		f.values[key] = s
+	}
+
+	return s
+}
+
+// CounterVec is a counter partitioned by a set of labels
+type CounterVec struct {
+	f *family
+}
+
+// NewCounterVec registers a new counter, by convention the name should end in _total
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{f: r.register(name, help, typeCounter, labels)}
+}
+
+// Inc increments the counter for the given label values by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values, negative values are ignored
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	c.f.get(labelValues).value += v
+}
+
+// Value returns the current value of the counter for the given label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	return c.f.get(labelValues).value
+}
+
+// GaugeVec is a gauge partitioned by a set of labels
+type GaugeVec struct {
+	f *family
+}
+
+// NewGaugeVec registers a new gauge
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{f: r.register(name, help, typeGauge, labels)}
+}
+
+// Set sets the gauge for the given label values
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.get(labelValues).value = v
+}
+
+// Value returns the current value of the gauge for the given label values
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	return g.f.get(labelValues).value
+}
+
+// Reset sets every sample of the gauge whose leading label values match the given values to zero. Samples are
+// kept instead of removed so that a value dropping to zero is visible to the scraper.
+func (g *GaugeVec) Reset(leadingLabelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	for _, s := range g.f.values {
+		match := true
+		for i, v := range leadingLabelValues {
+			if i >= len(s.labelValues) || s.labelValues[i] != v {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			s.value = 0
+		}
+	}
+}
+
+// Write renders every metric family in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family{}, r.families...)
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *family) write(w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(f.values))
+	for k := range f.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.values[k]
+
+		labels := make([]string, len(f.labels))
+		for i, l := range f.labels {
+			labels[i] = fmt.Sprintf(`%s="%s"`, l, escapeLabelValue(s.labelValues[i]))
+		}
+
+		name := f.name
+		if len(labels) > 0 {
+			name = fmt.Sprintf("%s{%s}", f.name, strings.Join(labels, ","))
+		}
+
+		if _, err := fmt.Fprintf(w, "%s %s\n", name, strconv.FormatFloat(s.value, 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler returns a http.Handler that serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.Write(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+
+	counter := r.NewCounterVec("test_total", "A test counter.", "type", "region")
+	counter.Inc("Bucket", "us-east-1")
+	counter.Add(2, "Bucket", "us-east-1")
+	counter.Add(-1, "Bucket", "us-east-1")
+	counter.Inc("Quote\"d", "global")
+
+	gauge := r.NewGaugeVec("a_gauge", "A test gauge.")
+	gauge.Set(4.5)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf))
+
+	assert.Equal(t, `# HELP a_gauge A test gauge.
+# TYPE a_gauge gauge
+a_gauge 4.5
+# HELP test_total A test counter.
+# TYPE test_total counter
+test_total{type="Bucket",region="us-east-1"} 3
+test_total{type="Quote\"d",region="global"} 1
+`, buf.String())
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "A test counter.")
+
+	assert.Panics(t, func() {
+		r.NewGaugeVec("test_total", "A test gauge.")
+	})
+}
+
+func TestGaugeVec_Reset(t *testing.T) {
+	r := NewRegistry()
+	gauge := r.NewGaugeVec("items", "Items.", "account", "state")
+	gauge.Set(3, "111", "waiting")
+	gauge.Set(2, "222", "waiting")
+
+	gauge.Reset("111")
+
+	assert.Equal(t, float64(0), gauge.Value("111", "waiting"))
+	assert.Equal(t, float64(2), gauge.Value("222", "waiting"))
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "A test counter.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "test_total 1\n")
+}
@@ -0,0 +1,45 @@
+package metrics
+
+// Default is the registry that all aws-nuke metrics are registered with
+var Default = NewRegistry()
+
+// Resource states used with the ResourcesTotal counter
+const (
+	ResourceDiscovered = "discovered"
+	ResourceFiltered   = "filtered"
+	ResourceRemoved    = "removed"
+	ResourceFailed     = "failed"
+	ResourceProtected  = "protected"
+)
+
+var (
+	// ResourcesTotal counts resources as they are discovered, filtered, removed or fail to be removed
+	ResourcesTotal = Default.NewCounterVec("aws_nuke_resources_total",
+		"Number of resources by account, resource type, region and state.",
+		"account", "resource_type", "region", "state")
+
+	// QueueItems is the number of items in the queue by state, it is updated after the scan and every iteration
+	QueueItems = Default.NewGaugeVec("aws_nuke_queue_items",
+		"Number of items in the queue by account, resource type, region and state.",
+		"account", "resource_type", "region", "state")
+
+	// RunIteration is the current iteration of the removal loop
+	RunIteration = Default.NewGaugeVec("aws_nuke_run_iteration",
+		"Current iteration of the removal loop.",
+		"account")
+
+	// LastProgress is the unix time at which a resource was last removed, or the scan completed
+	LastProgress = Default.NewGaugeVec("aws_nuke_last_progress_timestamp_seconds",
+		"Unix time at which the scan completed or a resource was last removed.",
+		"account")
+
+	// APICallsTotal counts every attempt to call an AWS API
+	APICallsTotal = Default.NewCounterVec("aws_nuke_api_calls_total",
+		"Number of AWS API call attempts by service and operation.",
+		"service", "operation")
+
+	// APIThrottlesTotal counts the AWS API calls that were throttled
+	APIThrottlesTotal = Default.NewCounterVec("aws_nuke_api_throttles_total",
+		"Number of throttled AWS API calls by service and operation.",
+		"service", "operation")
+)
@@ -0,0 +1,299 @@
+// Package notify posts a summary of a run to webhooks, such as Slack or Microsoft Teams incoming webhooks or any
+// HTTP endpoint that accepts JSON. Failing to deliver a notification never fails the run, it is only logged.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/report"
+)
+
+// EventType is the moment in the run a notification is sent for
+type EventType string
+
+const (
+	// EventStart is sent before the account is validated and scanned
+	EventStart EventType = "start"
+
+	// EventPrompt is sent once the final prompt has been passed, for a live run this is right before the removal
+	EventPrompt EventType = "prompt"
+
+	// EventFinish is sent when the run completed without an error
+	EventFinish EventType = "finish"
+
+	// EventFail is sent when the run failed
+	EventFail EventType = "fail"
+)
+
+// EventTypes are all supported event types
+var EventTypes = []EventType{EventStart, EventPrompt, EventFinish, EventFail}
+
+// Format is the format of the payload that is posted to a webhook
+type Format string
+
+const (
+	// FormatJSON posts the event as JSON, or the rendered template if one is configured
+	FormatJSON Format = "json"
+
+	// FormatSlack posts a message to a Slack incoming webhook
+	FormatSlack Format = "slack"
+
+	// FormatTeams posts a message card to a Microsoft Teams incoming webhook
+	FormatTeams Format = "teams"
+)
+
+// Formats are all supported payload formats
+var Formats = []Format{FormatJSON, FormatSlack, FormatTeams}
+
+// maxTopFailures is the number of resource types that are included in TopFailures
+const maxTopFailures = 5
+
+// defaultTemplate is the message that is posted to Slack and Teams unless a template is configured
+const defaultTemplate = `aws-nuke {{ .Event }}: account {{ .AccountID }}{{ with .AccountAlias }} ({{ . }}){{ end }}` +
+	`{{ if .DryRun }} [dry run]{{ end }}
+{{- with .Regions }}
+Regions: {{ join . ", " }}{{ end }}
+{{- with .Counts }}
+Resources:{{ range $state, $count := . }} {{ $count }} {{ $state }};{{ end }}{{ end }}
+{{- with .TopFailures }}
+Top failing resource types:{{ range . }} {{ .ResourceType }} ({{ .Count }});{{ end }}{{ end }}
+{{- if .DurationSeconds }}
+Duration: {{ .Duration }}{{ end }}
+{{- with .Error }}
+Error: {{ . }}{{ end }}`
+
+// TypeCount is the number of resources of a resource type
+type TypeCount struct {
+	ResourceType string `json:"resource_type"`
+	Count        int    `json:"count"`
+}
+
+// Event is the summary of the run that is sent to the webhooks
+type Event struct {
+	Event           EventType      `json:"event"`
+	AccountID       string         `json:"account_id"`
+	AccountAlias    string         `json:"account_alias,omitempty"`
+	Regions         []string       `json:"regions,omitempty"`
+	DryRun          bool           `json:"dry_run"`
+	Counts          map[string]int `json:"counts,omitempty"`
+	TopFailures     []TypeCount    `json:"top_failures,omitempty"`
+	Duration        string         `json:"duration,omitempty"`
+	DurationSeconds float64        `json:"duration_seconds,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Time            time.Time      `json:"time"`
+}
+
+// SetDuration sets the duration of the run so far
+func (e *Event) SetDuration(d time.Duration) {
+	e.Duration = d.Round(time.Second).String()
+	e.DurationSeconds = d.Seconds()
+}
+
+// SetQueue sets the number of resources per state and the resource types with the most failures from the queue
+func (e *Event) SetQueue(items []*queue.Item) {
+	e.Counts = make(map[string]int)
+	failures := make(map[string]int)
+
+	for _, item := range items {
+		state := report.StateOf(item)
+		e.Counts[string(state)]++
+
+		if state == report.StateFailed {
+			failures[item.Type]++
+		}
+	}
+
+	e.TopFailures = make([]TypeCount, 0, len(failures))
+	for resourceType, count := range failures {
+		e.TopFailures = append(e.TopFailures, TypeCount{ResourceType: resourceType, Count: count})
+	}
+
+	sort.Slice(e.TopFailures, func(i, j int) bool {
+		if e.TopFailures[i].Count != e.TopFailures[j].Count {
+			return e.TopFailures[i].Count > e.TopFailures[j].Count
+		}
+		return e.TopFailures[i].ResourceType < e.TopFailures[j].ResourceType
+	})
+
+	if len(e.TopFailures) > maxTopFailures {
+		e.TopFailures = e.TopFailures[:maxTopFailures]
+	}
+}
+
+type target struct {
+	url      string
+	headers  map[string]string
+	format   Format
+	events   []EventType
+	template *template.Template
+}
+
+// Notifier sends events to the configured webhooks
+type Notifier struct {
+	targets []*target
+	client  *http.Client
+	log     *logrus.Entry
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// New validates the notification configuration and returns a Notifier for it
+func New(notifications []*config.Notification, logger *logrus.Entry) (*Notifier, error) {
+	n := &Notifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		log:    logger,
+	}
+
+	for i, cfg := range notifications {
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("notification %d: url is required", i)
+		}
+
+		t := &target{
+			url:     cfg.URL,
+			headers: cfg.Headers,
+			format:  Format(strings.ToLower(cfg.Format)),
+			events:  EventTypes,
+		}
+
+		if t.format == "" {
+			t.format = FormatJSON
+		}
+
+		if !slices.Contains(Formats, t.format) {
+			return nil, fmt.Errorf("notification %d: unsupported format '%s', must be one of: %v", i, cfg.Format, Formats)
+		}
+
+		if len(cfg.Events) > 0 {
+			t.events = nil
+			for _, e := range cfg.Events {
+				if !slices.Contains(EventTypes, EventType(e)) {
+					return nil, fmt.Errorf("notification %d: unsupported event '%s', must be one of: %v", i, e, EventTypes)
+				}
+				t.events = append(t.events, EventType(e))
+			}
+		}
+
+		tmpl := cfg.Template
+		if tmpl == "" && t.format != FormatJSON {
+			tmpl = defaultTemplate
+		}
+
+		if tmpl != "" {
+			var err error
+			t.template, err = template.New("notification").Funcs(templateFuncs).Parse(tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("notification %d: invalid template: %w", i, err)
+			}
+		}
+
+		n.targets = append(n.targets, t)
+	}
+
+	return n, nil
+}
+
+// Send posts the event to every webhook that is interested in it. Errors are logged and otherwise ignored.
+func (n *Notifier) Send(ctx context.Context, event *Event) {
+	if n == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	for _, t := range n.targets {
+		if !slices.Contains(t.events, event.Event) {
+			continue
+		}
+
+		if err := n.send(ctx, t, event); err != nil {
+			n.log.WithError(err).Warnf("unable to send %s notification", event.Event)
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, t *target, event *Event) error {
+	payload, err := Render(t.format, t.template, event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// Render renders the payload of the event for the given format. For the json format the template, if set, renders the
+// entire payload, otherwise the event itself is the payload. For all other formats the template renders the message.
+func Render(format Format, tmpl *template.Template, event *Event) ([]byte, error) {
+	var message string
+	if tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, event); err != nil {
+			return nil, fmt.Errorf("unable to render template: %w", err)
+		}
+		message = buf.String()
+	}
+
+	switch format {
+	case FormatSlack:
+		return json.Marshal(map[string]string{
+			"text": message,
+		})
+	case FormatTeams:
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  fmt.Sprintf("aws-nuke %s: account %s", event.Event, event.AccountID),
+			"text":     strings.ReplaceAll(message, "\n", "\n\n"),
+		})
+	default:
+		if tmpl != nil {
+			return []byte(message), nil
+		}
+
+		return json.Marshal(event)
+	}
+}
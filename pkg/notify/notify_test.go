@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func newTestServer(t *testing.T, status int) (*httptest.Server, chan request) {
+	t.Helper()
+
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPost, r.Method)
+		requests <- request{header: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func testEvent() *Event {
+	e := &Event{
+		Event:     EventFinish,
+		AccountID: "000000000000",
+		Regions:   []string{"global", "us-east-1"},
+		DryRun:    true,
+		Time:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	e.SetDuration(90 * time.Second)
+	e.SetQueue([]*queue.Item{
+		{Type: "EC2Instance", State: queue.ItemStateFailed},
+		{Type: "EC2Instance", State: queue.ItemStateFailed},
+		{Type: "S3Bucket", State: queue.ItemStateFailed},
+		{Type: "S3Bucket", State: queue.ItemStateFinished},
+		{Type: "IAMRole", State: queue.ItemStateFiltered},
+	})
+
+	return e
+}
+
+func TestEvent_SetQueue(t *testing.T) {
+	e := testEvent()
+
+	assert.Equal(t, map[string]int{"failed": 3, "removed": 1, "filtered": 1}, e.Counts)
+	assert.Equal(t, []TypeCount{
+		{ResourceType: "EC2Instance", Count: 2},
+		{ResourceType: "S3Bucket", Count: 1},
+	}, e.TopFailures)
+	assert.Equal(t, "1m30s", e.Duration)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		config *config.Notification
+		error  string
+	}{
+		{
+			name:   "missing url",
+			config: &config.Notification{},
+			error:  "url is required",
+		},
+		{
+			name:   "format",
+			config: &config.Notification{URL: "http://localhost", Format: "email"},
+			error:  "unsupported format 'email'",
+		},
+		{
+			name:   "event",
+			config: &config.Notification{URL: "http://localhost", Events: []string{"done"}},
+			error:  "unsupported event 'done'",
+		},
+		{
+			name:   "template",
+			config: &config.Notification{URL: "http://localhost", Template: "{{ .Account "},
+			error:  "invalid template",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New([]*config.Notification{tc.config}, logrus.NewEntry(logrus.New()))
+			assert.ErrorContains(t, err, tc.error)
+		})
+	}
+}
+
+func TestNotifier_Send(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+
+	n, err := New([]*config.Notification{
+		{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			URL:    server.URL,
+			Format: "slack",
+			Events: []string{"fail"},
+		},
+	}, logrus.NewEntry(logrus.New()))
+	assert.NoError(t, err)
+
+	n.Send(context.Background(), testEvent())
+
+	assert.Len(t, requests, 1)
+
+	req := <-requests
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+
+	var event Event
+	assert.NoError(t, json.Unmarshal(req.body, &event))
+	assert.Equal(t, EventFinish, event.Event)
+	assert.Equal(t, "000000000000", event.AccountID)
+	assert.Equal(t, 3, event.Counts["failed"])
+	assert.Equal(t, 90.0, event.DurationSeconds)
+}
+
+func TestNotifier_SendFailure(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusInternalServerError)
+
+	n, err := New([]*config.Notification{{URL: server.URL}}, logrus.NewEntry(logrus.New()))
+	assert.NoError(t, err)
+
+	// a failing webhook is only logged
+	n.Send(context.Background(), testEvent())
+	assert.Len(t, requests, 1)
+
+	var nilNotifier *Notifier
+	nilNotifier.Send(context.Background(), testEvent())
+}
+
+func TestRender(t *testing.T) {
+	n, err := New([]*config.Notification{
+		{URL: "http://localhost", Format: "slack"},
+		{URL: "http://localhost", Format: "teams"},
+		{URL: "http://localhost", Template: `{"account": {{ json .AccountID }}, "regions": "{{ join .Regions "," }}"}`},
+	}, logrus.NewEntry(logrus.New()))
+	assert.NoError(t, err)
+
+	event := testEvent()
+	event.Error = "failed"
+
+	expected := "aws-nuke finish: account 000000000000 [dry run]\n" +
+		"Regions: global, us-east-1\n" +
+		"Resources: 3 failed; 1 filtered; 1 removed;\n" +
+		"Top failing resource types: EC2Instance (2); S3Bucket (1);\n" +
+		"Duration: 1m30s\n" +
+		"Error: failed"
+
+	slack, err := Render(n.targets[0].format, n.targets[0].template, event)
+	assert.NoError(t, err)
+
+	var slackPayload map[string]string
+	assert.NoError(t, json.Unmarshal(slack, &slackPayload))
+	assert.Equal(t, expected, slackPayload["text"])
+
+	teams, err := Render(n.targets[1].format, n.targets[1].template, event)
+	assert.NoError(t, err)
+
+	var teamsPayload map[string]string
+	assert.NoError(t, json.Unmarshal(teams, &teamsPayload))
+	assert.Equal(t, "MessageCard", teamsPayload["@type"])
+	assert.Equal(t, "aws-nuke finish: account 000000000000", teamsPayload["summary"])
+	assert.Contains(t, teamsPayload["text"], "Regions: global, us-east-1\n\n")
+
+	custom, err := Render(n.targets[2].format, n.targets[2].template, event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"account": "000000000000", "regions": "global,us-east-1"}`, string(custom))
+}
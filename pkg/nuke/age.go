@@ -0,0 +1,208 @@
+package nuke
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// CreatedAtProperty is the normalized property that holds the creation time of a resource in RFC3339 format
+const CreatedAtProperty = "CreatedAt"
+
+// createdAtProperties are the properties that resources use for their creation time, in order of preference. The
+// normalized property comes first, the others are the names used by the AWS APIs.
+var createdAtProperties = []string{
+	CreatedAtProperty,
+	"CreationTime",
+	"CreatedTime",
+	"CreationDate",
+	"CreateDate",
+	"CreateTime",
+	"CreatedDate",
+	"CreationDateTime",
+	"LaunchTime",
+	"SnapshotCreateTime",
+}
+
+// timeLayouts are the formats the creation time of a resource is found in, RFC3339 is used by
+// types.NewPropertiesFromStruct, the second is the format of time.Time.String()
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ParseTime parses a time in any of the formats resources use for their creation time
+func ParseTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	// time.Time.String() appends the monotonic clock reading, which cannot be parsed
+	if i := strings.Index(value, " m="); i != -1 {
+		value = value[:i]
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil && !t.IsZero() {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// SetCreatedAt sets the normalized CreatedAt property from the creation time of the resource, which can be a time,
+// a pointer to a time or a string in any of the formats understood by ParseTime. Nothing is set if the value is nil
+// or cannot be parsed.
+func SetCreatedAt(props types.Properties, value interface{}) types.Properties {
+	var createdAt time.Time
+
+	switch v := value.(type) {
+	case time.Time:
+		createdAt = v
+	case *time.Time:
+		if v != nil {
+			createdAt = *v
+		}
+	case string:
+		createdAt, _ = ParseTime(v)
+	case *string:
+		if v != nil {
+			createdAt, _ = ParseTime(*v)
+		}
+	}
+
+	if createdAt.IsZero() {
+		return props
+	}
+
+	return props.Set(CreatedAtProperty, createdAt.UTC())
+}
+
+// CreatedAt returns the creation time of the resource from its normalized CreatedAt property, or from any of the
+// other properties that resources use for their creation time
+func CreatedAt(res resource.Resource) (time.Time, bool) {
+	getter, ok := res.(resource.PropertyGetter)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	props := getter.Properties()
+	for _, property := range createdAtProperties {
+		if t, ok := ParseTime(props.Get(property)); ok {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+var ageUnitPattern = regexp.MustCompile(`^(\d+)([dw])(.*)$`)
+
+// ParseAge parses an age such as 7d, 2w, 36h or 1d12h. Days and weeks are supported in addition to the units of
+// time.ParseDuration.
+func ParseAge(value string) (time.Duration, error) {
+	var age time.Duration
+
+	rest := strings.TrimSpace(value)
+	for {
+		m := ageUnitPattern.FindStringSubmatch(rest)
+		if m == nil {
+			break
+		}
+
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid age '%s': %w", value, err)
+		}
+
+		unit := 24 * time.Hour
+		if m[2] == "w" {
+			unit *= 7
+		}
+
+		age += time.Duration(n) * unit
+		rest = m[3]
+	}
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age '%s', expected for example 7d, 2w or 36h", value)
+		}
+		age += d
+	}
+
+	if age <= 0 {
+		return 0, fmt.Errorf("invalid age '%s', must be greater than zero", value)
+	}
+
+	return age, nil
+}
+
+// AgeFilter protects resources that are younger than MinAge. Resources whose creation time is unknown are not
+// protected, they are recorded in Unknown so that they can be reported.
+type AgeFilter struct {
+	MinAge time.Duration
+	Now    time.Time
+
+	// Unknown are the items whose creation time is unknown
+	Unknown []*queue.Item
+}
+
+// NewAgeFilter creates a filter that protects resources that were created less than minAge ago
+func NewAgeFilter(minAge time.Duration) *AgeFilter {
+	return &AgeFilter{
+		MinAge: minAge,
+		Now:    time.Now(),
+	}
+}
+
+// Filter is the ItemFilter that returns the reason the item is protected, if it is younger than the minimum age
+func (f *AgeFilter) Filter(item *queue.Item) string {
+	createdAt, ok := CreatedAt(item.Resource)
+	if !ok {
+		f.Unknown = append(f.Unknown, item)
+		return ""
+	}
+
+	if age := f.Now.Sub(createdAt); age < f.MinAge {
+		return fmt.Sprintf("younger than the minimum age of %s, created %s ago", FormatAge(f.MinAge), FormatAge(age))
+	}
+
+	return ""
+}
+
+// UnknownByType returns the number of items whose creation time is unknown per resource type
+func (f *AgeFilter) UnknownByType() map[string]int {
+	counts := make(map[string]int)
+	for _, item := range f.Unknown {
+		counts[item.Type]++
+	}
+
+	return counts
+}
+
+// FormatAge formats an age in days and hours, or as a duration when it is less than a day
+func FormatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return d.Round(time.Minute).String()
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int((d % (24 * time.Hour)) / time.Hour)
+	if hours == 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+
+	return fmt.Sprintf("%dd%dh", days, hours)
+}
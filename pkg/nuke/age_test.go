@@ -0,0 +1,155 @@
+package nuke
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type ageTestResource struct {
+	props types.Properties
+}
+
+func (r *ageTestResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *ageTestResource) Properties() types.Properties {
+	return r.props
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []string{
+		"2024-01-02T03:04:05Z",
+		"2024-01-02T04:04:05+01:00",
+		"2024-01-02 03:04:05 +0000 UTC",
+		"2024-01-02 03:04:05.000000001 +0000 UTC m=+0.000000001",
+		"2024-01-02T03:04:05",
+	}
+
+	for _, value := range cases {
+		t.Run(value, func(t *testing.T) {
+			parsed, ok := ParseTime(value)
+			assert.True(t, ok)
+			assert.WithinDuration(t, expected, parsed, time.Microsecond)
+		})
+	}
+
+	for _, value := range []string{"", "yesterday", "0001-01-01T00:00:00Z"} {
+		_, ok := ParseTime(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestSetCreatedAt(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	expected := "2024-01-02T02:04:05Z"
+
+	var nilTime *time.Time
+	var nilString *string
+
+	assert.Equal(t, expected, SetCreatedAt(types.NewProperties(), created).Get(CreatedAtProperty))
+	assert.Equal(t, expected, SetCreatedAt(types.NewProperties(), &created).Get(CreatedAtProperty))
+	assert.Equal(t, expected, SetCreatedAt(types.NewProperties(), "2024-01-02T02:04:05Z").Get(CreatedAtProperty))
+	assert.Equal(t, "", SetCreatedAt(types.NewProperties(), nilTime).Get(CreatedAtProperty))
+	assert.Equal(t, "", SetCreatedAt(types.NewProperties(), nilString).Get(CreatedAtProperty))
+	assert.Equal(t, "", SetCreatedAt(types.NewProperties(), time.Time{}).Get(CreatedAtProperty))
+}
+
+func TestCreatedAt(t *testing.T) {
+	launched := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	createdAt, ok := CreatedAt(&ageTestResource{props: types.NewProperties().Set("LaunchTime", launched)})
+	assert.True(t, ok)
+	assert.Equal(t, launched, createdAt)
+
+	// the normalized property is preferred
+	createdAt, ok = CreatedAt(&ageTestResource{props: types.NewProperties().
+		Set("LaunchTime", launched).
+		Set(CreatedAtProperty, launched.Add(time.Hour))})
+	assert.True(t, ok)
+	assert.Equal(t, launched.Add(time.Hour), createdAt)
+
+	_, ok = CreatedAt(&ageTestResource{props: types.NewProperties().Set("Name", "test")})
+	assert.False(t, ok)
+}
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"7d":     7 * 24 * time.Hour,
+		"2w":     14 * 24 * time.Hour,
+		"36h":    36 * time.Hour,
+		"1d12h":  36 * time.Hour,
+		"1w1d":   8 * 24 * time.Hour,
+		"90m":    90 * time.Minute,
+		" 3d ":   3 * 24 * time.Hour,
+		"1d30m0": 0,
+	}
+
+	for value, expected := range cases {
+		t.Run(value, func(t *testing.T) {
+			age, err := ParseAge(value)
+			if expected == 0 {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, age)
+		})
+	}
+
+	for _, value := range []string{"", "0d", "seven days", "-1h", "d"} {
+		_, err := ParseAge(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestAgeFilter(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	f := &AgeFilter{MinAge: 7 * 24 * time.Hour, Now: now}
+
+	old := &queue.Item{Type: "Old", Resource: &ageTestResource{
+		props: SetCreatedAt(types.NewProperties(), now.Add(-8*24*time.Hour)),
+	}}
+	young := &queue.Item{Type: "Young", Resource: &ageTestResource{
+		props: types.NewProperties().Set("CreateDate", now.Add(-50*time.Hour)),
+	}}
+	unknown := &queue.Item{Type: "Unknown", Resource: &ageTestResource{props: types.NewProperties()}}
+
+	assert.Equal(t, "", f.Filter(old))
+	assert.Equal(t, "younger than the minimum age of 7d, created 2d2h ago", f.Filter(young))
+	assert.Equal(t, "", f.Filter(unknown))
+
+	assert.Equal(t, []*queue.Item{unknown}, f.Unknown)
+	assert.Equal(t, map[string]int{"Unknown": 1}, f.UnknownByType())
+}
+
+func TestRunner_ItemFilter(t *testing.T) {
+	n := newTestRunner(t, false)
+
+	n.RegisterItemFilter(func(item *queue.Item) string {
+		if item.Resource.(*testResource).Name == "remove-1" {
+			return "protected"
+		}
+		return ""
+	})
+
+	assert.NoError(t, n.Run(context.Background()))
+
+	assert.Equal(t, 1, n.Queue.Count(queue.ItemStateNew))
+	assert.Equal(t, 2, n.Queue.Count(queue.ItemStateFiltered))
+
+	for _, item := range n.Queue.GetItems() {
+		if item.Resource.(*testResource).Name == "remove-1" {
+			assert.Equal(t, "protected", item.GetReason())
+		}
+	}
+}
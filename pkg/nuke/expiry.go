@@ -0,0 +1,137 @@
+package nuke
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+)
+
+// NoExpiryTagPolicy is what happens to resources that do not have the expiry tag
+type NoExpiryTagPolicy string
+
+const (
+	// NoExpiryTagDelete removes resources without the expiry tag, as if there was no expiry tag configured
+	NoExpiryTagDelete NoExpiryTagPolicy = "delete"
+
+	// NoExpiryTagKeep keeps resources without the expiry tag
+	NoExpiryTagKeep NoExpiryTagPolicy = "keep"
+
+	// NoExpiryTagKeepFor keeps resources without the expiry tag for a period after they were created
+	NoExpiryTagKeepFor NoExpiryTagPolicy = "keep-for"
+)
+
+// ExpiryFilter protects resources whose expiry tag holds a time in the future. The tag is either an RFC3339 time or
+// date, or an age such as 7d that is counted from the creation time of the resource. Once the expiry has passed the
+// resource is removed like any other resource.
+type ExpiryFilter struct {
+	Tag      string
+	NoExpiry NoExpiryTagPolicy
+	KeepFor  time.Duration
+	Now      time.Time
+}
+
+// NewExpiryFilter creates a filter for the expiry tag with the given policy for resources without the tag. The policy
+// is one of delete, keep or keep-for-<age>, for example keep-for-7d, and defaults to delete.
+func NewExpiryFilter(tag, noExpiryTag string) (*ExpiryFilter, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("no-expiry-tag requires expiry-tag to be set")
+	}
+
+	f := &ExpiryFilter{
+		Tag:      tag,
+		NoExpiry: NoExpiryTagDelete,
+		Now:      time.Now(),
+	}
+
+	switch policy := strings.ToLower(strings.TrimSpace(noExpiryTag)); {
+	case policy == "" || policy == string(NoExpiryTagDelete):
+	case policy == string(NoExpiryTagKeep):
+		f.NoExpiry = NoExpiryTagKeep
+	case strings.HasPrefix(policy, string(NoExpiryTagKeepFor)+"-"):
+		keepFor, err := ParseAge(strings.TrimPrefix(policy, string(NoExpiryTagKeepFor)+"-"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid no-expiry-tag '%s': %w", noExpiryTag, err)
+		}
+
+		f.NoExpiry = NoExpiryTagKeepFor
+		f.KeepFor = keepFor
+	default:
+		return nil, fmt.Errorf("invalid no-expiry-tag '%s', must be one of: delete, keep, keep-for-<age>", noExpiryTag)
+	}
+
+	return f, nil
+}
+
+// Filter is the ItemFilter that returns the reason the item is protected, if it has not expired yet
+func (f *ExpiryFilter) Filter(item *queue.Item) string {
+	value, ok := f.tagValue(item.Resource)
+	if !ok {
+		return f.filterNoExpiry(item.Resource)
+	}
+
+	expiresAt, err := f.expiresAt(item.Resource, value)
+	if err != nil {
+		return err.Error()
+	}
+
+	if expiresAt.After(f.Now) {
+		return fmt.Sprintf("expires at %s, in %s", expiresAt.UTC().Format(time.RFC3339), FormatAge(expiresAt.Sub(f.Now)))
+	}
+
+	return ""
+}
+
+// filterNoExpiry applies the policy for resources without the expiry tag
+func (f *ExpiryFilter) filterNoExpiry(res resource.Resource) string {
+	switch f.NoExpiry {
+	case NoExpiryTagKeep:
+		return fmt.Sprintf("no %s tag", f.Tag)
+	case NoExpiryTagKeepFor:
+		createdAt, ok := CreatedAt(res)
+		if !ok {
+			return fmt.Sprintf("no %s tag and the creation time is unknown", f.Tag)
+		}
+
+		if expiresAt := createdAt.Add(f.KeepFor); expiresAt.After(f.Now) {
+			return fmt.Sprintf("no %s tag, kept for %s until %s",
+				f.Tag, FormatAge(f.KeepFor), expiresAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return ""
+}
+
+// expiresAt returns the time the resource expires at from the value of the expiry tag. A value that cannot be
+// understood returns an error, the resource is kept rather than removed by mistake.
+func (f *ExpiryFilter) expiresAt(res resource.Resource, value string) (time.Time, error) {
+	if t, ok := ParseTime(value); ok {
+		return t, nil
+	}
+
+	ttl, err := ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s tag '%s', expected a RFC3339 time or an age such as 7d", f.Tag, value)
+	}
+
+	createdAt, ok := CreatedAt(res)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s tag is an age of %s but the creation time is unknown", f.Tag, value)
+	}
+
+	return createdAt.Add(ttl), nil
+}
+
+// tagValue returns the value of the expiry tag of the resource itself, tags of related resources that are set with a
+// prefix such as tag:role:<key> are not considered
+func (f *ExpiryFilter) tagValue(res resource.Resource) (string, bool) {
+	getter, ok := res.(resource.PropertyGetter)
+	if !ok {
+		return "", false
+	}
+
+	value, ok := getter.Properties()["tag:"+f.Tag]
+	return value, ok
+}
@@ -0,0 +1,125 @@
+package nuke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+const testExpiryTag = "nuke:expires-at"
+
+func expiryTestItem(expiresAt string, createdAt interface{}) *queue.Item {
+	props := types.NewProperties()
+	if expiresAt != "" {
+		props.Set("tag:"+testExpiryTag, expiresAt)
+	}
+
+	return &queue.Item{Type: "Test", Resource: &ageTestResource{props: SetCreatedAt(props, createdAt)}}
+}
+
+func TestNewExpiryFilter(t *testing.T) {
+	f, err := NewExpiryFilter(testExpiryTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, NoExpiryTagDelete, f.NoExpiry)
+
+	f, err = NewExpiryFilter(testExpiryTag, "keep")
+	assert.NoError(t, err)
+	assert.Equal(t, NoExpiryTagKeep, f.NoExpiry)
+
+	f, err = NewExpiryFilter(testExpiryTag, "keep-for-7d")
+	assert.NoError(t, err)
+	assert.Equal(t, NoExpiryTagKeepFor, f.NoExpiry)
+	assert.Equal(t, 7*24*time.Hour, f.KeepFor)
+
+	for _, policy := range []string{"remove", "keep-for", "keep-for-soon"} {
+		_, err = NewExpiryFilter(testExpiryTag, policy)
+		assert.Error(t, err, policy)
+	}
+
+	_, err = NewExpiryFilter("", "keep")
+	assert.Error(t, err)
+}
+
+func TestExpiryFilter(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-3 * 24 * time.Hour)
+
+	cases := []struct {
+		name     string
+		item     *queue.Item
+		expected string
+	}{
+		{
+			name:     "future",
+			item:     expiryTestItem("2024-01-12T12:00:00Z", nil),
+			expected: "expires at 2024-01-12T12:00:00Z, in 2d12h",
+		},
+		{
+			name: "past",
+			item: expiryTestItem("2024-01-09T00:00:00Z", nil),
+		},
+		{
+			name:     "date",
+			item:     expiryTestItem("2024-01-11", nil),
+			expected: "expires at 2024-01-11T00:00:00Z, in 1d",
+		},
+		{
+			name:     "age not passed",
+			item:     expiryTestItem("7d", created),
+			expected: "expires at 2024-01-14T00:00:00Z, in 4d",
+		},
+		{
+			name: "age passed",
+			item: expiryTestItem("2d", created),
+		},
+		{
+			name:     "age without creation time",
+			item:     expiryTestItem("7d", nil),
+			expected: "nuke:expires-at tag is an age of 7d but the creation time is unknown",
+		},
+		{
+			name:     "invalid",
+			item:     expiryTestItem("friday", created),
+			expected: "invalid nuke:expires-at tag 'friday', expected a RFC3339 time or an age such as 7d",
+		},
+		{
+			name: "no tag",
+			item: expiryTestItem("", created),
+		},
+	}
+
+	f, err := NewExpiryFilter(testExpiryTag, "delete")
+	assert.NoError(t, err)
+	f.Now = now
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, f.Filter(tc.item))
+		})
+	}
+}
+
+func TestExpiryFilter_NoExpiryTag(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	keep, err := NewExpiryFilter(testExpiryTag, "keep")
+	assert.NoError(t, err)
+	keep.Now = now
+
+	assert.Equal(t, "no nuke:expires-at tag", keep.Filter(expiryTestItem("", now.Add(-30*24*time.Hour))))
+	assert.Equal(t, "", keep.Filter(expiryTestItem("2024-01-01T00:00:00Z", nil)))
+
+	keepFor, err := NewExpiryFilter(testExpiryTag, "keep-for-7d")
+	assert.NoError(t, err)
+	keepFor.Now = now
+
+	assert.Equal(t, "no nuke:expires-at tag, kept for 7d until 2024-01-13T00:00:00Z",
+		keepFor.Filter(expiryTestItem("", now.Add(-4*24*time.Hour))))
+	assert.Equal(t, "", keepFor.Filter(expiryTestItem("", now.Add(-8*24*time.Hour))))
+	assert.Equal(t, "no nuke:expires-at tag and the creation time is unknown",
+		keepFor.Filter(expiryTestItem("", nil)))
+}
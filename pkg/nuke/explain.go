@@ -0,0 +1,173 @@
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+// The places resource types can be included, excluded or replaced from, in the order libnuke resolves them
+const (
+	SourceFlags         = "cli flags"
+	SourceGlobalConfig  = "global config"
+	SourceAccountConfig = "account config"
+)
+
+// FilterSet is a set of filters of the configuration together with where they are defined, which is either the
+// filters of the account or a preset
+type FilterSet struct {
+	Origin  string
+	Filters filter.Filters
+}
+
+// FilterMatch is a filter that matched a resource and where it is defined
+type FilterMatch struct {
+	Origin string
+	Filter filter.Filter
+}
+
+func (m FilterMatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Origin, FilterString(&m.Filter))
+}
+
+// Explanation describes whether a resource would be removed and, if it is kept, why
+type Explanation struct {
+	Item    *queue.Item
+	Removed bool
+	Matches []FilterMatch
+	Reason  string
+}
+
+// Explain explains the decision the scan made for the item. Filters are matched against the combination of all the
+// sets, the same as the account filters and its presets are combined for the scan, and every matching filter is
+// attributed to the set it is defined in. Items that were kept for a reason other than a filter of the configuration,
+// such as a resource that can not be removed or one that is too young, carry the reason of the scan instead. Items
+// that the protect tag kept are never attributed to a filter, the tag overrides them.
+func Explain(sets []FilterSet, item *queue.Item, useGroups bool) (*Explanation, error) {
+	explanation := &Explanation{
+		Item:    item,
+		Removed: item.State != queue.ItemStateFiltered,
+	}
+
+	if explanation.Removed {
+		return explanation, nil
+	}
+
+	if IsProtected(item) {
+		explanation.Reason = item.GetReason()
+		return explanation, nil
+	}
+
+	combined := filter.Filters{}
+	for _, set := range sets {
+		for resourceType, filters := range set.Filters {
+			combined[resourceType] = append(combined[resourceType], filters...)
+		}
+	}
+
+	matches, err := MatchFilters(combined, item, useGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range matches {
+		for _, set := range sets {
+			if containsFilter(set.Filters[item.Type], match) || containsFilter(set.Filters[filter.Global], match) {
+				explanation.Matches = append(explanation.Matches, FilterMatch{Origin: set.Origin, Filter: match})
+				break
+			}
+		}
+	}
+
+	if len(explanation.Matches) == 0 {
+		explanation.Reason = item.Reason
+	}
+
+	return explanation, nil
+}
+
+func containsFilter(filters []filter.Filter, f filter.Filter) bool {
+	return slices.ContainsFunc(filters, func(other filter.Filter) bool {
+		return reflect.DeepEqual(other, f)
+	})
+}
+
+// Exclusion is a resource type that is not scanned, the place it was excluded from and why
+type Exclusion struct {
+	Type   string
+	Source string
+	Reason string
+}
+
+// ExplainResourceTypes returns the resource types of base that are excluded from the scan. It mirrors
+// types.ResolveResourceTypes step by step and records the first step that removes a resource type. The includes,
+// excludes and alternatives are given per source, in the same order as the sources.
+func ExplainResourceTypes(
+	base types.Collection, sources []string,
+	includes, excludes, alternatives []types.Collection,
+	alternativeMappings map[string]string) []Exclusion {
+	excluded := map[string]Exclusion{}
+	exclude := func(name, source, reason string) {
+		if _, ok := excluded[name]; ok || !slices.Contains(base, name) {
+			return
+		}
+
+		excluded[name] = Exclusion{Type: name, Source: source, Reason: reason}
+	}
+
+	remaining := base
+	for i, cl := range alternatives {
+		expanded := cl.Expand(remaining)
+
+		var replaced types.Collection
+		for _, name := range expanded {
+			old, found := alternativeMappings[name]
+			if !found {
+				continue
+			}
+
+			replaced = append(replaced, old)
+			if slices.Contains(remaining, old) {
+				exclude(old, sources[i], fmt.Sprintf("replaced by the alternative %s", name))
+			}
+		}
+
+		remaining = remaining.Union(expanded)
+		remaining = remaining.Remove(replaced)
+	}
+
+	for i, cl := range includes {
+		if len(cl) == 0 {
+			continue
+		}
+
+		kept := remaining.Intersect(cl.Expand(remaining))
+		for _, name := range remaining.Remove(kept) {
+			exclude(name, sources[i], "not included")
+		}
+		remaining = kept
+	}
+
+	for i, cl := range excludes {
+		expanded := cl.Expand(remaining)
+		for _, name := range remaining.Intersect(expanded) {
+			exclude(name, sources[i], "excluded")
+		}
+		remaining = remaining.Remove(expanded)
+	}
+
+	exclusions := make([]Exclusion, 0, len(excluded))
+	for _, exclusion := range excluded {
+		exclusions = append(exclusions, exclusion)
+	}
+	sort.Slice(exclusions, func(i, j int) bool {
+		return exclusions[i].Type < exclusions[j].Type
+	})
+
+	return exclusions
+}
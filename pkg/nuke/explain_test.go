@@ -0,0 +1,125 @@
+package nuke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+func TestExplain(t *testing.T) {
+	admin := filter.Filter{Property: "Name", Type: filter.Exact, Value: "admin", Group: "owner"}
+	team := filter.Filter{Property: "tag:Team", Type: filter.Exact, Value: "platform", Group: "team"}
+	owner := filter.Filter{Property: "tag:Owner", Type: filter.Exact, Value: "ops", Group: "owner"}
+
+	sets := []FilterSet{
+		{Origin: "account filters", Filters: filter.Filters{"IAMRole": {admin}}},
+		{Origin: "preset 'common'", Filters: filter.Filters{filter.Global: {team}, "IAMRole": {owner}}},
+	}
+
+	item := func(state queue.ItemState, name, team string) *queue.Item {
+		return &queue.Item{
+			Type:   "IAMRole",
+			State:  state,
+			Reason: "filtered by config",
+			Resource: &ageTestResource{props: types.NewProperties().
+				Set("Name", name).Set("tag:Team", team).Set("tag:Owner", "ops")},
+		}
+	}
+
+	cases := []struct {
+		name      string
+		item      *queue.Item
+		useGroups bool
+		want      Explanation
+	}{
+		{
+			name: "removed",
+			item: item(queue.ItemStateNew, "web", "data"),
+			want: Explanation{Removed: true},
+		},
+		{
+			name: "account",
+			item: item(queue.ItemStateFiltered, "admin", "data"),
+			want: Explanation{Matches: []FilterMatch{{Origin: "account filters", Filter: admin}}},
+		},
+		{
+			name: "global",
+			item: item(queue.ItemStateFiltered, "web", "platform"),
+			want: Explanation{Matches: []FilterMatch{{Origin: "preset 'common'", Filter: team}}},
+		},
+		{
+			name:      "groups",
+			item:      item(queue.ItemStateFiltered, "web", "platform"),
+			useGroups: true,
+			want: Explanation{Matches: []FilterMatch{
+				{Origin: "preset 'common'", Filter: team},
+				{Origin: "preset 'common'", Filter: owner},
+			}},
+		},
+		{
+			name: "other-reason",
+			item: &queue.Item{
+				Type: "EC2VPC", State: queue.ItemStateFiltered, Reason: "default vpc",
+				Resource: &ageTestResource{props: types.NewProperties()},
+			},
+			want: Explanation{Reason: "default vpc"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			explanation, err := Explain(sets, tc.item, tc.useGroups)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.want.Removed, explanation.Removed)
+			assert.ElementsMatch(t, tc.want.Matches, explanation.Matches)
+			assert.Equal(t, tc.want.Reason, explanation.Reason)
+		})
+	}
+}
+
+func TestFilterMatch_String(t *testing.T) {
+	match := FilterMatch{
+		Origin: "preset 'common'",
+		Filter: filter.Filter{Property: "Name", Type: filter.Glob, Value: "admin-*", Invert: true},
+	}
+
+	assert.Equal(t, "preset 'common': type=glob property=Name value=admin-* invert=true", match.String())
+}
+
+func TestExplainResourceTypes(t *testing.T) {
+	base := types.Collection{"EC2Instance", "EC2VPC", "IAMRole", "IAMUser", "S3Bucket", "S3Object"}
+	sources := []string{SourceFlags, SourceGlobalConfig, SourceAccountConfig}
+
+	exclusions := ExplainResourceTypes(base, sources,
+		[]types.Collection{{}, {}, {"EC2*", "IAM*", "S3*"}},
+		[]types.Collection{{"IAMUser"}, {"S3Object"}, {}},
+		[]types.Collection{{}, {"AWS::EC2::VPC"}, {}},
+		map[string]string{"AWS::EC2::VPC": "EC2VPC"},
+	)
+
+	assert.Equal(t, []Exclusion{
+		{Type: "EC2VPC", Source: SourceGlobalConfig, Reason: "replaced by the alternative AWS::EC2::VPC"},
+		{Type: "IAMUser", Source: SourceFlags, Reason: "excluded"},
+		{Type: "S3Object", Source: SourceGlobalConfig, Reason: "excluded"},
+	}, exclusions)
+
+	exclusions = ExplainResourceTypes(base, sources,
+		[]types.Collection{{"IAMRole", "S3Bucket"}, {}, {"IAMRole"}},
+		[]types.Collection{{}, {}, {}},
+		[]types.Collection{{}, {}, {}},
+		nil,
+	)
+
+	assert.Equal(t, []Exclusion{
+		{Type: "EC2Instance", Source: SourceFlags, Reason: "not included"},
+		{Type: "EC2VPC", Source: SourceFlags, Reason: "not included"},
+		{Type: "IAMUser", Source: SourceFlags, Reason: "not included"},
+		{Type: "S3Bucket", Source: SourceAccountConfig, Reason: "not included"},
+		{Type: "S3Object", Source: SourceFlags, Reason: "not included"},
+	}, exclusions)
+}
@@ -0,0 +1,109 @@
+package nuke
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+)
+
+// MatchFilters returns the configured filters that caused the item to be filtered. It mirrors the evaluation that
+// libnuke performs during the scan, but instead of only flagging the item it returns the filters that matched so that
+// they can be reported back to the user. When useGroups is false the first matching filter is returned, the same as
+// libnuke. When useGroups is true the item only matches if every group has at least one matching filter, in which case
+// all matching filters are returned.
+func MatchFilters(filters filter.Filters, item *queue.Item, useGroups bool) ([]filter.Filter, error) {
+	if useGroups {
+		return matchFiltersWithGroups(filters, item)
+	}
+
+	for _, f := range filters.Get(item.Type) {
+		matched, err := matchFilter(f, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			return []filter.Filter{f}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func matchFiltersWithGroups(filters filter.Filters, item *queue.Item) ([]filter.Filter, error) {
+	groups := filters.GetByGroup(item.Type)
+	if groups == nil {
+		return nil, nil
+	}
+
+	var matches []filter.Filter
+	for _, group := range groups {
+		groupMatched := false
+		for _, f := range group {
+			matched, err := matchFilter(f.Filter, item)
+			if err != nil {
+				return nil, err
+			}
+
+			if matched {
+				groupMatched = true
+				matches = append(matches, f.Filter)
+			}
+		}
+
+		if !groupMatched {
+			return nil, nil
+		}
+	}
+
+	return matches, nil
+}
+
+func matchFilter(f filter.Filter, item *queue.Item) (bool, error) {
+	prop, err := item.GetProperty(f.Property)
+	if err != nil {
+		// Note: libnuke treats a missing property as a non-match, this primarily happens with __global__ filters
+		// against resources that do not support custom properties.
+		return false, nil
+	}
+
+	match, err := f.Match(prop)
+	if err != nil {
+		return false, err
+	}
+
+	if f.Invert {
+		match = !match
+	}
+
+	return match, nil
+}
+
+// FilterString returns a short human-readable description of a filter, suitable for logs and reports.
+func FilterString(f *filter.Filter) string {
+	parts := []string{
+		fmt.Sprintf("type=%s", f.Type),
+	}
+
+	if f.Property != "" {
+		parts = append(parts, fmt.Sprintf("property=%s", f.Property))
+	}
+
+	if len(f.Values) > 0 {
+		parts = append(parts, fmt.Sprintf("values=%s", strings.Join(f.Values, ",")))
+	} else {
+		parts = append(parts, fmt.Sprintf("value=%s", f.Value))
+	}
+
+	if f.Invert {
+		parts = append(parts, "invert=true")
+	}
+
+	if f.Group != "" && f.Group != "default" {
+		parts = append(parts, fmt.Sprintf("group=%s", f.Group))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,87 @@
+package nuke
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+)
+
+// ReasonProtected starts the reason of every resource that is protected by the protect tag, it sets them apart from
+// the resources that are filtered
+const ReasonProtected = "protected by protect-tag"
+
+// ProtectTag protects every resource that has the tag, whatever the filters and presets of the configuration say.
+// Without a value the tag protects the resource whatever its value is. The key and value are compared without
+// regard to case, so that a tag is never missed because of how it was spelled.
+type ProtectTag struct {
+	Key   string
+	Value string
+}
+
+// ParseProtectTag parses the protect tag from key=value, or just key to protect resources whatever the value is. The
+// key and the value are split at the first equals sign.
+func ParseProtectTag(s string) (*ProtectTag, error) {
+	key, value, _ := strings.Cut(s, "=")
+
+	p := &ProtectTag{
+		Key:   strings.TrimSpace(key),
+		Value: strings.TrimSpace(value),
+	}
+
+	if p.Key == "" {
+		return nil, fmt.Errorf("invalid protect-tag '%s', expected key=value or key", s)
+	}
+
+	return p, nil
+}
+
+func (p *ProtectTag) String() string {
+	if p.Value == "" {
+		return p.Key
+	}
+
+	return p.Key + "=" + p.Value
+}
+
+// Reason is the reason of the resources that the tag protects
+func (p *ProtectTag) Reason() string {
+	return fmt.Sprintf("%s %s", ReasonProtected, p)
+}
+
+// Protected returns whether the resource has the tag. Every shape of tag property counts: tag:<key>, the custom tag
+// prefixes of some resource types such as key:tag:<key>, and the tags of a related resource such as tag:role:<key>
+// on an instance profile, removing the resource would break the protected resource it belongs to.
+func (p *ProtectTag) Protected(res resource.Resource) bool {
+	getter, ok := res.(resource.PropertyGetter)
+	if !ok {
+		return false
+	}
+
+	suffix := ":" + strings.ToLower(p.Key)
+
+	for property, value := range getter.Properties() {
+		name := strings.ToLower(property)
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+
+		prefix := strings.Split(strings.TrimSuffix(name, suffix), ":")
+		if !slices.Contains(prefix, "tag") {
+			continue
+		}
+
+		if p.Value == "" || strings.EqualFold(strings.TrimSpace(value), p.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsProtected returns whether the item was kept because of the protect tag
+func IsProtected(item *queue.Item) bool {
+	return item.GetState() == queue.ItemStateFiltered && strings.HasPrefix(item.GetReason(), ReasonProtected)
+}
@@ -0,0 +1,89 @@
+package nuke
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testTaggedResource struct {
+	props types.Properties
+}
+
+func (r *testTaggedResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testTaggedResource) Properties() types.Properties {
+	return r.props
+}
+
+func TestParseProtectTag(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected *ProtectTag
+		err      bool
+	}{
+		{value: "aws-nuke:protect=true", expected: &ProtectTag{Key: "aws-nuke:protect", Value: "true"}},
+		{value: "aws-nuke:protect", expected: &ProtectTag{Key: "aws-nuke:protect"}},
+		{value: " keep = a=b ", expected: &ProtectTag{Key: "keep", Value: "a=b"}},
+		{value: "=true", err: true},
+		{value: "", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			p, err := ParseProtectTag(tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestProtectTag_Protected(t *testing.T) {
+	p := &ProtectTag{Key: "aws-nuke:protect", Value: "true"}
+
+	cases := []struct {
+		name      string
+		props     types.Properties
+		protected bool
+	}{
+		{name: "tag", props: types.Properties{"tag:aws-nuke:protect": "true"}, protected: true},
+		{name: "case", props: types.Properties{"tag:AWS-Nuke:Protect": "TRUE"}, protected: true},
+		{name: "related", props: types.Properties{"tag:role:aws-nuke:protect": "true"}, protected: true},
+		{name: "custom prefix", props: types.Properties{"key:tag:aws-nuke:protect": "true"}, protected: true},
+		{name: "other value", props: types.Properties{"tag:aws-nuke:protect": "false"}},
+		{name: "not a tag", props: types.Properties{"aws-nuke:protect": "true"}},
+		{name: "other key", props: types.Properties{"tag:nuke:protect": "true"}},
+		{name: "untagged", props: types.Properties{"Name": "web"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.protected, p.Protected(&testTaggedResource{props: tc.props}))
+		})
+	}
+
+	anyValue := &ProtectTag{Key: "aws-nuke:protect"}
+	assert.True(t, anyValue.Protected(&testTaggedResource{props: types.Properties{"tag:aws-nuke:protect": "no"}}))
+}
+
+func TestIsProtected(t *testing.T) {
+	p := &ProtectTag{Key: "aws-nuke:protect", Value: "true"}
+
+	item := &queue.Item{State: queue.ItemStateFiltered, Reason: p.Reason()}
+	assert.True(t, IsProtected(item))
+	assert.Equal(t, "protected by protect-tag aws-nuke:protect=true", item.GetReason())
+
+	assert.False(t, IsProtected(&queue.Item{State: queue.ItemStateFiltered, Reason: "filtered by config"}))
+	assert.False(t, IsProtected(&queue.Item{State: queue.ItemStateNew, Reason: p.Reason()}))
+}
@@ -1,12 +1,18 @@
 package nuke
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go/aws/session" //nolint:staticcheck
 
+	"github.com/ekristen/libnuke/pkg/queue"
 	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
 )
 
 // Account is the resource scope that all resources in AWS Nuke are registered against.
@@ -23,6 +29,12 @@ type ListerOpts struct {
 	Logger    *logrus.Entry
 }
 
+// copy returns a shallow copy of the options
+func (o *ListerOpts) copy() *ListerOpts {
+	c := *o
+	return &c
+}
+
 // MutateOpts is a function that will be called for each resource type to mutate the options for the scanner based on
 // whatever criteria you want. However, in this case for the aws-nuke tool, it's mutating the opts to create the proper
 // session for the proper region for the resourceType. For example IAM only happens in the global region, not us-east-2.
@@ -51,3 +63,78 @@ var MutateOpts = func(opts interface{}, resourceType string) interface{} {
 
 	return o
 }
+
+// IdentityProperties are the properties that identify a resource, in order of preference. An ARN never changes and is
+// unique across regions, an ID is unique within a region and a name is the least stable. Everything that refers to a
+// single resource, such as filters that keep it or the key it is compared by, prefers them in this order.
+var IdentityProperties = []string{"ARN", "Arn", "ID", "Id", "Identifier", "Name"}
+
+// IdentityProperty returns the first of the IdentityProperties that has a value, together with the value. Both are
+// empty when the resource has none of them.
+func IdentityProperty(properties map[string]string) (property, value string) {
+	for _, property := range IdentityProperties {
+		if value := properties[property]; value != "" {
+			return property, value
+		}
+	}
+
+	return "", ""
+}
+
+// ResourceIdentifier returns a stable identifier for the resource in the item. It prefers the UniqueKey of the
+// resource, then the legacy String() value and finally falls back to the sorted properties of the resource.
+func ResourceIdentifier(item *queue.Item) string {
+	if getter, ok := item.Resource.(resource.UniqueKeyGetter); ok {
+		return getter.UniqueKey()
+	}
+
+	if stringer, ok := item.Resource.(resource.LegacyStringer); ok {
+		return stringer.String()
+	}
+
+	if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+		return JoinProperties(getter.Properties())
+	}
+
+	return ""
+}
+
+// ResourceKey returns a key that tells the resource in the item apart from the other resources of its type in its
+// region. It is the UniqueKey of the resource if it has one. Otherwise, it is the legacy String() value together with
+// all properties, the String() value alone is often a name that several resources share, such as the versions of a
+// Lambda layer. Resources that still share a key cannot be told apart and must not be matched to each other.
+func ResourceKey(item *queue.Item) string {
+	if getter, ok := item.Resource.(resource.UniqueKeyGetter); ok {
+		return getter.UniqueKey()
+	}
+
+	var parts []string
+	if stringer, ok := item.Resource.(resource.LegacyStringer); ok {
+		parts = append(parts, stringer.String())
+	}
+
+	if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+		parts = append(parts, JoinProperties(getter.Properties()))
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// JoinProperties joins the properties in the order of their names, internal properties are left out
+func JoinProperties(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		if strings.HasPrefix(k, "_") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, props[k]))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,406 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	libnuke "github.com/ekristen/libnuke/pkg/nuke"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/registry"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
+)
+
+// ScanHook is called once the scan has completed and before anything is removed
+type ScanHook func(q *queue.Queue) error
+
+// IterationHook is called after every iteration of the removal loop
+type IterationHook func(iteration int, q *queue.Queue) error
+
+// ItemFilter is called during the scan for every item that the configured filters would remove, if it returns a
+// reason the item is filtered with that reason
+type ItemFilter func(item *queue.Item) (reason string)
+
+// RemoveHook is called after every attempt to remove a resource, the state of the item reflects the outcome
+type RemoveHook func(ctx context.Context, item *queue.Item, attempt int)
+
+// Runner drives the libnuke process. It is functionally equivalent to (*libnuke.Nuke).Run, however libnuke does not
+// expose any way to hook into the different phases of the run, which aws-nuke needs to record, trace and persist the
+// progress of a run. Validation, prompting, filtering and the removal of individual items are still performed by
+// libnuke, scanning is done by the aws-nuke Scanner.
+type Runner struct {
+	*libnuke.Nuke
+
+	log      *logrus.Entry
+	runSleep time.Duration
+	scanners []*Scanner
+
+	protectTag     *ProtectTag
+	itemFilters    []ItemFilter
+	scanHooks      []ScanHook
+	iterationHooks []IterationHook
+	removeHooks    []RemoveHook
+
+	attempts     map[*queue.Item]int
+	failedCount  int
+	waitingCount int
+
+	// activeSpan is the span of the iteration or removal in progress, API calls that are made without a context
+	// are recorded as its children
+	activeSpan atomic.Pointer[tracing.Span]
+}
+
+// NewRunner wraps the libnuke instance in a Runner
+func NewRunner(n *libnuke.Nuke) *Runner {
+	return &Runner{
+		Nuke:     n,
+		log:      logrus.NewEntry(logrus.StandardLogger()),
+		runSleep: 5 * time.Second,
+		attempts: make(map[*queue.Item]int),
+	}
+}
+
+// SetLogger sets the logger for both the runner and the underlying libnuke instance
+func (r *Runner) SetLogger(logger *logrus.Entry) {
+	r.log = logger
+	r.Nuke.SetLogger(logger)
+}
+
+// SetRunSleep sets how long to sleep between iterations of the removal loop
+func (r *Runner) SetRunSleep(duration time.Duration) {
+	r.runSleep = duration
+	r.Nuke.SetRunSleep(duration)
+}
+
+// SetProtectTag sets the tag that protects resources whatever the filters say, it is checked again before every
+// attempt to remove a resource
+func (r *Runner) SetProtectTag(tag *ProtectTag) {
+	r.protectTag = tag
+}
+
+// RegisterItemFilter registers a filter that is applied to every item after the configured filters
+func (r *Runner) RegisterItemFilter(f ItemFilter) {
+	r.itemFilters = append(r.itemFilters, f)
+}
+
+// RegisterScanHook registers a function that is called after the scan has completed
+func (r *Runner) RegisterScanHook(hook ScanHook) {
+	r.scanHooks = append(r.scanHooks, hook)
+}
+
+// RegisterIterationHook registers a function that is called after every iteration of the removal loop
+func (r *Runner) RegisterIterationHook(hook IterationHook) {
+	r.iterationHooks = append(r.iterationHooks, hook)
+}
+
+// RegisterRemoveHook registers a function that is called after every attempt to remove a resource
+func (r *Runner) RegisterRemoveHook(hook RemoveHook) {
+	r.removeHooks = append(r.removeHooks, hook)
+}
+
+// Attempts returns the number of times removal of the item has been attempted
+func (r *Runner) Attempts(item *queue.Item) int {
+	return r.attempts[item]
+}
+
+// SetAttempts sets the number of removal attempts for an item, used when restoring a previous run
+func (r *Runner) SetAttempts(item *queue.Item, attempts int) {
+	r.attempts[item] = attempts
+}
+
+// Run validates, prompts, scans and then removes resources, calling the registered hooks along the way
+func (r *Runner) Run(ctx context.Context) error {
+	r.Version()
+
+	printLog := r.log.WithField("_handler", "println")
+
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Prompt(); err != nil {
+		return err
+	}
+
+	printLog.Info("starting scan for resources")
+
+	if err := r.Scan(ctx); err != nil {
+		return err
+	}
+
+	for _, hook := range r.scanHooks {
+		if err := hook(r.Queue); err != nil {
+			return err
+		}
+	}
+
+	// libnuke only checks for new items, the items of a resumed run that were already removed are waiting instead
+	if r.Queue.Count(queue.ItemStateNew, queue.ItemStateWaiting) == 0 {
+		printLog.Info("No resource to delete.")
+		return nil
+	}
+
+	if !r.Parameters.NoDryRun {
+		printLog.Info("The above resources would be deleted with the supplied configuration. " +
+			"Provide --no-dry-run to actually destroy resources.")
+		return nil
+	}
+
+	if err := r.Prompt(); err != nil {
+		return err
+	}
+
+	if err := r.run(ctx); err != nil {
+		return err
+	}
+
+	printLog.
+		WithFields(logrus.Fields{
+			"failed":   r.Queue.Count(queue.ItemStateFailed),
+			"skipped":  r.Queue.Count(queue.ItemStateFiltered),
+			"finished": r.Queue.Count(queue.ItemStateFinished),
+		}).
+		Infof("Nuke complete: %d failed, %d skipped, %d finished.\n",
+			r.Queue.Count(queue.ItemStateFailed), r.Queue.Count(queue.ItemStateFiltered),
+			r.Queue.Count(queue.ItemStateFinished))
+
+	return nil
+}
+
+// run handles the processing and loop of the queue of items. It is the equivalent of the libnuke loop, except that
+// each iteration is traced, that the iteration hooks are called and that the sleep between iterations is cancelled
+// with the context.
+func (r *Runner) run(ctx context.Context) (err error) {
+	if r.runSleep == 0 {
+		r.runSleep = 5 * time.Second
+	}
+
+	ctx, span := tracing.Start(ctx, "remove")
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	for iteration := 1; ; iteration++ {
+		if err := r.iterate(ctx, iteration); err != nil {
+			return err
+		}
+
+		unfinishedCount := r.Queue.Count(queue.ItemStateNew, queue.ItemStateNewDependency,
+			queue.ItemStatePending, queue.ItemStatePendingDependency, queue.ItemStateFailed,
+			queue.ItemStateWaiting, queue.ItemStateHold,
+		)
+
+		if unfinishedCount == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.runSleep):
+		}
+	}
+
+	return nil
+}
+
+// iterate handles the queue once and then checks whether the run should be aborted
+func (r *Runner) iterate(ctx context.Context, iteration int) (err error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("iteration %d", iteration),
+		tracing.Int("aws_nuke.iteration", iteration))
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	r.activeSpan.Store(span)
+	defer r.activeSpan.Store(nil)
+
+	r.handleQueue(ctx)
+
+	for _, hook := range r.iterationHooks {
+		if err := hook(iteration, r.Queue); err != nil {
+			return err
+		}
+	}
+
+	if err := r.handleFailure(); err != nil {
+		return err
+	}
+
+	return r.handleWaiting()
+}
+
+// handleQueue is the equivalent of (*libnuke.Nuke).HandleQueue with the removal of each item going through remove,
+// which re-checks the protect tag, counts the attempts and calls the remove hooks
+func (r *Runner) handleQueue(ctx context.Context) {
+	listCache := make(libnuke.ListCache)
+
+	for _, item := range r.Queue.GetItems() {
+		switch item.GetState() {
+		case queue.ItemStateNew, queue.ItemStateHold:
+			r.remove(ctx, item)
+			item.Print()
+		case queue.ItemStateNewDependency, queue.ItemStatePendingDependency:
+			r.handleWaitDependency(ctx, item)
+			item.Print()
+		case queue.ItemStateFailed:
+			r.remove(ctx, item)
+			r.HandleWait(ctx, item, listCache)
+			item.Print()
+		case queue.ItemStatePending:
+			r.HandleWait(ctx, item, listCache)
+			item.State = queue.ItemStateWaiting
+			item.Print()
+		case queue.ItemStateWaiting:
+			r.HandleWait(ctx, item, listCache)
+			item.Print()
+		}
+	}
+
+	countWaiting := r.Queue.Count(
+		queue.ItemStateWaiting,
+		queue.ItemStatePending,
+		queue.ItemStatePendingDependency,
+		queue.ItemStateNewDependency,
+		queue.ItemStateHold,
+	)
+	countFailed := r.Queue.Count(queue.ItemStateFailed)
+	countSkipped := r.Queue.Count(queue.ItemStateFiltered)
+	countFinished := r.Queue.Count(queue.ItemStateFinished)
+
+	r.log.WithField("_handler", "println").
+		WithFields(logrus.Fields{
+			"waiting":  countWaiting,
+			"failed":   countFailed,
+			"skipped":  countSkipped,
+			"finished": countFinished,
+		}).
+		Infof("Removal requested: %d waiting, %d failed, %d skipped, %d finished\n\n",
+			countWaiting, countFailed, countSkipped, countFinished)
+}
+
+// remove removes the item through libnuke and then calls the remove hooks
+func (r *Runner) remove(ctx context.Context, item *queue.Item) {
+	// The protect tag is checked again right before the removal, whatever changed the state of the item since the scan
+	if r.protect(item) {
+		return
+	}
+
+	r.attempts[item]++
+
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("remove %s", item.Type),
+		tracing.String("cloud.region", item.Owner),
+		tracing.String("aws_nuke.resource_type", item.Type),
+		tracing.String("aws_nuke.resource", ResourceIdentifier(item)),
+		tracing.Int("aws_nuke.attempt", r.attempts[item]),
+	)
+
+	parent := r.activeSpan.Swap(span)
+	r.HandleRemove(ctx, item)
+	r.activeSpan.Store(parent)
+
+	switch item.GetState() {
+	case queue.ItemStateFailed:
+		span.SetError(errors.New(item.GetReason()))
+	case queue.ItemStateHold:
+		span.SetAttributes(tracing.String("aws_nuke.hold", item.GetReason()))
+	}
+	span.Finish()
+
+	for _, hook := range r.removeHooks {
+		hook(ctx, item, r.attempts[item])
+	}
+}
+
+// handleWaitDependency is the equivalent of (*libnuke.Nuke).HandleWaitDependency with the removal going through remove
+func (r *Runner) handleWaitDependency(ctx context.Context, item *queue.Item) {
+	depCount := 0
+	for _, dep := range dependsOn(item) {
+		depCount += r.Queue.CountByType(dep,
+			queue.ItemStateNew, queue.ItemStateNewDependency,
+			queue.ItemStatePending, queue.ItemStatePendingDependency,
+			queue.ItemStateWaiting, queue.ItemStateHold)
+	}
+
+	if depCount == 0 {
+		r.remove(ctx, item)
+		return
+	}
+
+	item.State = queue.ItemStatePendingDependency
+	item.Reason = fmt.Sprintf("left: %d", depCount)
+}
+
+// handleFailure is the equivalent of the libnuke failure handling, it errors once there are only failed resources
+// left and they have been retried twice.
+func (r *Runner) handleFailure() error {
+	printLog := r.log.WithField("_handler", "println")
+
+	processingCount := r.Queue.Count(queue.ItemStatePending, queue.ItemStatePendingDependency, queue.ItemStateHold,
+		queue.ItemStateWaiting, queue.ItemStateNew, queue.ItemStateNewDependency)
+
+	failedCount := r.Queue.Count(queue.ItemStateFailed)
+
+	if processingCount == 0 && failedCount > 0 {
+		if r.failedCount >= 2 {
+			printLog.Errorf("There are resources in failed state, but none are ready for deletion, anymore.")
+
+			for _, item := range r.Queue.GetItems() {
+				if item.GetState() != queue.ItemStateFailed {
+					continue
+				}
+
+				item.Print()
+				printLog.Error(item.GetReason())
+			}
+
+			return fmt.Errorf("failed")
+		}
+
+		r.failedCount++
+	} else {
+		r.failedCount = 0
+	}
+
+	return nil
+}
+
+// handleWaiting is the equivalent of the libnuke wait handling, it errors once MaxWaitRetries has been exceeded
+func (r *Runner) handleWaiting() error {
+	if r.Parameters.MaxWaitRetries == 0 {
+		return nil
+	}
+
+	pendingCount := r.Queue.Count(queue.ItemStateWaiting, queue.ItemStatePending,
+		queue.ItemStatePendingDependency, queue.ItemStateHold)
+
+	newCount := r.Queue.Count(queue.ItemStateNew, queue.ItemStateNewDependency)
+
+	if pendingCount > 0 && newCount == 0 {
+		if r.waitingCount >= r.Parameters.MaxWaitRetries {
+			return fmt.Errorf("max wait retries of %d exceeded", r.Parameters.MaxWaitRetries)
+		}
+		r.waitingCount++
+	} else {
+		r.waitingCount = 0
+	}
+
+	return nil
+}
+
+// dependsOn returns the resource types the item depends on
+func dependsOn(item *queue.Item) []string {
+	reg := registry.GetRegistration(item.Type)
+	if reg == nil {
+		return nil
+	}
+
+	return reg.DependsOn
+}
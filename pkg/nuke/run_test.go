@@ -0,0 +1,386 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/session" //nolint:staticcheck
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	libnuke "github.com/ekristen/libnuke/pkg/nuke"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
+)
+
+const testResourceType = "TestRunnerResource"
+
+// testResources are the resources that currently exist, removing a resource deletes it from the map
+var testResources = map[string]bool{}
+
+type testResource struct {
+	Name string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	delete(testResources, r.Name)
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.Name).Set("tag:Name", r.Name)
+}
+
+func (r *testResource) String() string {
+	return r.Name
+}
+
+type testLister struct{}
+
+func (l *testLister) List(ctx context.Context, o interface{}) ([]resource.Resource, error) {
+	opts := o.(*ListerOpts)
+	if opts.Session == nil || opts.Config == nil {
+		panic("lister options were not mutated")
+	}
+
+	var resources []resource.Resource
+	for name := range testResources {
+		resources = append(resources, &testResource{Name: name})
+	}
+
+	return resources, nil
+}
+
+const testFailingResourceType = "TestRunnerFailingResource"
+
+type testFailingLister struct{}
+
+func (l *testFailingLister) List(_ context.Context, _ interface{}) ([]resource.Resource, error) {
+	return nil, errors.New("access denied")
+}
+
+func init() {
+	registry.Register(&registry.Registration{
+		Name:   testResourceType,
+		Scope:  Account,
+		Lister: &testLister{},
+	})
+
+	registry.Register(&registry.Registration{
+		Name:   testFailingResourceType,
+		Scope:  Account,
+		Lister: &testFailingLister{},
+	})
+}
+
+type spanRecorder struct {
+	spans []*tracing.Span
+}
+
+func (e *spanRecorder) Export(_ context.Context, _ *tracing.Resource, spans []*tracing.Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *spanRecorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (e *spanRecorder) byName(name string) *tracing.Span {
+	for _, s := range e.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func newTestRunner(t *testing.T, noDryRun bool) *Runner {
+	t.Helper()
+
+	testResources = map[string]bool{"keep": true, "remove-1": true, "remove-2": true}
+
+	n := NewRunner(libnuke.New(&libnuke.Parameters{
+		Force:      true,
+		ForceSleep: 3,
+		NoDryRun:   noDryRun,
+	}, filter.Filters{
+		testResourceType: []filter.Filter{{Property: "Name", Type: filter.Exact, Value: "keep"}},
+	}, nil))
+
+	n.SetLogger(logrus.NewEntry(logrus.New()))
+	n.SetRunSleep(time.Millisecond)
+	n.RegisterPrompt(func() error { return nil })
+
+	region := NewRegion("us-east-1",
+		func(_, _ string) string { return "test" },
+		func(_, _ string) (*session.Session, error) { return session.NewSession() },
+		func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+	)
+
+	assert.NoError(t, n.RegisterScanner(&Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testResourceType},
+		Opts:          &ListerOpts{Region: region},
+	}))
+
+	return n
+}
+
+func TestRunner_Run(t *testing.T) {
+	n := newTestRunner(t, true)
+
+	var scanned, iterations, removals int
+	n.RegisterScanHook(func(q *queue.Queue) error {
+		scanned = q.Total()
+		return nil
+	})
+	n.RegisterIterationHook(func(iteration int, _ *queue.Queue) error {
+		iterations = iteration
+		return nil
+	})
+	n.RegisterRemoveHook(func(_ context.Context, item *queue.Item, attempt int) {
+		assert.Equal(t, 1, attempt)
+		assert.Equal(t, queue.ItemStatePending, item.GetState())
+		removals++
+	})
+
+	assert.NoError(t, n.Run(context.Background()))
+
+	assert.Equal(t, 3, scanned)
+	assert.Equal(t, 2, removals)
+	assert.GreaterOrEqual(t, iterations, 2)
+	assert.Equal(t, map[string]bool{"keep": true}, testResources)
+	assert.Equal(t, 2, n.Queue.Count(queue.ItemStateFinished))
+	assert.Equal(t, 1, n.Queue.Count(queue.ItemStateFiltered))
+
+	for _, item := range n.Queue.GetItems() {
+		assert.Equal(t, "us-east-1", item.Owner)
+		assert.NotNil(t, item.Opts)
+	}
+}
+
+func TestRunner_DryRun(t *testing.T) {
+	n := newTestRunner(t, false)
+
+	iterations := 0
+	n.RegisterIterationHook(func(_ int, _ *queue.Queue) error {
+		iterations++
+		return nil
+	})
+
+	assert.NoError(t, n.Run(context.Background()))
+
+	assert.Equal(t, 0, iterations)
+	assert.Len(t, testResources, 3)
+	assert.Equal(t, 2, n.Queue.Count(queue.ItemStateNew))
+}
+
+func TestRunner_ProtectTag(t *testing.T) {
+	n := newTestRunner(t, true)
+	n.SetProtectTag(&ProtectTag{Key: "name", Value: "REMOVE-1"})
+
+	// an item that is made removable again after the scan is still protected when it is about to be removed
+	n.RegisterScanHook(func(q *queue.Queue) error {
+		for _, item := range q.GetItems() {
+			if IsProtected(item) {
+				item.State = queue.ItemStateNew
+			}
+		}
+		return nil
+	})
+
+	assert.NoError(t, n.Run(context.Background()))
+
+	assert.Equal(t, map[string]bool{"keep": true, "remove-1": true}, testResources)
+	assert.Equal(t, 1, n.Queue.Count(queue.ItemStateFinished))
+
+	for _, item := range n.Queue.GetItems() {
+		if item.Resource.(*testResource).Name == "remove-1" {
+			assert.True(t, IsProtected(item))
+			assert.Equal(t, "protected by protect-tag name=REMOVE-1", item.GetReason())
+			assert.Equal(t, 0, n.Attempts(item))
+		}
+	}
+}
+
+func TestScanner_List(t *testing.T) {
+	testResources = map[string]bool{"keep": true, "remove-1": true}
+
+	region := NewRegion("us-east-1",
+		func(_, _ string) string { return "test" },
+		func(_, _ string) (*session.Session, error) { return session.NewSession() },
+		func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+	)
+
+	s := &Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testResourceType},
+		Opts:          &ListerOpts{Region: region},
+	}
+
+	items, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	for _, item := range items {
+		assert.Equal(t, queue.ItemStateNew, item.GetState())
+		assert.Equal(t, "us-east-1", item.Owner)
+	}
+
+	assert.Len(t, testResources, 2)
+}
+
+func TestScanner_Inventory(t *testing.T) {
+	s := &Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testResourceType, "Missing"},
+		Inventory: map[string][]resource.Resource{
+			testResourceType: {&testResource{Name: "keep"}, &testResource{Name: "remove-1"}},
+			"Missing":        {},
+		},
+	}
+
+	items, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	for _, item := range items {
+		assert.Equal(t, queue.ItemStateNew, item.GetState())
+		assert.Equal(t, testResourceType, item.Type)
+		assert.Equal(t, "us-east-1", item.Owner)
+	}
+}
+
+func TestScanner_PartialInventory(t *testing.T) {
+	testResources = map[string]bool{"listed": true}
+
+	region := NewRegion("us-east-1",
+		func(_, _ string) string { return "test" },
+		func(_, _ string) (*session.Session, error) { return session.NewSession() },
+		func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+	)
+
+	// the resource types that are not in the inventory are listed
+	s := &Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testResourceType, "Cached"},
+		Opts:          &ListerOpts{Region: region},
+		Inventory: map[string][]resource.Resource{
+			"Cached": {&testResource{Name: "cached"}},
+		},
+	}
+
+	items, err := s.List(context.Background())
+	assert.NoError(t, err)
+
+	byName := map[string]string{}
+	for _, item := range items {
+		byName[item.Resource.(*testResource).Name] = item.Type
+	}
+	assert.Equal(t, map[string]string{"listed": testResourceType, "cached": "Cached"}, byName)
+}
+
+func TestScanner_Incomplete(t *testing.T) {
+	testResources = map[string]bool{"keep": true, "remove-1": true, "remove-2": true}
+
+	region := NewRegion("us-east-1",
+		func(_, _ string) string { return "test" },
+		func(_, _ string) (*session.Session, error) { return session.NewSession() },
+		func(_ context.Context, _, _ string) (*awsv2.Config, error) { return &awsv2.Config{}, nil },
+	)
+
+	s := &Scanner{
+		Owner:         "us-east-1",
+		ResourceTypes: []string{testResourceType, testFailingResourceType},
+		Opts:          &ListerOpts{Region: region},
+	}
+
+	items, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	incomplete := s.Incomplete()
+	assert.Len(t, incomplete, 1)
+	assert.ErrorContains(t, incomplete[testFailingResourceType], "access denied")
+
+	// a queue that is too small cuts the listing short
+	s.ResourceTypes = []string{testResourceType}
+	s.QueueSize = 2
+
+	items, err = s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, map[string]error{testResourceType: errQueueFull}, s.Incomplete())
+}
+
+func TestRunner_Resumed(t *testing.T) {
+	n := newTestRunner(t, true)
+
+	// resources that were moved to the waiting state are verified instead of removed, as they still exist the run
+	// eventually gives up waiting for them
+	removals := 0
+	n.RegisterScanHook(func(q *queue.Queue) error {
+		for _, item := range q.GetItems() {
+			if item.GetState() == queue.ItemStateNew {
+				item.State = queue.ItemStateWaiting
+			}
+		}
+		return nil
+	})
+	n.RegisterRemoveHook(func(_ context.Context, _ *queue.Item, _ int) {
+		removals++
+	})
+
+	n.Parameters.MaxWaitRetries = 2
+
+	err := n.Run(context.Background())
+	assert.ErrorContains(t, err, "max wait retries")
+	assert.Equal(t, 0, removals)
+	assert.Len(t, testResources, 3)
+}
+
+func TestRunner_Tracing(t *testing.T) {
+	recorder := &spanRecorder{}
+	tracer := tracing.NewTracer(&tracing.Resource{ServiceName: "aws-nuke"}, logrus.NewEntry(logrus.New()), recorder)
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(nil)
+
+	n := newTestRunner(t, true)
+
+	ctx, root := tracing.Start(context.Background(), "nuke account")
+	assert.NoError(t, n.Run(ctx))
+	root.Finish()
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	scan := recorder.byName("scan")
+	region := recorder.byName("scan us-east-1")
+	list := recorder.byName("list " + testResourceType)
+	remove := recorder.byName("remove")
+	iteration := recorder.byName("iteration 1")
+	removal := recorder.byName("remove " + testResourceType)
+
+	for _, s := range []*tracing.Span{scan, region, list, remove, iteration, removal} {
+		if assert.NotNil(t, s) {
+			assert.Equal(t, root.TraceID, s.TraceID)
+		}
+	}
+
+	assert.Equal(t, root.SpanID, scan.ParentID)
+	assert.Equal(t, scan.SpanID, region.ParentID)
+	assert.Equal(t, region.SpanID, list.ParentID)
+	assert.Equal(t, root.SpanID, remove.ParentID)
+	assert.Equal(t, remove.SpanID, iteration.ParentID)
+	assert.Equal(t, iteration.SpanID, removal.ParentID)
+}
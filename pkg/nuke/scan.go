@@ -0,0 +1,426 @@
+package nuke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/aws/aws-sdk-go/aws/request" //nolint:staticcheck
+	"github.com/aws/smithy-go/middleware"
+
+	liberrors "github.com/ekristen/libnuke/pkg/errors"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/scanner"
+	"github.com/ekristen/libnuke/pkg/utils"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/tracing"
+)
+
+// Scanner lists the resources of a set of resource types for a single region. It is the equivalent of the libnuke
+// scanner, which does not offer a way to hook into the listing of the individual resource types. It differs from it in
+// that the items are returned instead of sent over a channel, that the resource types whose listing failed or did not
+// fit in the queue are recorded, see Incomplete, and that resource types can be taken from an Inventory.
+type Scanner struct {
+	Owner           string
+	ResourceTypes   []string
+	Opts            *ListerOpts
+	ParallelQueries int64
+	QueueSize       int
+	Logger          *logrus.Logger
+
+	// Inventory holds previously listed resources by resource type. The resource types in it are taken from it
+	// instead of their listers, no session is created and nothing is requested from AWS for them.
+	Inventory map[string][]resource.Resource
+
+	// incomplete holds the resource types of the last scan whose listing failed or did not fit in the queue
+	incomplete   map[string]error
+	incompleteMu sync.Mutex
+}
+
+// RegisterScanner registers a scanner, the scanners are run in the order they are registered
+func (r *Runner) RegisterScanner(s *Scanner) error {
+	for _, existing := range r.scanners {
+		if existing.Owner == s.Owner {
+			return fmt.Errorf("scanner is already registered, you cannot register it twice")
+		}
+	}
+
+	s.setDefaults()
+	r.scanners = append(r.scanners, s)
+
+	return nil
+}
+
+// List runs the scanner on its own and returns every resource it found. Nothing is filtered and no libnuke instance
+// is involved, there is no way for a resource to be removed, which makes it safe to use with read-only credentials.
+func (s *Scanner) List(ctx context.Context) ([]*queue.Item, error) {
+	s.setDefaults()
+
+	return (&Runner{}).runScanner(ctx, s)
+}
+
+// Incomplete returns the resource types of the last scan whose resources were not all listed, because their lister
+// failed or the queue was full, together with the reason. Their items are not a complete picture of the region.
+func (s *Scanner) Incomplete() map[string]error {
+	s.incompleteMu.Lock()
+	defer s.incompleteMu.Unlock()
+
+	incomplete := make(map[string]error, len(s.incomplete))
+	for resourceType, err := range s.incomplete {
+		incomplete[resourceType] = err
+	}
+
+	return incomplete
+}
+
+// setIncomplete records that the resources of the resource type were not all listed
+func (s *Scanner) setIncomplete(resourceType string, err error) {
+	s.incompleteMu.Lock()
+	defer s.incompleteMu.Unlock()
+
+	if _, ok := s.incomplete[resourceType]; !ok {
+		s.incomplete[resourceType] = err
+	}
+}
+
+func (s *Scanner) setDefaults() {
+	if s.ParallelQueries <= 0 {
+		s.ParallelQueries = scanner.DefaultParallelQueries
+	}
+
+	if s.QueueSize <= 0 {
+		s.QueueSize = scanner.DefaultQueueSize
+	}
+
+	if s.Logger == nil {
+		s.Logger = logrus.StandardLogger()
+	}
+}
+
+// Scan runs all registered scanners, filters the resources that were found and places them on the queue. It is the
+// equivalent of (*libnuke.Nuke).Scan, except that protected items are filtered before the configured filters, that
+// the item filters are applied after them and that the protected items are counted separately.
+func (r *Runner) Scan(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "scan")
+	defer span.Finish()
+
+	itemQueue := queue.New()
+
+	for _, s := range r.scanners {
+		items, err := r.runScanner(ctx, s)
+		if err != nil {
+			span.SetError(err)
+			return err
+		}
+
+		for _, item := range items {
+			// Experimental Feature
+			if r.Parameters.WaitOnDependencies {
+				reg := registry.GetRegistration(item.Type)
+				if len(reg.DependsOn) > 0 {
+					item.State = queue.ItemStateNewDependency
+				}
+			}
+
+			sGetter, ok := item.Resource.(resource.SettingsGetter)
+			if ok {
+				sGetter.Settings(r.Settings.Get(item.Type))
+			}
+
+			itemQueue.Items = append(itemQueue.Items, item)
+
+			// The protect tag overrides everything else, the filters are not even evaluated for protected items
+			if r.protect(item) {
+				if !r.Parameters.Quiet {
+					item.Print()
+				}
+				continue
+			}
+
+			if err := r.Filter(item); err != nil {
+				span.SetError(err)
+				return err
+			}
+
+			r.applyItemFilters(item)
+
+			// If quiet and filtered, skip printing to screen
+			if r.Parameters.Quiet && item.State == queue.ItemStateFiltered {
+				continue
+			}
+
+			item.Print()
+		}
+	}
+
+	nukeable := itemQueue.Count(queue.ItemStateNew, queue.ItemStateNewDependency)
+	filtered := itemQueue.Count(queue.ItemStateFiltered)
+
+	protected := 0
+	for _, item := range itemQueue.Items {
+		if IsProtected(item) {
+			protected++
+		}
+	}
+	filtered -= protected
+
+	span.SetAttributes(
+		tracing.Int("aws_nuke.resources.total", itemQueue.Total()),
+		tracing.Int("aws_nuke.resources.nukeable", nukeable),
+		tracing.Int("aws_nuke.resources.filtered", filtered),
+		tracing.Int("aws_nuke.resources.protected", protected),
+	)
+
+	printLog := r.log.WithField("_handler", "println").
+		WithFields(logrus.Fields{
+			"total":    itemQueue.Total(),
+			"nukeable": nukeable,
+			"filtered": filtered,
+		})
+
+	if r.protectTag != nil {
+		printLog.WithField("protected", protected).
+			Infof("Scan complete: %d total, %d nukeable, %d filtered, %d protected.\n",
+				itemQueue.Total(), nukeable, filtered, protected)
+	} else {
+		printLog.Infof("Scan complete: %d total, %d nukeable, %d filtered.\n", itemQueue.Total(), nukeable, filtered)
+	}
+
+	r.Queue = itemQueue
+
+	return nil
+}
+
+// protect filters the item when it has the protect tag, and returns whether it did
+func (r *Runner) protect(item *queue.Item) bool {
+	if r.protectTag == nil || !r.protectTag.Protected(item.Resource) {
+		return false
+	}
+
+	item.State = queue.ItemStateFiltered
+	item.Reason = r.protectTag.Reason()
+
+	return true
+}
+
+// applyItemFilters filters the item with the first registered item filter that returns a reason
+func (r *Runner) applyItemFilters(item *queue.Item) {
+	for _, f := range r.itemFilters {
+		state := item.GetState()
+		if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+			return
+		}
+
+		if reason := f(item); reason != "" {
+			item.State = queue.ItemStateFiltered
+			item.Reason = reason
+		}
+	}
+}
+
+// runScanner lists all resource types of the scanner with at most ParallelQueries listers running at a time. Unlike
+// (*scanner.Scanner).Run, every lister gets its own copy of the options and the queue size applies to the items of
+// all resource types together rather than to a buffered channel, the resource types that did not fit are incomplete.
+func (r *Runner) runScanner(ctx context.Context, s *Scanner) ([]*queue.Item, error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("scan %s", s.Owner),
+		tracing.String("cloud.region", s.Owner),
+		tracing.Int("aws_nuke.resource_types", len(s.ResourceTypes)),
+	)
+	defer span.Finish()
+
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		items       []*queue.Item
+		fullWarning sync.Once
+	)
+
+	s.incompleteMu.Lock()
+	s.incomplete = make(map[string]error)
+	s.incompleteMu.Unlock()
+
+	sem := make(chan struct{}, s.ParallelQueries)
+
+	for _, resourceType := range s.ResourceTypes {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			span.SetError(ctx.Err())
+			return nil, ctx.Err()
+		}
+
+		// every lister gets its own copy of the options, so they do not overwrite each other's session
+		var opts *ListerOpts
+		if _, ok := s.Inventory[resourceType]; !ok {
+			opts = MutateOpts(s.Opts.copy(), resourceType).(*ListerOpts)
+		}
+
+		wg.Add(1)
+		go func(resourceType string, opts *ListerOpts) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			listed, err := r.list(ctx, s, resourceType, opts)
+			if err != nil {
+				s.setIncomplete(resourceType, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, item := range listed {
+				if len(items) >= s.QueueSize {
+					fullWarning.Do(func() {
+						s.Logger.WithField("owner", s.Owner).Warn("item queue is full, not all resources will be enqueued")
+					})
+					s.setIncomplete(resourceType, errQueueFull)
+					return
+				}
+
+				items = append(items, item)
+			}
+		}(resourceType, opts)
+	}
+
+	wg.Wait()
+
+	span.SetAttributes(tracing.Int("aws_nuke.resources.total", len(items)))
+
+	return items, nil
+}
+
+// errQueueFull is the reason a resource type is incomplete when not all of its resources fit in the queue
+var errQueueFull = errors.New("item queue is full")
+
+// list runs the lister of a single resource type and wraps the resources in queue items. It returns an error when the
+// resources could not be listed, a request that is skipped because the service is not available is not an error.
+// Unlike the libnuke scanner it recovers from panics whose value is not an error, and it traces the lister.
+func (r *Runner) list(
+	ctx context.Context, s *Scanner, resourceType string, opts *ListerOpts,
+) (items []*queue.Item, listErr error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("list %s", resourceType),
+		tracing.String("cloud.region", s.Owner),
+		tracing.String("aws_nuke.resource_type", resourceType),
+	)
+	defer span.Finish()
+
+	logger := s.Logger.WithField("resource_type", resourceType).WithField("owner", s.Owner)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			err := fmt.Errorf("%v\n\n%s", rec, string(debug.Stack()))
+			dump := utils.Indent(fmt.Sprintf("%v", err), "    ")
+			logger.Errorf("listing failed:\n%s", dump)
+			span.SetError(err)
+			items = nil
+			listErr = err
+		}
+	}()
+
+	if rs, ok := s.Inventory[resourceType]; ok {
+		span.SetAttributes(tracing.Int("aws_nuke.resources.total", len(rs)))
+		return newItems(s, resourceType, opts, rs), nil
+	}
+
+	lister := registry.GetLister(resourceType)
+	if lister == nil {
+		err := fmt.Errorf("lister for resource type not found")
+		logger.Error(err.Error())
+		span.SetError(err)
+		return nil, err
+	}
+
+	if tracing.Enabled() {
+		r.traceOpts(opts, span)
+	}
+
+	logger.Debug("attempting to run lister")
+
+	rs, err := lister.List(ctx, opts)
+	if err != nil {
+		var errSkipRequest liberrors.ErrSkipRequest
+		var errUnknownEndpoint liberrors.ErrUnknownEndpoint
+		if errors.As(err, &errSkipRequest) || errors.As(err, &errUnknownEndpoint) {
+			logger.Debugf("skipping request: %v", err)
+			span.SetAttributes(tracing.Bool("aws_nuke.skipped", true))
+			return nil, nil
+		}
+
+		dump := utils.Indent(fmt.Sprintf("%v", err), "    ")
+		logger.WithError(err).Errorf("listing failed:\n%s", dump)
+		span.SetError(err)
+		return nil, err
+	}
+
+	logger.WithField("count", len(rs)).Debugf("listing complete")
+	span.SetAttributes(tracing.Int("aws_nuke.resources.total", len(rs)))
+
+	return newItems(s, resourceType, opts, rs), nil
+}
+
+// newItems wraps the resources in queue items
+func newItems(s *Scanner, resourceType string, opts *ListerOpts, rs []resource.Resource) (items []*queue.Item) {
+	for _, res := range rs {
+		i := &queue.Item{
+			Resource: res,
+			State:    queue.ItemStateNew,
+			Type:     resourceType,
+			Owner:    s.Owner,
+			Opts:     opts,
+			Logger:   s.Logger,
+		}
+
+		itemHook, ok := res.(resource.QueueItemHook)
+		if ok {
+			itemHook.BeforeEnqueue(i)
+		}
+
+		items = append(items, i)
+	}
+
+	return items
+}
+
+// traceOpts gives the lister its own copy of the session and config, so that API calls which are made without a
+// context, which is the case for most SDK v1 calls, are still recorded as children of the lister. When the same
+// clients are later used to remove the resource, the calls are recorded as children of the removal instead.
+func (r *Runner) traceOpts(opts *ListerOpts, listSpan *tracing.Span) {
+	withParent := func(ctx context.Context) context.Context {
+		if tracing.SpanFromContext(ctx) != nil {
+			return ctx
+		}
+
+		if active := r.activeSpan.Load(); active != nil {
+			return tracing.ContextWithSpan(ctx, active)
+		}
+
+		return tracing.ContextWithSpan(ctx, listSpan)
+	}
+
+	if opts.Session != nil {
+		opts.Session = opts.Session.Copy()
+		opts.Session.Handlers.Validate.PushFront(func(req *request.Request) {
+			req.SetContext(withParent(req.Context()))
+		})
+	}
+
+	if opts.Config != nil {
+		cfg := opts.Config.Copy()
+		cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("aws-nuke::traceParent",
+				func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+					middleware.InitializeOutput, middleware.Metadata, error,
+				) {
+					return next.HandleInitialize(withParent(ctx), in)
+				}), middleware.Before)
+		})
+		opts.Config = &cfg
+	}
+}
@@ -0,0 +1,211 @@
+// Package plan provides a way to save the resolved list of resources from a dry run so that exactly those resources,
+// and nothing else, can be removed at a later point in time.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+const (
+	// ReasonNotInPlan is the reason given to resources that were discovered during apply but were not part of the plan
+	ReasonNotInPlan = "not in plan"
+
+	// ReasonAmbiguous is the reason given to resources that were discovered during apply but cannot be told apart
+	// from another resource that matches the same planned resource
+	ReasonAmbiguous = "ambiguous in plan"
+)
+
+// Resource is a single resource that is planned to be removed
+type Resource struct {
+	Type       string `json:"type"`
+	Region     string `json:"region"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name,omitempty"`
+
+	// Key tells the resource apart from all other resources of its type in its region, see nuke.ResourceKey
+	Key string `json:"key"`
+}
+
+func (r *Resource) key() string {
+	return fmt.Sprintf("%s|%s|%s", r.Type, r.Region, r.Key)
+}
+
+// Plan is the list of resources that would be removed for an account with a given configuration and tool version
+type Plan struct {
+	AccountID  string      `json:"account_id"`
+	ConfigHash string      `json:"config_hash"`
+	Version    string      `json:"version"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Resources  []*Resource `json:"resources"`
+
+	index map[string]*Resource
+}
+
+// New creates a new empty Plan
+func New(accountID, configHash, version string) *Plan {
+	return &Plan{
+		AccountID:  accountID,
+		ConfigHash: configHash,
+		Version:    version,
+		CreatedAt:  time.Now().UTC(),
+		Resources:  make([]*Resource, 0),
+		index:      make(map[string]*Resource),
+	}
+}
+
+// Load reads a plan from disk
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("unable to parse plan %s: %w", path, err)
+	}
+
+	p.index = make(map[string]*Resource)
+	for _, r := range p.Resources {
+		p.index[r.key()] = r
+	}
+
+	return p, nil
+}
+
+// Save writes the plan to disk
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// Validate ensures the plan was created for the same account, configuration and tool version
+func (p *Plan) Validate(accountID, configHash, version string) error {
+	if p.AccountID != accountID {
+		return fmt.Errorf("plan was created for account '%s', but the current account is '%s'",
+			p.AccountID, accountID)
+	}
+
+	if p.ConfigHash != configHash {
+		return fmt.Errorf("plan was created with a different configuration file (hash mismatch)")
+	}
+
+	if p.Version != version {
+		return fmt.Errorf("plan was created with version '%s', but the current version is '%s'",
+			p.Version, version)
+	}
+
+	return nil
+}
+
+// Add adds all items that would be removed to the plan, all other items are ignored. Items that share their key with
+// another item cannot be told apart when the plan is applied, they are left out of the plan and returned instead.
+func (p *Plan) Add(items []*queue.Item) (ambiguous []*Resource) {
+	resources := make(map[string][]*Resource)
+	var keys []string
+
+	for _, item := range items {
+		state := item.GetState()
+		if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+			continue
+		}
+
+		r := resourceFromItem(item)
+		if _, ok := resources[r.key()]; !ok {
+			keys = append(keys, r.key())
+		}
+		resources[r.key()] = append(resources[r.key()], r)
+	}
+
+	for _, key := range keys {
+		if len(resources[key]) > 1 {
+			ambiguous = append(ambiguous, resources[key]...)
+			continue
+		}
+
+		if _, ok := p.index[key]; ok {
+			continue
+		}
+
+		p.index[key] = resources[key][0]
+		p.Resources = append(p.Resources, resources[key][0])
+	}
+
+	return ambiguous
+}
+
+// Restrict marks every item that would be removed, but that is not part of the plan, as filtered. A planned resource
+// that more than one item matches cannot be told apart from the others, all of them are filtered as well. It returns
+// the number of items that were excluded and the planned resources that could no longer be found.
+func (p *Plan) Restrict(items []*queue.Item) (excluded int, missing []*Resource) {
+	matches := make(map[string][]*queue.Item)
+
+	for _, item := range items {
+		r := resourceFromItem(item)
+		if _, ok := p.index[r.key()]; ok {
+			matches[r.key()] = append(matches[r.key()], item)
+			continue
+		}
+
+		state := item.GetState()
+		if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+			continue
+		}
+
+		item.State = queue.ItemStateFiltered
+		item.Reason = ReasonNotInPlan
+		excluded++
+	}
+
+	for _, r := range p.Resources {
+		matched := matches[r.key()]
+		if len(matched) == 0 {
+			missing = append(missing, r)
+			continue
+		}
+
+		if len(matched) == 1 {
+			continue
+		}
+
+		for _, item := range matched {
+			state := item.GetState()
+			if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+				continue
+			}
+
+			item.State = queue.ItemStateFiltered
+			item.Reason = ReasonAmbiguous
+			excluded++
+		}
+	}
+
+	return excluded, missing
+}
+
+func resourceFromItem(item *queue.Item) *Resource {
+	r := &Resource{
+		Type:       item.Type,
+		Region:     item.Owner,
+		Identifier: nuke.ResourceIdentifier(item),
+		Key:        nuke.ResourceKey(item),
+	}
+
+	if stringer, ok := item.Resource.(resource.LegacyStringer); ok {
+		r.Name = stringer.String()
+	}
+
+	return r
+}
@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	ID string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) String() string {
+	return r.ID
+}
+
+// testLayer is a resource whose String() is only its name, which all of its versions share
+type testLayer struct {
+	Name    string
+	Version string
+}
+
+func (r *testLayer) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLayer) String() string {
+	return r.Name
+}
+
+func (r *testLayer) Properties() types.Properties {
+	return types.NewProperties().Set("Name", r.Name).Set("Version", r.Version)
+}
+
+func newLayer(name, version string) *queue.Item {
+	return &queue.Item{
+		Resource: &testLayer{Name: name, Version: version},
+		State:    queue.ItemStateNew,
+		Type:     "TestLayer",
+		Owner:    "us-east-1",
+	}
+}
+
+func newItem(id, region string, state queue.ItemState) *queue.Item {
+	return &queue.Item{
+		Resource: &testResource{ID: id},
+		State:    state,
+		Type:     "TestResource",
+		Owner:    region,
+	}
+}
+
+func TestPlan_AddSaveLoad(t *testing.T) {
+	p := New("123456789012", "abc", "test")
+	ambiguous := p.Add([]*queue.Item{
+		newItem("one", "us-east-1", queue.ItemStateNew),
+		newItem("two", "us-east-1", queue.ItemStateNewDependency),
+		newItem("three", "us-east-1", queue.ItemStateFiltered),
+		newItem("four", "us-east-1", queue.ItemStateNew),
+		newItem("four", "us-east-1", queue.ItemStateNew), // cannot be told apart
+	})
+
+	assert.Len(t, p.Resources, 2)
+	assert.Len(t, ambiguous, 2)
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	assert.NoError(t, p.Save(path))
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, p.AccountID, loaded.AccountID)
+	assert.Len(t, loaded.Resources, 2)
+
+	// the loaded plan matches the resources by their region as well
+	elsewhere := newItem("one", "us-west-2", queue.ItemStateNew)
+	excluded, missing := loaded.Restrict([]*queue.Item{newItem("one", "us-east-1", queue.ItemStateNew), elsewhere})
+	assert.Equal(t, 1, excluded)
+	assert.Equal(t, ReasonNotInPlan, elsewhere.GetReason())
+	assert.Len(t, missing, 1)
+}
+
+func TestPlan_Validate(t *testing.T) {
+	p := New("123456789012", "abc", "v1")
+
+	assert.NoError(t, p.Validate("123456789012", "abc", "v1"))
+	assert.ErrorContains(t, p.Validate("000000000000", "abc", "v1"), "account")
+	assert.ErrorContains(t, p.Validate("123456789012", "def", "v1"), "configuration")
+	assert.ErrorContains(t, p.Validate("123456789012", "abc", "v2"), "version")
+}
+
+func TestPlan_Restrict(t *testing.T) {
+	p := New("123456789012", "abc", "test")
+	p.Add([]*queue.Item{
+		newItem("one", "us-east-1", queue.ItemStateNew),
+		newItem("gone", "us-east-1", queue.ItemStateNew),
+	})
+
+	planned := newItem("one", "us-east-1", queue.ItemStateNew)
+	unplanned := newItem("new", "us-east-1", queue.ItemStateNew)
+	filtered := newItem("kept", "us-east-1", queue.ItemStateFiltered)
+
+	excluded, missing := p.Restrict([]*queue.Item{planned, unplanned, filtered})
+
+	assert.Equal(t, 1, excluded)
+	assert.Len(t, missing, 1)
+	assert.Equal(t, "gone", missing[0].Identifier)
+
+	assert.Equal(t, queue.ItemStateNew, planned.GetState())
+	assert.Equal(t, queue.ItemStateFiltered, unplanned.GetState())
+	assert.Equal(t, ReasonNotInPlan, unplanned.GetReason())
+	assert.Equal(t, "", filtered.GetReason())
+}
+
+func TestPlan_SharedName(t *testing.T) {
+	p := New("123456789012", "abc", "test")
+	assert.Empty(t, p.Add([]*queue.Item{newLayer("shared", "1"), newLayer("shared", "2")}))
+	assert.Len(t, p.Resources, 2)
+
+	// a version that was published after the plan was written shares the name, but was never reviewed
+	planned := newLayer("shared", "1")
+	published := newLayer("shared", "3")
+
+	excluded, missing := p.Restrict([]*queue.Item{planned, published})
+	assert.Equal(t, 1, excluded)
+	assert.Len(t, missing, 1)
+	assert.Equal(t, queue.ItemStateNew, planned.GetState())
+	assert.Equal(t, queue.ItemStateFiltered, published.GetState())
+	assert.Equal(t, ReasonNotInPlan, published.GetReason())
+}
+
+func TestPlan_RestrictAmbiguous(t *testing.T) {
+	p := New("123456789012", "abc", "test")
+	p.Add([]*queue.Item{newItem("one", "us-east-1", queue.ItemStateNew)})
+
+	// two resources match the planned one, neither of them is removed
+	first := newItem("one", "us-east-1", queue.ItemStateNew)
+	second := newItem("one", "us-east-1", queue.ItemStateNew)
+
+	excluded, missing := p.Restrict([]*queue.Item{first, second})
+	assert.Equal(t, 2, excluded)
+	assert.Empty(t, missing)
+	assert.Equal(t, ReasonAmbiguous, first.GetReason())
+	assert.Equal(t, ReasonAmbiguous, second.GetReason())
+}
@@ -0,0 +1,367 @@
+// Package report provides a machine-readable record of every resource that was discovered during a run, what
+// happened to it and why. It is built from the libnuke queue once the run has completed.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/cost"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// Format is the output format of the report
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// Formats is the list of supported report formats
+var Formats = []Format{FormatJSON, FormatCSV, FormatNDJSON}
+
+// ParseFormat converts a string into a Format, returning an error if the format is not supported
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported report format '%s', must be one of: json, csv, ndjson", s)
+}
+
+// State is the final state of a resource as recorded in the report
+type State string
+
+const (
+	StateWouldRemove State = "would-remove"
+	StateRemoved     State = "removed"
+	StateFailed      State = "failed"
+	StateFiltered    State = "filtered"
+
+	// StatePending is used for resources where removal was triggered but the run ended before it was confirmed
+	StatePending State = "pending"
+
+	// StateProtected is used for resources that the protect tag kept, whatever the filters say
+	StateProtected State = "protected"
+)
+
+// StateFromItem converts the libnuke queue item state into a report State
+func StateFromItem(state queue.ItemState) State {
+	switch state {
+	case queue.ItemStateNew, queue.ItemStateNewDependency:
+		return StateWouldRemove
+	case queue.ItemStateFinished:
+		return StateRemoved
+	case queue.ItemStateFailed:
+		return StateFailed
+	case queue.ItemStateFiltered:
+		return StateFiltered
+	default:
+		return StatePending
+	}
+}
+
+// StateOf returns the report State of the item, resources that the protect tag kept are protected rather than filtered
+func StateOf(item *queue.Item) State {
+	if nuke.IsProtected(item) {
+		return StateProtected
+	}
+
+	return StateFromItem(item.GetState())
+}
+
+// Record is a single resource entry in the report
+type Record struct {
+	Account      string            `json:"account"`
+	Region       string            `json:"region"`
+	ResourceType string            `json:"resource_type"`
+	Name         string            `json:"name,omitempty"`
+	CreatedAt    *time.Time        `json:"created_at,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	State        State             `json:"state"`
+	Filter       string            `json:"filter,omitempty"`
+	Reason       string            `json:"reason,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	MonthlyCost  *float64          `json:"monthly_cost,omitempty"`
+	ScannedAt    time.Time         `json:"scanned_at"`
+
+	// UpdatedAt is when the state of the resource last changed, it is the scan time if the state never changed
+	UpdatedAt time.Time `json:"updated_at"`
+
+	item *queue.Item
+}
+
+// Report is the full report for a single run against a single account
+type Report struct {
+	AccountID    string    `json:"account_id"`
+	AccountAlias string    `json:"account_alias"`
+	Version      string    `json:"version"`
+	DryRun       bool      `json:"dry_run"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Error        string    `json:"error,omitempty"`
+	Resources    []*Record `json:"resources"`
+
+	// Cost is the estimated monthly cost of the resources that would be or were removed, if it was requested
+	Cost *cost.Estimate `json:"cost,omitempty"`
+
+	scannedAt map[*queue.Item]time.Time
+	updatedAt map[*queue.Item]time.Time
+	states    map[*queue.Item]State
+}
+
+// New creates a new Report for the given account, the start time of the run is set to now
+func New(accountID, accountAlias, version string, dryRun bool) *Report {
+	return &Report{
+		AccountID:    accountID,
+		AccountAlias: accountAlias,
+		Version:      version,
+		DryRun:       dryRun,
+		StartedAt:    time.Now().UTC(),
+		Resources:    make([]*Record, 0),
+		scannedAt:    make(map[*queue.Item]time.Time),
+		updatedAt:    make(map[*queue.Item]time.Time),
+		states:       make(map[*queue.Item]State),
+	}
+}
+
+// MarkScanned records the time at which the items were discovered. Items that are already marked are left untouched.
+func (r *Report) MarkScanned(items []*queue.Item) {
+	now := time.Now().UTC()
+	for _, item := range items {
+		if _, ok := r.scannedAt[item]; !ok {
+			r.scannedAt[item] = now
+			r.updatedAt[item] = now
+			r.states[item] = StateOf(item)
+		}
+	}
+}
+
+// MarkUpdated records the current time for the items whose state changed since they were last marked, it is called
+// whenever the state of items may have changed so that every record knows when its state last changed
+func (r *Report) MarkUpdated(items []*queue.Item) {
+	now := time.Now().UTC()
+	for _, item := range items {
+		state := StateOf(item)
+		if previous, ok := r.states[item]; ok && previous == state {
+			continue
+		}
+
+		r.states[item] = state
+		r.updatedAt[item] = now
+	}
+}
+
+// Collect converts the queue items into report records. The filters are used to determine which configured filter,
+// if any, caused a resource to be filtered.
+func (r *Report) Collect(items []*queue.Item, filters filter.Filters, useGroups bool) {
+	now := time.Now().UTC()
+
+	// Whatever changed since the items were last marked changed by the end of the run
+	r.MarkUpdated(items)
+
+	for _, item := range items {
+		scannedAt, ok := r.scannedAt[item]
+		if !ok {
+			scannedAt = now
+		}
+
+		rec := &Record{
+			Account:      r.AccountID,
+			Region:       item.Owner,
+			ResourceType: item.Type,
+			State:        StateOf(item),
+			ScannedAt:    scannedAt,
+			UpdatedAt:    r.updatedAt[item],
+			item:         item,
+		}
+
+		if stringer, ok := item.Resource.(resource.LegacyStringer); ok {
+			rec.Name = stringer.String()
+		}
+
+		if createdAt, ok := nuke.CreatedAt(item.Resource); ok {
+			createdAt = createdAt.UTC()
+			rec.CreatedAt = &createdAt
+		}
+
+		if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+			rec.Properties = make(map[string]string)
+			for k, v := range getter.Properties() {
+				if strings.HasPrefix(k, "_") {
+					continue
+				}
+				rec.Properties[k] = v
+			}
+		}
+
+		switch rec.State {
+		case StateFailed:
+			rec.Error = item.GetReason()
+		case StateFiltered:
+			rec.Reason = item.GetReason()
+
+			matches, err := nuke.MatchFilters(filters, item, useGroups)
+			if err == nil && len(matches) > 0 {
+				descriptions := make([]string, 0, len(matches))
+				for i := range matches {
+					descriptions = append(descriptions, nuke.FilterString(&matches[i]))
+				}
+				rec.Filter = strings.Join(descriptions, "; ")
+			}
+		default:
+			rec.Reason = item.GetReason()
+		}
+
+		r.Resources = append(r.Resources, rec)
+	}
+
+	sort.SliceStable(r.Resources, func(i, j int) bool {
+		a, b := r.Resources[i], r.Resources[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Name < b.Name
+	})
+}
+
+// SetCost adds the cost estimate to the report, and the estimated monthly price to every record that has one
+func (r *Report) SetCost(estimate *cost.Estimate) {
+	r.Cost = estimate
+
+	for _, rec := range r.Resources {
+		if price, ok := estimate.Price(rec.item); ok {
+			rec.MonthlyCost = &price
+		}
+	}
+}
+
+// Finish marks the report as complete, recording the error of the run if there was one
+func (r *Report) Finish(runErr error) {
+	r.FinishedAt = time.Now().UTC()
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+}
+
+// Count returns the number of records in the given state
+func (r *Report) Count(state State) int {
+	count := 0
+	for _, rec := range r.Resources {
+		if rec.State == state {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteFile writes the report to the given path in the given format
+func (r *Report) WriteFile(path string, format Format) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Write(f, format); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write writes the report to the writer in the given format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, rec := range r.Resources {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return r.writeCSV(w)
+	default:
+		return fmt.Errorf("unsupported report format '%s'", format)
+	}
+}
+
+// csvHeader is the header row of the CSV format, properties are encoded as a JSON object in a single column
+var csvHeader = []string{
+	"account", "region", "resource_type", "name", "state", "filter", "reason", "error",
+	"scanned_at", "updated_at", "properties", "created_at", "monthly_cost",
+}
+
+func (r *Report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, rec := range r.Resources {
+		props := ""
+		if len(rec.Properties) > 0 {
+			data, err := json.Marshal(rec.Properties)
+			if err != nil {
+				return err
+			}
+			props = string(data)
+		}
+
+		createdAt := ""
+		if rec.CreatedAt != nil {
+			createdAt = rec.CreatedAt.Format(time.RFC3339)
+		}
+
+		monthlyCost := ""
+		if rec.MonthlyCost != nil {
+			monthlyCost = strconv.FormatFloat(*rec.MonthlyCost, 'f', 2, 64)
+		}
+
+		if err := cw.Write([]string{
+			rec.Account,
+			rec.Region,
+			rec.ResourceType,
+			rec.Name,
+			string(rec.State),
+			rec.Filter,
+			rec.Reason,
+			rec.Error,
+			rec.ScannedAt.Format(time.RFC3339),
+			rec.UpdatedAt.Format(time.RFC3339),
+			props,
+			createdAt,
+			monthlyCost,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,265 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ekristen/libnuke/pkg/filter"
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/cost"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+type testResource struct {
+	Name      string
+	Env       string
+	CreatedAt string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) String() string {
+	return r.Name
+}
+
+func (r *testResource) Properties() types.Properties {
+	props := types.NewProperties().
+		Set("Name", r.Name).
+		Set("tag:env", r.Env)
+
+	if r.CreatedAt != "" {
+		props.Set("CreatedAt", r.CreatedAt)
+	}
+
+	return props
+}
+
+func testItems() []*queue.Item {
+	return []*queue.Item{
+		{
+			Resource: &testResource{Name: "keep-me", Env: "prod"},
+			State:    queue.ItemStateFiltered,
+			Reason:   "filtered by config",
+			Type:     "TestResource",
+			Owner:    "us-east-1",
+		},
+		{
+			Resource: &testResource{Name: "remove-me", Env: "dev"},
+			State:    queue.ItemStateNew,
+			Type:     "TestResource",
+			Owner:    "us-east-1",
+		},
+		{
+			Resource: &testResource{Name: "broken", Env: "dev", CreatedAt: "2024-05-01T10:00:00Z"},
+			State:    queue.ItemStateFailed,
+			Reason:   "access denied",
+			Type:     "TestResource",
+			Owner:    "global",
+		},
+	}
+}
+
+func testFilters() filter.Filters {
+	return filter.Filters{
+		"TestResource": []filter.Filter{
+			{
+				Property: "tag:env",
+				Type:     filter.Exact,
+				Value:    "prod",
+			},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{
+		"json":   FormatJSON,
+		"JSON":   FormatJSON,
+		"csv":    FormatCSV,
+		"ndjson": FormatNDJSON,
+	}
+
+	for in, want := range cases {
+		t.Run(in, func(t *testing.T) {
+			have, err := ParseFormat(in)
+			assert.NoError(t, err)
+			assert.Equal(t, want, have)
+		})
+	}
+
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestReport_Collect(t *testing.T) {
+	r := New("123456789012", "sandbox", "test", true)
+	r.Collect(testItems(), testFilters(), false)
+
+	assert.Len(t, r.Resources, 3)
+
+	// records are sorted by region, type and name
+	assert.Equal(t, "global", r.Resources[0].Region)
+	assert.Equal(t, StateFailed, r.Resources[0].State)
+	assert.Equal(t, "access denied", r.Resources[0].Error)
+	assert.Equal(t, "2024-05-01T10:00:00Z", r.Resources[0].CreatedAt.Format(time.RFC3339))
+	assert.Nil(t, r.Resources[1].CreatedAt)
+
+	assert.Equal(t, "keep-me", r.Resources[1].Name)
+	assert.Equal(t, StateFiltered, r.Resources[1].State)
+	assert.Equal(t, "type=exact property=tag:env value=prod", r.Resources[1].Filter)
+	assert.Equal(t, "prod", r.Resources[1].Properties["tag:env"])
+	assert.NotContains(t, r.Resources[1].Properties, "_tagPrefix")
+
+	assert.Equal(t, "remove-me", r.Resources[2].Name)
+	assert.Equal(t, StateWouldRemove, r.Resources[2].State)
+	assert.Equal(t, "123456789012", r.Resources[2].Account)
+
+	assert.Equal(t, 1, r.Count(StateWouldRemove))
+	assert.Equal(t, 1, r.Count(StateFiltered))
+	assert.Equal(t, 1, r.Count(StateFailed))
+}
+
+func TestReport_CollectProtected(t *testing.T) {
+	protectTag := &nuke.ProtectTag{Key: "env", Value: "prod"}
+
+	items := testItems()
+	items[0].Reason = protectTag.Reason()
+
+	r := New("123456789012", "sandbox", "test", true)
+	r.Collect(items, testFilters(), false)
+
+	// the protect tag overrides the filters, the record is not attributed to the filter that matches as well
+	assert.Equal(t, "keep-me", r.Resources[1].Name)
+	assert.Equal(t, StateProtected, r.Resources[1].State)
+	assert.Equal(t, "protected by protect-tag env=prod", r.Resources[1].Reason)
+	assert.Empty(t, r.Resources[1].Filter)
+
+	assert.Equal(t, 1, r.Count(StateProtected))
+	assert.Equal(t, 0, r.Count(StateFiltered))
+}
+
+func TestReport_MarkScanned(t *testing.T) {
+	items := testItems()
+
+	r := New("123456789012", "sandbox", "test", false)
+	r.MarkScanned(items)
+	scannedAt := r.scannedAt[items[0]]
+
+	items[1].State = queue.ItemStateFinished
+	r.Collect(items, testFilters(), false)
+
+	for _, rec := range r.Resources {
+		assert.Equal(t, scannedAt, rec.ScannedAt)
+		assert.False(t, rec.UpdatedAt.Before(rec.ScannedAt))
+	}
+
+	assert.Equal(t, 1, r.Count(StateRemoved))
+}
+
+func TestReport_MarkUpdated(t *testing.T) {
+	items := testItems()
+
+	r := New("123456789012", "sandbox", "test", false)
+	r.MarkScanned(items)
+
+	// the state of remove-me changes, it keeps the time of that change however often it is marked again
+	items[1].State = queue.ItemStateFinished
+	r.MarkUpdated(items)
+	changedAt := r.scannedAt[items[1]].Add(-time.Hour)
+	r.updatedAt[items[1]] = changedAt
+	r.MarkUpdated(items)
+
+	r.Collect(items, testFilters(), false)
+
+	records := make(map[string]*Record)
+	for _, rec := range r.Resources {
+		records[rec.Name] = rec
+	}
+
+	assert.Equal(t, changedAt, records["remove-me"].UpdatedAt)
+	assert.Equal(t, records["keep-me"].ScannedAt, records["keep-me"].UpdatedAt, "the state of keep-me never changed")
+}
+
+func TestReport_Write(t *testing.T) {
+	r := New("123456789012", "sandbox", "test", true)
+	r.Collect(testItems(), testFilters(), false)
+	r.Finish(nil)
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, r.Write(&buf, FormatJSON))
+
+		var decoded Report
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, "sandbox", decoded.AccountAlias)
+		assert.True(t, decoded.DryRun)
+		assert.Len(t, decoded.Resources, 3)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, r.Write(&buf, FormatNDJSON))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 3)
+
+		var rec Record
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &rec))
+		assert.Equal(t, "keep-me", rec.Name)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, r.Write(&buf, FormatCSV))
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, rows, 4)
+		assert.Equal(t, csvHeader, rows[0])
+		assert.Equal(t, "would-remove", rows[3][4])
+		assert.JSONEq(t, `{"Name":"remove-me","tag:env":"dev"}`, rows[3][10])
+		assert.Equal(t, "2024-05-01T10:00:00Z", rows[1][11])
+		assert.Equal(t, "", rows[3][11])
+	})
+}
+
+func TestReport_SetCost(t *testing.T) {
+	items := testItems()
+	table := &cost.PriceTable{
+		Currency: "USD",
+		ResourceTypes: map[string]*cost.Rule{
+			"TestResource": {Property: "Name", Monthly: map[string]float64{"remove-me": 12.5, "keep-me": 100}},
+		},
+	}
+
+	r := New("123456789012", "sandbox", "test", true)
+	r.Collect(items, testFilters(), false)
+	r.SetCost(table.Estimate(items))
+
+	assert.InDelta(t, 12.5, r.Cost.Total, 0.001)
+	assert.Equal(t, 1, r.Cost.Unpriced)
+
+	// records are sorted by region, type and name
+	assert.Nil(t, r.Resources[0].MonthlyCost)
+	assert.Nil(t, r.Resources[1].MonthlyCost, "filtered resources are not part of the estimate")
+	assert.InDelta(t, 12.5, *r.Resources[2].MonthlyCost, 0.001)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf, FormatCSV))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "12.50", rows[3][12])
+	assert.Equal(t, "", rows[2][12])
+}
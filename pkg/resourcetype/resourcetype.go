@@ -0,0 +1,183 @@
+// Package resourcetype describes the registered resource types in a structured form: their scope, dependencies,
+// alternatives, settings and the properties they can be filtered by. It is used to build tooling that authors
+// configuration files without reading the source of the resources.
+package resourcetype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/docs"
+	"github.com/ekristen/libnuke/pkg/registry"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/config"
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// createdAtDescription describes the normalized creation time, which is added to the properties rather than declared
+// on the resource structs
+const createdAtDescription = "The time the resource was created, normalized to RFC3339"
+
+// Format is the output format of the resource types
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats is the list of supported output formats
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatMarkdown}
+
+// ParseFormat converts a string into a Format, returning an error if the format is not supported
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported output format '%s', must be one of: text, json, yaml, markdown", s)
+}
+
+// Property is a property that the resources of a type can be filtered by
+type Property struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// ResourceType is the description of a single registered resource type
+type ResourceType struct {
+	Name                string   `json:"name" yaml:"name"`
+	Scope               string   `json:"scope" yaml:"scope"`
+	DependsOn           []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	AlternativeResource string   `json:"alternative_resource,omitempty" yaml:"alternative_resource,omitempty"`
+	DeprecatedAliases   []string `json:"deprecated_aliases,omitempty" yaml:"deprecated_aliases,omitempty"`
+	Settings            []string `json:"settings,omitempty" yaml:"settings,omitempty"`
+
+	// Properties are nil when they cannot be known, for example for Cloud Control resource types whose properties
+	// are populated from the API
+	Properties []Property `json:"properties,omitempty" yaml:"properties,omitempty"`
+
+	// TagPrefixes are the prefixes of the tag properties, for example tag: or tag:vpc:
+	TagPrefixes []string `json:"tag_prefixes,omitempty" yaml:"tag_prefixes,omitempty"`
+}
+
+// List returns the description of the named resource types in the order of the names. Names that are not
+// registered are skipped.
+func List(regs registry.Registrations, names []string) []*ResourceType {
+	catalog := config.NewCatalog(regs, nil)
+
+	resourceTypes := make([]*ResourceType, 0, len(names))
+	for _, name := range names {
+		reg, ok := regs[name]
+		if !ok || reg == nil {
+			continue
+		}
+
+		resourceTypes = append(resourceTypes, New(reg, catalog.ResourceTypes[name]))
+	}
+
+	return resourceTypes
+}
+
+// New describes a single registration. The properties are those of the catalog, with the descriptions of the
+// property and description struct tags of the resource.
+func New(reg *registry.Registration, rt *config.ResourceType) *ResourceType {
+	r := &ResourceType{
+		Name:                reg.Name,
+		Scope:               string(reg.Scope),
+		DependsOn:           reg.DependsOn,
+		AlternativeResource: reg.AlternativeResource,
+		DeprecatedAliases:   reg.DeprecatedAliases,
+		Settings:            reg.Settings,
+	}
+
+	if rt == nil || rt.Properties == nil {
+		return r
+	}
+
+	descriptions := docs.GeneratePropertiesMap(reg.Resource)
+	if descriptions[nuke.CreatedAtProperty] == "" {
+		descriptions[nuke.CreatedAtProperty] = createdAtDescription
+	}
+
+	r.Properties = make([]Property, 0, len(rt.Properties))
+	for _, name := range rt.Properties {
+		r.Properties = append(r.Properties, Property{Name: name, Description: descriptions[name]})
+	}
+	r.TagPrefixes = rt.TagPrefixes
+
+	return r
+}
+
+// Write writes the resource types to the writer in the given format
+func Write(w io.Writer, resourceTypes []*ResourceType, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resourceTypes)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(resourceTypes); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatMarkdown:
+		return writeMarkdown(w, resourceTypes)
+	default:
+		return fmt.Errorf("unsupported output format '%s'", format)
+	}
+}
+
+func writeMarkdown(w io.Writer, resourceTypes []*ResourceType) error {
+	var b strings.Builder
+
+	b.WriteString("# Resource Types\n")
+	for _, r := range resourceTypes {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Name)
+		fmt.Fprintf(&b, "- **Scope:** %s\n", r.Scope)
+
+		list := func(title string, values []string) {
+			if len(values) == 0 {
+				return
+			}
+
+			quoted := make([]string, 0, len(values))
+			for _, value := range values {
+				quoted = append(quoted, "`"+value+"`")
+			}
+			fmt.Fprintf(&b, "- **%s:** %s\n", title, strings.Join(quoted, ", "))
+		}
+
+		if r.AlternativeResource != "" {
+			list("Alternative Resource", []string{r.AlternativeResource})
+		}
+		list("Depends On", r.DependsOn)
+		list("Deprecated Aliases", r.DeprecatedAliases)
+		list("Settings", r.Settings)
+		list("Tag Prefixes", r.TagPrefixes)
+
+		if len(r.Properties) == 0 {
+			continue
+		}
+
+		b.WriteString("\n| Property | Description |\n| --- | --- |\n")
+		for _, p := range r.Properties {
+			description := strings.Join(strings.Fields(p.Description), " ")
+			description = strings.ReplaceAll(description, "|", "\\|")
+			fmt.Fprintf(&b, "| `%s` | %s |\n", p.Name, description)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,112 @@
+package resourcetype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/registry"
+	"github.com/ekristen/libnuke/pkg/resource"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testInstance struct {
+	ID    *string           `property:"name=Identifier" description:"The instance ID"`
+	State *string           `description:"The state | of the instance"`
+	Tags  map[string]string `description:"The tags of the instance"`
+}
+
+func (r *testInstance) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testInstance) Properties() types.Properties {
+	return types.NewPropertiesFromStruct(r)
+}
+
+type testLister struct{}
+
+func (l *testLister) List(_ context.Context, _ interface{}) ([]resource.Resource, error) {
+	return nil, nil
+}
+
+func testRegistrations() registry.Registrations {
+	return registry.Registrations{
+		"TestInstance": {
+			Name:                "TestInstance",
+			Scope:               "account",
+			Resource:            &testInstance{},
+			Lister:              &testLister{},
+			Settings:            []string{"DisableDeletionProtection"},
+			DependsOn:           []string{"TestVolume"},
+			DeprecatedAliases:   []string{"TestInstances"},
+			AlternativeResource: "AWS::Test::Instance",
+		},
+		"AWS::Test::Volume": {
+			Name:   "AWS::Test::Volume",
+			Scope:  "account",
+			Lister: &testLister{},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("JSON")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	resourceTypes := List(testRegistrations(), []string{"AWS::Test::Volume", "Missing", "TestInstance"})
+
+	assert.Equal(t, []*ResourceType{
+		{Name: "AWS::Test::Volume", Scope: "account"},
+		{
+			Name:                "TestInstance",
+			Scope:               "account",
+			DependsOn:           []string{"TestVolume"},
+			AlternativeResource: "AWS::Test::Instance",
+			DeprecatedAliases:   []string{"TestInstances"},
+			Settings:            []string{"DisableDeletionProtection"},
+			Properties: []Property{
+				{Name: "CreatedAt", Description: createdAtDescription},
+				{Name: "Identifier", Description: "The instance ID"},
+				{Name: "State", Description: "The state | of the instance"},
+			},
+			TagPrefixes: []string{"tag:"},
+		},
+	}, resourceTypes)
+}
+
+func TestWrite(t *testing.T) {
+	resourceTypes := List(testRegistrations(), []string{"TestInstance"})
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(&out, resourceTypes, FormatJSON))
+
+	var fromJSON []*ResourceType
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &fromJSON))
+	assert.Equal(t, resourceTypes, fromJSON)
+
+	out.Reset()
+	assert.NoError(t, Write(&out, resourceTypes, FormatYAML))
+
+	var fromYAML []*ResourceType
+	assert.NoError(t, yaml.Unmarshal(out.Bytes(), &fromYAML))
+	assert.Equal(t, resourceTypes, fromYAML)
+
+	out.Reset()
+	assert.NoError(t, Write(&out, resourceTypes, FormatMarkdown))
+	assert.Contains(t, out.String(), "## TestInstance\n\n- **Scope:** account\n")
+	assert.Contains(t, out.String(), "- **Alternative Resource:** `AWS::Test::Instance`\n")
+	assert.Contains(t, out.String(), "| `State` | The state \\| of the instance |\n")
+
+	assert.Error(t, Write(&out, resourceTypes, FormatText))
+}
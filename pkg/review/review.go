@@ -0,0 +1,547 @@
+// Package review implements the interactive review of the resources that would be removed. The operator can search
+// the resources and deselect individual resources or entire resource types, the deselected resources are then kept
+// and a filter snippet is generated so that they can be kept in future runs as well.
+package review
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/resource"
+
+	"github.com/ekristen/aws-nuke/v3/pkg/nuke"
+)
+
+// ReasonDeselected is the reason that is set on resources that were deselected during the review
+const ReasonDeselected = "deselected during interactive review"
+
+// ErrAborted is returned when the operator aborts the run during the review
+var ErrAborted = errors.New("aborted during interactive review")
+
+const help = `Commands:
+  list                           list all resources, grouped by region and resource type
+  search <text>                  list the resources whose type, region, name or properties contain the text
+  show <n>                       show all properties of a resource
+  deselect <n>...                keep the resources with these numbers, ranges such as 3-7 are allowed
+  select <n>...                  remove the resources with these numbers again
+  deselect-type <type> [region]  keep every resource of the type, optionally only in one region
+  select-type <type> [region]    remove every resource of the type again, optionally only in one region
+  summary                        show the number of selected resources per region and resource type
+  filters                        show the filters that keep the deselected resources in future runs
+  done                           continue with the selected resources
+  abort                          abort the run without removing anything
+`
+
+type entry struct {
+	number     int
+	item       *queue.Item
+	region     string
+	name       string
+	properties map[string]string
+	selected   bool
+}
+
+// matches returns true if the text is part of the type, region, name or any property value of the entry
+func (e *entry) matches(text string) bool {
+	text = strings.ToLower(text)
+
+	if strings.Contains(strings.ToLower(e.item.Type), text) ||
+		strings.Contains(strings.ToLower(e.region), text) ||
+		strings.Contains(strings.ToLower(e.name), text) {
+		return true
+	}
+
+	for _, v := range e.properties {
+		if strings.Contains(strings.ToLower(v), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Review is the interactive review of the resources of a single account
+type Review struct {
+	accountID string
+	entries   []*entry
+
+	// excludedTypes are the resource types that were deselected in every region
+	excludedTypes map[string]bool
+
+	in  io.Reader
+	out io.Writer
+}
+
+// New creates a review of the items that would be removed, all of them are selected to start with
+func New(accountID string, items []*queue.Item) *Review {
+	r := &Review{
+		accountID:     accountID,
+		excludedTypes: make(map[string]bool),
+	}
+
+	for _, item := range items {
+		state := item.GetState()
+		if state != queue.ItemStateNew && state != queue.ItemStateNewDependency {
+			continue
+		}
+
+		e := &entry{
+			item:     item,
+			region:   item.Owner,
+			name:     nuke.ResourceIdentifier(item),
+			selected: true,
+		}
+
+		if getter, ok := item.Resource.(resource.PropertyGetter); ok {
+			e.properties = make(map[string]string)
+			for k, v := range getter.Properties() {
+				if !strings.HasPrefix(k, "_") {
+					e.properties[k] = v
+				}
+			}
+		}
+
+		r.entries = append(r.entries, e)
+	}
+
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		a, b := r.entries[i], r.entries[j]
+		if a.region != b.region {
+			return a.region < b.region
+		}
+		if a.item.Type != b.item.Type {
+			return a.item.Type < b.item.Type
+		}
+		return a.name < b.name
+	})
+
+	for i, e := range r.entries {
+		e.number = i + 1
+	}
+
+	return r
+}
+
+// Len returns the number of resources under review
+func (r *Review) Len() int {
+	return len(r.entries)
+}
+
+// Run reads commands from in until the operator is done or aborts, ErrAborted is returned if the run was aborted
+func (r *Review) Run(in io.Reader, out io.Writer) error {
+	r.in, r.out = in, out
+
+	r.printf("Review the %d resources that would be removed. Type 'help' for a list of commands.\n\n", len(r.entries))
+	r.summary()
+
+	for {
+		r.printf("review> ")
+
+		line, err := readLine(r.in)
+		if errors.Is(err, io.EOF) && line == "" {
+			return ErrAborted
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		command, args := fields[0], fields[1:]
+
+		switch command {
+		case "help", "?":
+			r.printf("%s", help)
+		case "list", "ls":
+			r.list("")
+		case "search", "find", "/":
+			if len(args) == 0 {
+				r.printf("search requires the text to search for\n")
+				continue
+			}
+			r.list(strings.Join(args, " "))
+		case "show":
+			r.show(args)
+		case "deselect", "keep":
+			r.setNumbers(args, false)
+		case "select":
+			r.setNumbers(args, true)
+		case "deselect-type", "keep-type":
+			r.setType(args, false)
+		case "select-type":
+			r.setType(args, true)
+		case "summary":
+			r.summary()
+		case "filters":
+			r.filters()
+		case "done", "continue":
+			deselected := len(r.Deselected())
+			r.printf("Continuing with %d resources, %d deselected resources will be kept.\n\n",
+				len(r.entries)-deselected, deselected)
+			return nil
+		case "abort", "quit", "exit":
+			return ErrAborted
+		default:
+			r.printf("unknown command '%s', type 'help' for a list of commands\n", command)
+		}
+	}
+}
+
+// Deselected returns the items that were deselected
+func (r *Review) Deselected() []*queue.Item {
+	var items []*queue.Item
+	for _, e := range r.entries {
+		if !e.selected {
+			items = append(items, e.item)
+		}
+	}
+
+	return items
+}
+
+// Apply marks the deselected items as filtered, so that they are not removed, and returns how many there were
+func (r *Review) Apply() int {
+	deselected := r.Deselected()
+	for _, item := range deselected {
+		item.State = queue.ItemStateFiltered
+		item.Reason = ReasonDeselected
+	}
+
+	return len(deselected)
+}
+
+// snippetAccount is the part of the account configuration that the snippet contains
+type snippetAccount struct {
+	Filters       map[string][]interface{} `yaml:"filters,omitempty"`
+	ResourceTypes *snippetResourceTypes    `yaml:"resource-types,omitempty"`
+}
+
+type snippetResourceTypes struct {
+	Excludes []string `yaml:"excludes"`
+}
+
+type snippetFilter struct {
+	Property string `yaml:"property"`
+	Value    string `yaml:"value"`
+}
+
+// Snippet returns a configuration snippet that keeps the deselected resources in future runs, resource types that
+// were deselected in every region are excluded entirely. Nil is returned when nothing was deselected.
+func (r *Review) Snippet() ([]byte, error) {
+	account := &snippetAccount{
+		Filters: make(map[string][]interface{}),
+	}
+
+	for _, e := range r.entries {
+		if e.selected || r.excludedTypes[e.item.Type] {
+			continue
+		}
+
+		account.Filters[e.item.Type] = append(account.Filters[e.item.Type], filterFor(e))
+	}
+
+	if len(r.excludedTypes) > 0 {
+		account.ResourceTypes = &snippetResourceTypes{}
+		for t := range r.excludedTypes {
+			account.ResourceTypes.Excludes = append(account.ResourceTypes.Excludes, t)
+		}
+		sort.Strings(account.ResourceTypes.Excludes)
+	}
+
+	if len(account.Filters) == 0 && account.ResourceTypes == nil {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{
+		"accounts": map[string]*snippetAccount{
+			r.accountID: account,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("# Generated by the interactive review of account %s on %s.\n"+
+		"# Merge it into the config to keep the deselected resources in future runs.\n",
+		r.accountID, time.Now().UTC().Format(time.RFC3339))
+
+	return append([]byte(header), data...), nil
+}
+
+// filterFor returns the filter that matches the resource of the entry, which is its first identity property and
+// otherwise its legacy name
+func filterFor(e *entry) interface{} {
+	if property, v := nuke.IdentityProperty(e.properties); property != "" {
+		return snippetFilter{Property: property, Value: v}
+	}
+
+	if stringer, ok := e.item.Resource.(resource.LegacyStringer); ok {
+		return stringer.String()
+	}
+
+	keys := make([]string, 0, len(e.properties))
+	for k, v := range e.properties {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return e.name
+	}
+
+	return snippetFilter{Property: keys[0], Value: e.properties[keys[0]]}
+}
+
+func (r *Review) printf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(r.out, format, a...)
+}
+
+// list prints the entries that match the text grouped by region and type, all entries if the text is empty
+func (r *Review) list(text string) {
+	var region, resourceType string
+	shown := 0
+
+	for _, e := range r.entries {
+		if text != "" && !e.matches(text) {
+			continue
+		}
+
+		if e.region != region {
+			region, resourceType = e.region, ""
+			r.printf("%s\n", region)
+		}
+
+		if e.item.Type != resourceType {
+			resourceType = e.item.Type
+			r.printf("  %s\n", resourceType)
+		}
+
+		mark := "x"
+		if !e.selected {
+			mark = " "
+		}
+
+		r.printf("    [%s] %4d  %s\n", mark, e.number, e.name)
+		shown++
+	}
+
+	if shown == 0 {
+		r.printf("no resources found\n")
+	}
+}
+
+// show prints every property of the entries
+func (r *Review) show(args []string) {
+	numbers, err := r.parseNumbers(args)
+	if err != nil {
+		r.printf("%s\n", err)
+		return
+	}
+
+	for _, n := range numbers {
+		e := r.entries[n-1]
+		r.printf("%d: %s - %s - %s\n", e.number, e.region, e.item.Type, e.name)
+
+		keys := make([]string, 0, len(e.properties))
+		for k := range e.properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			r.printf("  %s: %s\n", k, e.properties[k])
+		}
+	}
+}
+
+// setNumbers selects or deselects the entries with the given numbers
+func (r *Review) setNumbers(args []string, selected bool) {
+	numbers, err := r.parseNumbers(args)
+	if err != nil {
+		r.printf("%s\n", err)
+		return
+	}
+
+	for _, n := range numbers {
+		e := r.entries[n-1]
+		e.selected = selected
+
+		if selected {
+			delete(r.excludedTypes, e.item.Type)
+		}
+	}
+
+	r.printf("%s %d resources\n", verb(selected), len(numbers))
+}
+
+// setType selects or deselects all entries of a resource type, optionally only in a single region
+func (r *Review) setType(args []string, selected bool) {
+	if len(args) == 0 || len(args) > 2 {
+		r.printf("expected a resource type and optionally a region\n")
+		return
+	}
+
+	resourceType, region := args[0], ""
+	if len(args) == 2 {
+		region = args[1]
+	}
+
+	count := 0
+	for _, e := range r.entries {
+		if !strings.EqualFold(e.item.Type, resourceType) || (region != "" && e.region != region) {
+			continue
+		}
+
+		resourceType = e.item.Type
+		e.selected = selected
+		count++
+	}
+
+	if count == 0 {
+		r.printf("no resources of type '%s' found\n", args[0])
+		return
+	}
+
+	if selected {
+		delete(r.excludedTypes, resourceType)
+	} else if region == "" {
+		r.excludedTypes[resourceType] = true
+	}
+
+	r.printf("%s %d resources\n", verb(selected), count)
+}
+
+// summary prints the number of selected resources per region and resource type
+func (r *Review) summary() {
+	type group struct {
+		region       string
+		resourceType string
+		selected     int
+		total        int
+	}
+
+	var groups []*group
+	for _, e := range r.entries {
+		if len(groups) == 0 || groups[len(groups)-1].region != e.region ||
+			groups[len(groups)-1].resourceType != e.item.Type {
+			groups = append(groups, &group{region: e.region, resourceType: e.item.Type})
+		}
+
+		g := groups[len(groups)-1]
+		g.total++
+		if e.selected {
+			g.selected++
+		}
+	}
+
+	region := ""
+	for _, g := range groups {
+		if g.region != region {
+			region = g.region
+			r.printf("%s\n", region)
+		}
+
+		r.printf("  %-50s %d of %d selected\n", g.resourceType, g.selected, g.total)
+	}
+
+	r.printf("\n")
+}
+
+// filters prints the snippet of the deselected resources
+func (r *Review) filters() {
+	snippet, err := r.Snippet()
+	if err != nil {
+		r.printf("unable to generate filters: %s\n", err)
+		return
+	}
+
+	if snippet == nil {
+		r.printf("no resources are deselected\n")
+		return
+	}
+
+	r.printf("%s", snippet)
+}
+
+// parseNumbers parses resource numbers and ranges of resource numbers, separated by spaces or commas
+func (r *Review) parseNumbers(args []string) ([]int, error) {
+	var numbers []int
+
+	for _, arg := range args {
+		for _, part := range strings.Split(arg, ",") {
+			if part == "" {
+				continue
+			}
+
+			low, high, isRange := strings.Cut(part, "-")
+
+			start, err := strconv.Atoi(low)
+			if err != nil {
+				return nil, fmt.Errorf("invalid resource number '%s'", part)
+			}
+
+			end := start
+			if isRange {
+				end, err = strconv.Atoi(high)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid range '%s'", part)
+				}
+			}
+
+			if start < 1 || end > len(r.entries) {
+				return nil, fmt.Errorf("resource numbers must be between 1 and %d", len(r.entries))
+			}
+
+			for n := start; n <= end; n++ {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("expected one or more resource numbers")
+	}
+
+	return numbers, nil
+}
+
+func verb(selected bool) string {
+	if selected {
+		return "selected"
+	}
+
+	return "deselected"
+}
+
+// readLine reads a single line one byte at a time, so that nothing after the line is consumed from the reader. This
+// keeps the input intact for the prompt that follows the review.
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return strings.TrimSpace(string(line)), nil
+			}
+			line = append(line, buf[0])
+		}
+
+		if err != nil {
+			return strings.TrimSpace(string(line)), err
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ekristen/libnuke/pkg/queue"
+	"github.com/ekristen/libnuke/pkg/types"
+)
+
+type testResource struct {
+	id   string
+	name string
+}
+
+func (r *testResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testResource) Properties() types.Properties {
+	return types.NewProperties().Set("ID", r.id).Set("Name", r.name)
+}
+
+type testLegacyResource struct {
+	name string
+}
+
+func (r *testLegacyResource) Remove(_ context.Context) error {
+	return nil
+}
+
+func (r *testLegacyResource) String() string {
+	return r.name
+}
+
+func testItems() []*queue.Item {
+	return []*queue.Item{
+		{Type: "S3Bucket", Owner: "global", State: queue.ItemStateNew, Resource: &testLegacyResource{name: "s3://logs"}},
+		{Type: "EC2Instance", Owner: "us-east-1", State: queue.ItemStateNew, Resource: &testResource{id: "i-2", name: "db"}},
+		{Type: "EC2Instance", Owner: "us-east-1", State: queue.ItemStateNew, Resource: &testResource{id: "i-1", name: "web"}},
+		{Type: "EC2Volume", Owner: "us-east-1", State: queue.ItemStateNew, Resource: &testResource{id: "vol-1"}},
+		{Type: "EC2Volume", Owner: "us-west-2", State: queue.ItemStateNew, Resource: &testResource{id: "vol-2"}},
+		{Type: "EC2Instance", Owner: "us-east-1", State: queue.ItemStateFiltered, Resource: &testResource{id: "i-3"}},
+	}
+}
+
+func run(t *testing.T, r *Review, commands ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	err := r.Run(strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+
+	return out.String(), err
+}
+
+func TestNew(t *testing.T) {
+	r := New("000000000000", testItems())
+
+	// the filtered item is not under review, the others are sorted by region, type and name
+	assert.Equal(t, 5, r.Len())
+	assert.Equal(t, "s3://logs", r.entries[0].name)
+	assert.Equal(t, "ID=i-1,Name=web", r.entries[1].name)
+	assert.Equal(t, "ID=i-2,Name=db", r.entries[2].name)
+	assert.Equal(t, 5, r.entries[4].number)
+}
+
+func TestReview_Run(t *testing.T) {
+	r := New("000000000000", testItems())
+
+	out, err := run(t, r,
+		"search web",
+		"deselect 1,3",
+		"select 1",
+		"deselect-type ec2volume",
+		"select-type EC2Volume us-west-2",
+		"bogus",
+		"done",
+	)
+	assert.NoError(t, err)
+
+	assert.Contains(t, out, "Review the 5 resources that would be removed.")
+	assert.Contains(t, out, "us-east-1\n  EC2Instance\n    [x]    2  ID=i-1,Name=web\n")
+	assert.NotContains(t, out, "i-2,")
+	assert.Contains(t, out, "deselected 2 resources")
+	assert.Contains(t, out, "unknown command 'bogus'")
+	assert.Contains(t, out, "Continuing with 3 resources, 2 deselected resources will be kept.")
+
+	deselected := r.Deselected()
+	assert.Len(t, deselected, 2)
+
+	assert.Equal(t, 2, r.Apply())
+	for _, item := range deselected {
+		assert.Equal(t, queue.ItemStateFiltered, item.GetState())
+		assert.Equal(t, ReasonDeselected, item.GetReason())
+	}
+}
+
+func TestReview_Abort(t *testing.T) {
+	r := New("000000000000", testItems())
+
+	_, err := run(t, r, "deselect 1", "abort")
+	assert.ErrorIs(t, err, ErrAborted)
+
+	// the end of the input aborts as well
+	_, err = run(t, New("000000000000", testItems()), "list")
+	assert.ErrorIs(t, err, ErrAborted)
+}
+
+func TestReview_InvalidNumbers(t *testing.T) {
+	r := New("000000000000", testItems())
+
+	out, err := run(t, r, "deselect 0", "deselect 2-1", "deselect x", "deselect", "show 6", "done")
+	assert.NoError(t, err)
+
+	assert.Contains(t, out, "resource numbers must be between 1 and 5")
+	assert.Contains(t, out, "invalid range '2-1'")
+	assert.Contains(t, out, "invalid resource number 'x'")
+	assert.Contains(t, out, "expected one or more resource numbers")
+	assert.Empty(t, r.Deselected())
+}
+
+func TestReview_Snippet(t *testing.T) {
+	r := New("000000000000", testItems())
+
+	snippet, err := r.Snippet()
+	assert.NoError(t, err)
+	assert.Nil(t, snippet)
+
+	_, err = run(t, r, "deselect 1-2", "deselect-type EC2Volume", "done")
+	assert.NoError(t, err)
+
+	snippet, err = r.Snippet()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(snippet), "# Generated by the interactive review of account 000000000000"))
+
+	var parsed map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(snippet, &parsed))
+	assert.Equal(t, map[string]interface{}{
+		"accounts": map[string]interface{}{
+			"000000000000": map[string]interface{}{
+				"filters": map[string]interface{}{
+					"S3Bucket": []interface{}{"s3://logs"},
+					"EC2Instance": []interface{}{
+						map[string]interface{}{"property": "ID", "value": "i-1"},
+					},
+				},
+				"resource-types": map[string]interface{}{
+					"excludes": []interface{}{"EC2Volume"},
+				},
+			},
+		},
+	}, parsed)
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"":              "expected 5 fields",
+		"* * * *":       "expected 5 fields",
+		"60 * * * *":    "invalid value '60' in minute field",
+		"* 24 * * *":    "invalid value '24' in hour field",
+		"* * 0 * *":     "invalid value '0' in day of month field",
+		"* * * foo *":   "invalid value 'foo' in month field",
+		"* * * * 8":     "invalid value '8' in day of week field",
+		"*/0 * * * *":   "invalid step '0' in minute field",
+		"10-5 * * * *":  "invalid range '10-5' in minute field",
+		"@fortnightly":  "expected 5 fields",
+		"* * * * * * *": "expected 5 fields",
+	}
+
+	for spec, expected := range cases {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Monday
+	now := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	cases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2024, 1, 15, 11, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 18 * * mon-fri", time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		// when both day fields are restricted, either of them matches
+		{"0 0 1 * fri", time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, s.Next(now))
+			assert.Equal(t, tc.spec, s.String())
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The OTLP JSON encoding of an ExportTraceServiceRequest, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func newOTLPValue(v interface{}) otlpValue {
+	switch value := v.(type) {
+	case string:
+		return otlpValue{StringValue: &value}
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		return otlpValue{IntValue: &s}
+	case bool:
+		return otlpValue{BoolValue: &value}
+	default:
+		s := fmt.Sprintf("%v", value)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// Encode encodes the spans as an OTLP JSON ExportTraceServiceRequest
+func Encode(resource *Resource, spans []*Span) ([]byte, error) {
+	scope := otlpScopeSpans{
+		Scope: otlpScope{Name: resource.ServiceName, Version: resource.ServiceVersion},
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: s.StatusCode, Message: s.StatusMessage},
+		}
+
+		if s.ParentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpKeyValue{Key: a.Key, Value: newOTLPValue(a.Value)})
+		}
+		s.mu.Unlock()
+
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	return json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{
+						{Key: "service.name", Value: newOTLPValue(resource.ServiceName)},
+						{Key: "service.version", Value: newOTLPValue(resource.ServiceVersion)},
+					},
+				},
+				ScopeSpans: []otlpScopeSpans{scope},
+			},
+		},
+	})
+}
+
+// HTTPExporter sends spans to an OTLP/HTTP endpoint, such as an OpenTelemetry collector, using the JSON encoding
+type HTTPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewHTTPExporter creates a new HTTPExporter. The endpoint is the base URL of the collector, for example
+// http://localhost:4318, to which /v1/traces is appended unless it is already part of the endpoint.
+func NewHTTPExporter(endpoint string, headers map[string]string) *HTTPExporter {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+
+	return &HTTPExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Export sends the spans to the endpoint
+func (e *HTTPExporter) Export(ctx context.Context, resource *Resource, spans []*Span) error {
+	body, err := Encode(resource, spans)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unable to export spans to %s: %s: %s", e.endpoint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// Shutdown is a no-op for the HTTPExporter
+func (e *HTTPExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// FileExporter writes spans to a local file, one OTLP JSON ExportTraceServiceRequest per line. This is the same
+// format that is written by the file exporter of the OpenTelemetry collector.
+type FileExporter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileExporter creates the file and returns a FileExporter that writes to it
+func NewFileExporter(path string) (*FileExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileExporter{f: f}, nil
+}
+
+// Export writes the spans to the file
+func (e *FileExporter) Export(_ context.Context, resource *Resource, spans []*Span) error {
+	data, err := Encode(resource, spans)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err = e.f.Write(append(data, '\n'))
+	return err
+}
+
+// Shutdown closes the file
+func (e *FileExporter) Shutdown(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.f.Close()
+}
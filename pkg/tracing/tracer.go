@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBatchSize is the number of finished spans after which an export is triggered
+	maxBatchSize = 512
+
+	// exportInterval is the maximum time a finished span waits before it is exported
+	exportInterval = 5 * time.Second
+)
+
+// Exporter sends finished spans to their destination
+type Exporter interface {
+	Export(ctx context.Context, resource *Resource, spans []*Span) error
+	Shutdown(ctx context.Context) error
+}
+
+// Resource describes the entity that produced the spans
+type Resource struct {
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Tracer collects finished spans and exports them in batches in the background
+type Tracer struct {
+	resource  *Resource
+	exporters []Exporter
+	log       *logrus.Entry
+
+	mu      sync.Mutex
+	pending []*Span
+
+	flush chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewTracer creates a new Tracer and starts exporting in the background
+func NewTracer(resource *Resource, logger *logrus.Entry, exporters ...Exporter) *Tracer {
+	t := &Tracer{
+		resource:  resource,
+		exporters: exporters,
+		log:       logger,
+		flush:     make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.loop()
+
+	return t
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= maxBatchSize
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) loop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.flush:
+		}
+
+		if err := t.export(context.Background()); err != nil {
+			t.log.WithError(err).Warn("unable to export spans")
+		}
+	}
+}
+
+func (t *Tracer) export(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, e := range t.exporters {
+		errs = append(errs, e.Export(ctx, t.resource, spans))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Shutdown stops the background export, exports all remaining spans and shuts down the exporters
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	close(t.done)
+	t.wg.Wait()
+
+	errs := []error{t.export(ctx)}
+	for _, e := range t.exporters {
+		errs = append(errs, e.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
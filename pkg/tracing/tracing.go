@@ -0,0 +1,204 @@
+// Package tracing records the phases of a run as spans and exports them using the OpenTelemetry protocol (OTLP), so
+// that it is possible to see where the time of a long run goes. Tracing is disabled unless a Tracer is set, in which
+// case starting a span is a no-op and returns a nil *Span, all methods of which are safe to call.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind describes the relationship between the span, its parents and its children
+type SpanKind int
+
+// The span kinds as defined by OpenTelemetry
+const (
+	KindInternal SpanKind = 1
+	KindClient   SpanKind = 3
+)
+
+// StatusCode is the status of a span
+type StatusCode int
+
+// The status codes as defined by OpenTelemetry
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key value pair that is attached to a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int creates an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool creates a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a single timed operation
+type Span struct {
+	mu sync.Mutex
+
+	tracer *Tracer
+
+	TraceID       [16]byte
+	SpanID        [8]byte
+	ParentID      [8]byte
+	Name          string
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	StatusCode    StatusCode
+	StatusMessage string
+
+	ended bool
+}
+
+// SetKind sets the kind of the span
+func (s *Span) SetKind(kind SpanKind) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Kind = kind
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// SetError marks the span as failed with the error as message, a nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.StatusCode = StatusError
+	s.StatusMessage = err.Error()
+}
+
+// Finish ends the span, only the first call has an effect
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+// TraceIDString returns the hex encoded trace ID
+func (s *Span) TraceIDString() string {
+	if s == nil {
+		return ""
+	}
+
+	return hex.EncodeToString(s.TraceID[:])
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of the context that carries the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by the context, or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+var global atomic.Pointer[Tracer]
+
+// SetTracer sets the tracer that is used by Start, passing nil disables tracing
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// Enabled returns true if a tracer has been set
+func Enabled() bool {
+	return global.Load() != nil
+}
+
+// Start starts a new span as a child of the span in the context. If the context does not carry a span, a new trace
+// is started. The returned context carries the new span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	span := StartWithParent(SpanFromContext(ctx), name, attrs...)
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartWithParent starts a new span as a child of the given parent, a nil parent starts a new trace
+func StartWithParent(parent *Span, name string, attrs ...Attribute) *Span {
+	t := global.Load()
+	if t == nil {
+		return nil
+	}
+
+	span := &Span{
+		tracer:     t,
+		Name:       name,
+		Kind:       KindInternal,
+		Start:      time.Now(),
+		Attributes: attrs,
+	}
+
+	_, _ = rand.Read(span.SpanID[:])
+
+	if parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.TraceID[:])
+	}
+
+	return span
+}
@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var testResource = &Resource{ServiceName: "aws-nuke", ServiceVersion: "test"}
+
+func TestStart_Disabled(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "noop")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	// all span methods are safe to call on a nil span
+	span.SetKind(KindClient)
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("failed"))
+	span.Finish()
+}
+
+func TestStart_Hierarchy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	exporter, err := NewFileExporter(path)
+	assert.NoError(t, err)
+
+	tracer := NewTracer(testResource, logrus.NewEntry(logrus.New()), exporter)
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx, root := Start(context.Background(), "root", String("account", "123456789012"))
+	_, child := Start(ctx, "child", Int("count", 3), Bool("ok", true))
+	child.SetKind(KindClient)
+	child.SetError(errors.New("access denied"))
+	child.Finish()
+	child.Finish() // only the first call has an effect
+	root.Finish()
+
+	_, other := Start(context.Background(), "other")
+	other.Finish()
+
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentID)
+	assert.NotEqual(t, root.TraceID, other.TraceID)
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	var lines []otlpRequest
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var req otlpRequest
+		assert.NoError(t, json.Unmarshal(s.Bytes(), &req))
+		lines = append(lines, req)
+	}
+
+	assert.Len(t, lines, 1)
+
+	spans := lines[0].ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 3)
+
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, KindClient, spans[0].Kind)
+	assert.Equal(t, StatusError, spans[0].Status.Code)
+	assert.Equal(t, "access denied", spans[0].Status.Message)
+	assert.Equal(t, root.TraceIDString(), spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, "3", *spans[0].Attributes[0].Value.IntValue)
+	assert.True(t, *spans[0].Attributes[1].Value.BoolValue)
+
+	assert.Equal(t, "root", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, "123456789012", *spans[1].Attributes[0].Value.StringValue)
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var received otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(testResource, logrus.NewEntry(logrus.New()),
+		NewHTTPExporter(server.URL+"/", map[string]string{"Authorization": "secret"}))
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	_, span := Start(context.Background(), "span")
+	span.Finish()
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	assert.Len(t, received.ResourceSpans, 1)
+	assert.Equal(t, "service.name", received.ResourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "span", received.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+}
+
+func TestHTTPExporter_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	e := NewHTTPExporter(server.URL+"/v1/traces", nil)
+	err := e.Export(context.Background(), testResource, []*Span{{Name: "span"}})
+	assert.ErrorContains(t, err, "400")
+}
@@ -91,7 +91,7 @@ func (r *APIGatewayAPIKey) Remove(_ context.Context) error {
 }
 
 func (r *APIGatewayAPIKey) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedDate)
 }
 
 func (r *APIGatewayAPIKey) String() string {
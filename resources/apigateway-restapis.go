@@ -105,5 +105,6 @@ func (f *APIGatewayRestAPI) Properties() types.Properties {
 		Set("Name", f.name).
 		Set("Version", f.version).
 		Set("CreatedDate", f.createdDate.Format(time.RFC3339))
+	nuke.SetCreatedAt(properties, f.createdDate)
 	return properties
 }
@@ -97,5 +97,6 @@ func (f *APIGatewayV2API) Properties() types.Properties {
 		Set("ProtocolType", f.protocolType).
 		Set("Version", f.version).
 		Set("CreatedDate", f.createdDate.Format(time.RFC3339))
+	nuke.SetCreatedAt(properties, f.createdDate)
 	return properties
 }
@@ -84,6 +84,7 @@ func (asg *AutoScalingGroup) Properties() types.Properties {
 
 	properties.Set("CreatedTime", asg.group.CreatedTime)
 	properties.Set("Name", asg.group.AutoScalingGroupName)
+	nuke.SetCreatedAt(properties, asg.group.CreatedTime)
 
 	return properties
 }
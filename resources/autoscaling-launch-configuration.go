@@ -71,7 +71,7 @@ type AutoScalingLaunchConfiguration struct {
 }
 
 func (r *AutoScalingLaunchConfiguration) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *AutoScalingLaunchConfiguration) Remove(_ context.Context) error {
@@ -81,7 +81,7 @@ func (r *BedrockAgentCoreAPIKeyCredentialProvider) Remove(ctx context.Context) e
 }
 
 func (r *BedrockAgentCoreAPIKeyCredentialProvider) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *BedrockAgentCoreAPIKeyCredentialProvider) String() string {
@@ -96,7 +96,7 @@ func (r *BedrockAgentCoreOauth2CredentialProvider) Remove(ctx context.Context) e
 }
 
 func (r *BedrockAgentCoreOauth2CredentialProvider) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *BedrockAgentCoreOauth2CredentialProvider) String() string {
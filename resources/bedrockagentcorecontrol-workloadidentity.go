@@ -100,7 +100,7 @@ func (r *BedrockAgentCoreWorkloadIdentity) Remove(ctx context.Context) error {
 }
 
 func (r *BedrockAgentCoreWorkloadIdentity) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *BedrockAgentCoreWorkloadIdentity) String() string {
@@ -338,7 +338,7 @@ func (r *CloudFormationStack) waitForStackToStabilize(currentStatus string) erro
 }
 
 func (r *CloudFormationStack) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *CloudFormationStack) String() string {
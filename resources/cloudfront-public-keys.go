@@ -86,6 +86,7 @@ func (f *CloudFrontPublicKey) Properties() types.Properties {
 	properties.Set("ID", f.ID)
 	properties.Set("Name", f.name)
 	properties.Set("CreatedTime", f.createdTime.Format(time.RFC3339))
+	nuke.SetCreatedAt(properties, f.createdTime)
 	return properties
 }
 
@@ -167,7 +167,7 @@ func (r *CloudWatchLogsLogGroup) String() string {
 }
 
 func (r *CloudWatchLogsLogGroup) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r).
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime).
 		Set("logGroupName", r.Name) // TODO(v4): remove this property
 }
 
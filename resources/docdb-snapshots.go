@@ -100,7 +100,7 @@ func (r *DocDBSnapshot) Remove(ctx context.Context) error {
 }
 
 func (r *DocDBSnapshot) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.SnapshotCreateTime)
 }
 
 func (r *DocDBSnapshot) String() string {
@@ -158,7 +158,7 @@ func (r *DSQLCluster) Settings(settings *libsettings.Setting) {
 }
 
 func (r *DSQLCluster) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *DSQLCluster) String() string {
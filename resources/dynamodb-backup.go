@@ -95,7 +95,7 @@ func (r *DynamoDBBackup) Remove(_ context.Context) error {
 }
 
 func (r *DynamoDBBackup) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *DynamoDBBackup) String() string {
@@ -168,6 +168,7 @@ func (e *EC2Image) Properties() types.Properties {
 	for _, tagValue := range e.tags {
 		properties.SetTag(tagValue.Key, tagValue.Value)
 	}
+	nuke.SetCreatedAt(properties, e.creationDate)
 	return properties
 }
 
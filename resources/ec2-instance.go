@@ -181,7 +181,7 @@ func (i *EC2Instance) DisableTerminationProtection() error {
 }
 
 func (i *EC2Instance) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(i)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(i), i.LaunchTime)
 }
 
 func (i *EC2Instance) String() string {
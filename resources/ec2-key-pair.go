@@ -72,7 +72,7 @@ func (r *EC2KeyPair) Remove(_ context.Context) error {
 }
 
 func (r *EC2KeyPair) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateTime)
 }
 
 func (r *EC2KeyPair) String() string {
@@ -85,6 +85,7 @@ func (n *EC2NATGateway) Properties() types.Properties {
 	for _, tagValue := range n.natgw.Tags {
 		properties.SetTag(tagValue.Key, tagValue.Value)
 	}
+	nuke.SetCreatedAt(properties, n.natgw.CreateTime)
 	return properties
 }
 
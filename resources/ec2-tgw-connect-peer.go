@@ -80,7 +80,7 @@ func (r *EC2TGWConnectPeer) Remove(_ context.Context) error {
 }
 
 func (r *EC2TGWConnectPeer) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *EC2TGWConnectPeer) String() string {
@@ -91,7 +91,7 @@ func (r *EC2VerifiedAccessEndpoint) Remove(ctx context.Context) error {
 }
 
 func (r *EC2VerifiedAccessEndpoint) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *EC2VerifiedAccessEndpoint) String() string {
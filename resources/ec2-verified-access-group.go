@@ -87,7 +87,7 @@ func (r *EC2VerifiedAccessGroup) Remove(ctx context.Context) error {
 }
 
 func (r *EC2VerifiedAccessGroup) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *EC2VerifiedAccessGroup) String() string {
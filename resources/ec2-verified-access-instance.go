@@ -95,7 +95,7 @@ func (r *EC2VerifiedAccessInstance) Remove(ctx context.Context) error {
 }
 
 func (r *EC2VerifiedAccessInstance) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *EC2VerifiedAccessInstance) String() string {
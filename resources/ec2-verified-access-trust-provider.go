@@ -83,7 +83,7 @@ func (r *EC2VerifiedAccessTrustProvider) Remove(ctx context.Context) error {
 }
 
 func (r *EC2VerifiedAccessTrustProvider) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *EC2VerifiedAccessTrustProvider) String() string {
@@ -95,7 +95,7 @@ func (r *EC2Volume) Remove(ctx context.Context) error {
 }
 
 func (r *EC2Volume) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateTime)
 }
 
 func (r *EC2Volume) String() string {
@@ -95,6 +95,7 @@ func (r *ECRPublicRepository) Properties() types.Properties {
 	for _, t := range r.tags {
 		props.SetTag(t.Key, t.Value)
 	}
+	nuke.SetCreatedAt(props, r.createdTime)
 
 	return props
 }
@@ -91,6 +91,7 @@ func (r *ECRRepository) Properties() types.Properties {
 	for _, t := range r.tags {
 		properties.SetTag(t.Key, t.Value)
 	}
+	nuke.SetCreatedAt(properties, r.createdTime)
 	return properties
 }
 
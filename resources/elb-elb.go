@@ -101,6 +101,7 @@ func (e *ELBLoadBalancer) Properties() types.Properties {
 	for _, tagValue := range e.tags {
 		properties.SetTag(tagValue.Key, tagValue.Value)
 	}
+	nuke.SetCreatedAt(properties, e.elb.CreatedTime)
 
 	return properties
 }
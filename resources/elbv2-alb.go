@@ -150,7 +150,7 @@ func (r *ELBv2LoadBalancer) DisableProtection() error {
 }
 
 func (r *ELBv2LoadBalancer) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *ELBv2LoadBalancer) String() string {
@@ -90,6 +90,7 @@ func (f *EMRCluster) Remove(_ context.Context) error {
 func (f *EMRCluster) Properties() types.Properties {
 	properties := types.NewProperties().
 		Set("CreatedTime", f.cluster.Status.Timeline.CreationDateTime.Format(time.RFC3339))
+	nuke.SetCreatedAt(properties, f.cluster.Status.Timeline.CreationDateTime)
 
 	return properties
 }
@@ -94,7 +94,7 @@ func (r *GameLiftBuild) Remove(_ context.Context) error {
 }
 
 func (r *GameLiftBuild) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *GameLiftBuild) String() string {
@@ -94,7 +94,7 @@ func (r *GameLiftMatchmakingConfiguration) Remove(_ context.Context) error {
 }
 
 func (r *GameLiftMatchmakingConfiguration) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *GameLiftMatchmakingConfiguration) String() string {
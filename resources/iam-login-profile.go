@@ -94,7 +94,7 @@ func (r *IAMLoginProfile) Remove(_ context.Context) error {
 }
 
 func (r *IAMLoginProfile) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *IAMLoginProfile) String() string {
@@ -122,7 +122,7 @@ func (r *IAMPolicy) Remove(_ context.Context) error {
 }
 
 func (r *IAMPolicy) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *IAMPolicy) String() string {
@@ -124,7 +124,7 @@ func (r *IAMRole) Remove(_ context.Context) error {
 }
 
 func (r *IAMRole) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *IAMRole) String() string {
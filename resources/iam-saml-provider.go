@@ -68,7 +68,7 @@ func (r *IAMSAMLProvider) Remove(_ context.Context) error {
 }
 
 func (r *IAMSAMLProvider) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *IAMSAMLProvider) String() string {
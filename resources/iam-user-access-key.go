@@ -60,6 +60,7 @@ func (e *IAMUserAccessKey) Properties() types.Properties {
 	for _, tag := range e.userTags {
 		properties.SetTag(tag.Key, tag.Value)
 	}
+	nuke.SetCreatedAt(properties, e.createDate)
 
 	return properties
 }
@@ -82,7 +82,7 @@ func (r *IAMUser) String() string {
 }
 
 func (r *IAMUser) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate)
 }
 
 func (r *IAMUser) Settings(settings *libsettings.Setting) {
@@ -97,5 +97,5 @@ func (r *KMSAlias) String() string {
 }
 
 func (r *KMSAlias) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
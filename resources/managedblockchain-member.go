@@ -94,6 +94,7 @@ func (r *ManagedBlockchainMember) Properties() types.Properties {
 	properties.Set("CreationDate", r.member.CreationDate)
 	properties.Set("IsOwned", r.member.IsOwned)
 	properties.Set("Status", r.member.Status)
+	nuke.SetCreatedAt(properties, r.member.CreationDate)
 	return properties
 }
 
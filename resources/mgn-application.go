@@ -103,7 +103,7 @@ func (f *MGNApplication) Remove(ctx context.Context) error {
 }
 
 func (f *MGNApplication) Properties() libtypes.Properties {
-	return libtypes.NewPropertiesFromStruct(f)
+	return nuke.SetCreatedAt(libtypes.NewPropertiesFromStruct(f), f.CreationDateTime)
 }
 
 func (f *MGNApplication) String() string {
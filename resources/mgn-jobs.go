@@ -109,7 +109,7 @@ func (f *MGNJob) Remove(ctx context.Context) error {
 }
 
 func (f *MGNJob) Properties() libtypes.Properties {
-	return libtypes.NewPropertiesFromStruct(f)
+	return nuke.SetCreatedAt(libtypes.NewPropertiesFromStruct(f), f.CreationDateTime)
 }
 
 func (f *MGNJob) String() string {
@@ -103,7 +103,7 @@ func (f *MGNWave) Remove(ctx context.Context) error {
 }
 
 func (f *MGNWave) Properties() libtypes.Properties {
-	return libtypes.NewPropertiesFromStruct(f)
+	return nuke.SetCreatedAt(libtypes.NewPropertiesFromStruct(f), f.CreationDateTime)
 }
 
 func (f *MGNWave) String() string {
@@ -101,5 +101,5 @@ func (r *NeptuneSnapshot) String() string {
 }
 
 func (r *NeptuneSnapshot) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateTime)
 }
@@ -94,7 +94,7 @@ func (r *OSPackage) Remove(_ context.Context) error {
 }
 
 func (r *OSPackage) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedTime)
 }
 
 func (r *OSPackage) String() string {
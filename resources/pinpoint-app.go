@@ -68,7 +68,7 @@ type PinpointApp struct {
 }
 
 func (r *PinpointApp) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *PinpointApp) Remove(_ context.Context) error {
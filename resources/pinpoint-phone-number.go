@@ -76,7 +76,7 @@ type PinpointPhoneNumber struct {
 }
 
 func (r *PinpointPhoneNumber) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreatedDate)
 }
 
 func (r *PinpointPhoneNumber) Remove(_ context.Context) error {
@@ -81,7 +81,7 @@ func (r *PipesPipes) Remove(_ context.Context) error {
 }
 
 func (r *PipesPipes) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *PipesPipes) String() string {
@@ -109,6 +109,7 @@ func (l *QLDBLedger) Properties() types.Properties {
 	properties.Set("State", l.ledger.State)
 	properties.Set("PermissionsMode", l.ledger.PermissionsMode)
 	properties.Set("EncryptionDescription", l.ledger.EncryptionDescription)
+	nuke.SetCreatedAt(properties, l.ledger.CreationDateTime)
 	return properties
 }
 func (l *QLDBLedger) String() string {
@@ -105,6 +105,7 @@ func (i *RDSSnapshot) Properties() types.Properties {
 
 	if i.snapshot != nil && i.snapshot.SnapshotCreateTime != nil {
 		properties.Set("CreatedTime", i.snapshot.SnapshotCreateTime.Format(time.RFC3339))
+		nuke.SetCreatedAt(properties, i.snapshot.SnapshotCreateTime)
 	}
 
 	for _, tag := range i.tags {
@@ -71,6 +71,7 @@ func (f *RedshiftCluster) Properties() types.Properties {
 	for _, tag := range f.cluster.Tags {
 		properties.SetTag(tag.Key, tag.Value)
 	}
+	nuke.SetCreatedAt(properties, f.cluster.ClusterCreateTime)
 
 	return properties
 }
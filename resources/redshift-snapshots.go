@@ -71,6 +71,7 @@ func (f *RedshiftSnapshot) Properties() types.Properties {
 	for _, tag := range f.snapshot.Tags {
 		properties.SetTag(tag.Key, tag.Value)
 	}
+	nuke.SetCreatedAt(properties, f.snapshot.SnapshotCreateTime)
 
 	return properties
 }
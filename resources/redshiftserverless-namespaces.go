@@ -70,6 +70,7 @@ func (n *RedshiftServerlessNamespace) Properties() types.Properties {
 	properties := types.NewProperties().
 		Set("CreationDate", n.namespace.CreationDate).
 		Set("NamespaceName", n.namespace.NamespaceName)
+	nuke.SetCreatedAt(properties, n.namespace.CreationDate)
 
 	return properties
 }
@@ -71,6 +71,7 @@ func (s *RedshiftServerlessSnapshot) Properties() types.Properties {
 		Set("CreateTime", s.snapshot.SnapshotCreateTime).
 		Set("Namespace", s.snapshot.NamespaceName).
 		Set("SnapshotName", s.snapshot.SnapshotName)
+	nuke.SetCreatedAt(properties, s.snapshot.SnapshotCreateTime)
 
 	return properties
 }
@@ -71,6 +71,7 @@ func (w *RedshiftServerlessWorkgroup) Properties() types.Properties {
 		Set("CreationDate", w.workgroup.CreationDate).
 		Set("Namespace", w.workgroup.NamespaceName).
 		Set("WorkgroupName", w.workgroup.WorkgroupName)
+	nuke.SetCreatedAt(properties, w.workgroup.CreationDate)
 
 	return properties
 }
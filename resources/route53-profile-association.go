@@ -107,7 +107,7 @@ func (r *Route53ProfileAssociation) Remove(ctx context.Context) error {
 }
 
 func (r *Route53ProfileAssociation) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *Route53ProfileAssociation) String() string {
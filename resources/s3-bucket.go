@@ -258,7 +258,7 @@ func (r *S3Bucket) Settings(settings *libsettings.Setting) {
 }
 
 func (r *S3Bucket) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *S3Bucket) String() string {
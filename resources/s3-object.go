@@ -119,7 +119,7 @@ func (r *S3Object) Remove(ctx context.Context) error {
 }
 
 func (r *S3Object) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *S3Object) String() string {
@@ -116,6 +116,7 @@ func (f *SageMakerDomain) Properties() types.Properties {
 	for _, tag := range f.tags {
 		properties.SetTag(tag.Key, tag.Value)
 	}
+	nuke.SetCreatedAt(properties, f.creationTime)
 
 	return properties
 }
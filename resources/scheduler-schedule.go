@@ -72,7 +72,7 @@ func (r *SchedulerSchedule) Remove(_ context.Context) error {
 }
 
 func (r *SchedulerSchedule) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *SchedulerSchedule) String() string {
@@ -92,7 +92,7 @@ func (r *SFNStateMachine) Remove(_ context.Context) error {
 }
 
 func (r *SFNStateMachine) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationDate)
 }
 
 func (r *SFNStateMachine) String() string {
@@ -110,7 +110,7 @@ func (r *TextractAdapterVersion) Remove(ctx context.Context) error {
 }
 
 func (r *TextractAdapterVersion) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *TextractAdapterVersion) String() string {
@@ -90,7 +90,7 @@ func (r *TextractAdapter) Remove(ctx context.Context) error {
 }
 
 func (r *TextractAdapter) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r)
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreationTime)
 }
 
 func (r *TextractAdapter) String() string {
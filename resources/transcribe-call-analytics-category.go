@@ -92,6 +92,7 @@ func (r *TranscribeCallAnalyticsCategory) Properties() types.Properties {
 	properties.Set("InputType", r.inputType)
 	properties.Set("CreateTime", r.createTime)
 	properties.Set("LastUpdateTime", r.lastUpdateTime)
+	nuke.SetCreatedAt(properties, r.createTime)
 	return properties
 }
 
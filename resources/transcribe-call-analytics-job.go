@@ -103,6 +103,7 @@ func (r *TranscribeCallAnalyticsJob) Properties() types.Properties {
 	properties.Set("FailureReason", r.failureReason)
 	properties.Set("LanguageCode", r.languageCode)
 	properties.Set("StartTime", r.startTime)
+	nuke.SetCreatedAt(properties, r.creationTime)
 	return properties
 }
 
@@ -99,6 +99,7 @@ func (r *TranscribeLanguageModel) Properties() types.Properties {
 	properties.Set("LastModifiedTime", r.lastModifiedTime)
 	properties.Set("ModelStatus", r.modelStatus)
 	properties.Set("UpgradeAvailability", r.upgradeAvailability)
+	nuke.SetCreatedAt(properties, r.createTime)
 	return properties
 }
 
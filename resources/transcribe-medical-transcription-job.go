@@ -108,6 +108,7 @@ func (r *TranscribeMedicalTranscriptionJob) Properties() types.Properties {
 	properties.Set("Specialty", r.specialty)
 	properties.Set("StartTime", r.startTime)
 	properties.Set("InputType", r.inputType)
+	nuke.SetCreatedAt(properties, r.creationTime)
 	return properties
 }
 
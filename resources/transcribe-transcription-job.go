@@ -96,6 +96,7 @@ func (r *TranscribeTranscriptionJob) Properties() types.Properties {
 	properties.Set("FailureReason", r.failureReason)
 	properties.Set("LanguageCode", r.languageCode)
 	properties.Set("StartTime", r.startTime)
+	nuke.SetCreatedAt(properties, r.creationTime)
 	return properties
 }
 
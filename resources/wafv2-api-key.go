@@ -117,7 +117,7 @@ func (r *WAFv2APIKey) String() string {
 }
 
 func (r *WAFv2APIKey) Properties() types.Properties {
-	return types.NewPropertiesFromStruct(r).
+	return nuke.SetCreatedAt(types.NewPropertiesFromStruct(r), r.CreateDate).
 		// Note: this is necessary because NewPropertiesFromStruct doesn't handle slices of strings
 		Set("TokenDomains", strings.Join(r.TokenDomains, ","))
 }